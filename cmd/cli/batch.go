@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/philiplinell/commit-msg/internal/batch"
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/style"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+	"github.com/urfave/cli"
+)
+
+const (
+	// maxBatchDiffTokens is the most of a diff sent for a commit, so a
+	// commit vendoring dependencies does not spend the budget.
+	maxBatchDiffTokens = 16000
+
+	// batchAnswerTokens is the estimated tokens of an answer, added to the
+	// tokens of a request for the tokens per minute.
+	batchAnswerTokens = 500
+)
+
+//nolint:gochecknoglobals
+var (
+	batchBudgetFlag  float64
+	batchOutFlag     string
+	batchReposFlag   cli.StringSlice
+	batchRPMFlag     int
+	batchTPMFlag     int
+	batchWorkersFlag int
+)
+
+//nolint:gochecknoglobals
+var batchCommand = cli.Command{
+	Name:      "batch",
+	Usage:     "suggest messages for many commits, e.g. to audit the messages of a repository",
+	ArgsUsage: "[<revision>...]",
+	Description: `Suggests a message for each commit of the revisions, e.g. main~100..main, in
+   each repository of --repo. Without revisions they are read from stdin, one
+   per line, optionally preceded by the repository: "<repo> <revision>". Merge
+   commits are skipped.
+
+   The results are written as JSON lines to --out, with the current and the
+   suggested message. If the file exists the commits already in it are
+   skipped, so an interrupted batch is resumed by running it again.`,
+	Action: batchAction,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "repo",
+			Usage: "the directory of a repository, may be repeated. The current directory if not set",
+			Value: &batchReposFlag,
+		},
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "the file to write the results to as JSON lines, and to resume from. Stdout if not set",
+			Destination: &batchOutFlag,
+		},
+		&cli.IntFlag{
+			Name:        "workers",
+			Usage:       "the number of commits suggested at the same time",
+			Value:       4,
+			Destination: &batchWorkersFlag,
+		},
+		&cli.IntFlag{
+			Name:        "rpm",
+			Usage:       "the most requests to the models per minute, a commit may take several. No limit if 0",
+			Destination: &batchRPMFlag,
+		},
+		&cli.IntFlag{
+			Name:        "tpm",
+			Usage:       "the most tokens per minute, estimated from the diffs, no limit if 0",
+			Destination: &batchTPMFlag,
+		},
+		&cli.Float64Flag{
+			Name:        "budget",
+			Usage:       "the most to spend in cent, including the results of earlier runs in --out. No new commits are started once it is spent, no limit if 0",
+			Destination: &batchBudgetFlag,
+		},
+	},
+}
+
+func batchAction(c *cli.Context) error {
+	if batchWorkersFlag < 1 {
+		log.Fatalf("invalid workers %d, must be at least 1", batchWorkersFlag)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repos := []string(batchReposFlag)
+	if len(repos) == 0 {
+		repos = []string{"."}
+	}
+
+	var (
+		jobs []batch.Job
+		err  error
+	)
+
+	if c.NArg() > 0 {
+		for _, repo := range repos {
+			for _, revision := range c.Args() {
+				jobs = appendJobs(ctx, jobs, repo, revision)
+			}
+		}
+	} else {
+		jobs, err = readJobs(ctx, os.Stdin, repos)
+		if err != nil {
+			log.Fatalf("could not read the revisions: %s", err)
+		}
+	}
+
+	done := map[string]bool{}
+	spent := 0.0
+	out := io.Writer(os.Stdout)
+
+	if batchOutFlag != "" {
+		file, err := os.OpenFile(batchOutFlag, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalf("could not open %q: %s", batchOutFlag, err)
+		}
+
+		defer file.Close()
+
+		done, spent, err = batch.ReadResults(file)
+		if err != nil {
+			log.Fatalf("could not read the results in %q: %s", batchOutFlag, err)
+		}
+
+		out = file
+	}
+
+	pending := make([]batch.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if !done[job.Key()] {
+			pending = append(pending, job)
+		}
+	}
+
+	if len(pending) < len(jobs) {
+		log.Printf("Resuming, %d of %d commits are done (%.2f cent)", len(jobs)-len(pending), len(jobs), spent)
+	}
+
+	// Each request to a model is limited, also those of a fallback, a hedge
+	// or a self-check, so a commit may take several.
+	limiter := batch.NewLimiter(batchRPMFlag, batchTPMFlag)
+
+	p, err := newProviderFromFlags(func(model provider.Provider) provider.Provider {
+		return batch.Limit(model, limiter, batchAnswerTokens)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	suggester := &batchSuggester{
+		provider: p,
+		repos:    map[string]*batchRepo{},
+	}
+
+	encoder := json.NewEncoder(out)
+	failed := 0
+
+	runner := batch.Runner{
+		Workers: batchWorkersFlag,
+		Budget:  batchBudgetFlag,
+		Do:      suggester.suggest,
+		OnResult: func(result batch.Result, n int) {
+			if err := encoder.Encode(result); err != nil {
+				log.Fatalf("could not write the result: %s", err)
+			}
+
+			if result.Error != "" {
+				failed++
+				log.Printf("[%d/%d] %s: %s", n, len(pending), result.Key(), result.Error)
+
+				return
+			}
+
+			log.Printf("[%d/%d] %s", n, len(pending), result.Key())
+		},
+	}
+
+	spent, err = runner.Run(ctx, pending, spent)
+
+	if costFlag {
+		log.Printf("Cost %.2f cent in total", spent)
+	}
+
+	if failed > 0 {
+		log.Printf("%d commits failed, run the batch again to retry them", failed)
+	}
+
+	switch {
+	case errors.Is(err, batch.ErrBudgetExceeded):
+		log.Fatalf("stopped, the budget of %.2f cent is spent (%.2f cent)", batchBudgetFlag, spent)
+	case err != nil:
+		log.Fatalf("stopped: %s", err)
+	}
+
+	return nil
+}
+
+// appendJobs appends the commits of the revision in the repository, e.g. of
+// a range, to jobs. The revisions are resolved to hashes, so a resumed batch
+// skips the same commits. Merge commits are skipped.
+func appendJobs(ctx context.Context, jobs []batch.Job, repo, revision string) []batch.Job {
+	if !strings.Contains(revision, "..") {
+		hash, err := git.New(repo).RevParse(ctx, revision)
+		if err != nil {
+			log.Fatalf("could not resolve %q in %q: %s", revision, repo, err)
+		}
+
+		return append(jobs, batch.Job{Repo: repo, Revision: hash})
+	}
+
+	revisions, err := git.New(repo).RevList(ctx, revision)
+	if err != nil {
+		log.Fatalf("could not list the commits of %q in %q: %s", revision, repo, err)
+	}
+
+	// The oldest commit first, like the history is read.
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if len(revisions[i].Parents) > 1 {
+			continue
+		}
+
+		jobs = append(jobs, batch.Job{Repo: repo, Revision: revisions[i].Hash})
+	}
+
+	return jobs
+}
+
+// readJobs reads the revisions from r, one per line, optionally preceded by
+// the repository. Revisions without one are in each of repos.
+func readJobs(ctx context.Context, r io.Reader, repos []string) ([]batch.Job, error) {
+	var jobs []batch.Job
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		switch len(fields) {
+		case 0:
+		case 1:
+			for _, repo := range repos {
+				jobs = appendJobs(ctx, jobs, repo, fields[0])
+			}
+		case 2:
+			jobs = appendJobs(ctx, jobs, fields[0], fields[1])
+		default:
+			return nil, errors.New("expected a revision, or a repository and a revision, per line")
+		}
+	}
+
+	return jobs, scanner.Err()
+}
+
+// batchSuggester suggests the messages of the batch, with the styles and the
+// configuration of each repository.
+type batchSuggester struct {
+	provider provider.Provider
+
+	mu    sync.Mutex
+	repos map[string]*batchRepo
+}
+
+type batchRepo struct {
+	styles *style.Registry
+	cfg    commitassist.MessageConfig
+	err    error
+}
+
+// repo returns the styles and the configuration of the repository, loading
+// them the first time.
+func (s *batchSuggester) repo(dir string) *batchRepo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo, ok := s.repos[dir]; ok {
+		return repo
+	}
+
+	repo := &batchRepo{}
+	s.repos[dir] = repo
+
+	repoCfg, styles, err := loadConfig(dir)
+	if err != nil {
+		repo.err = err
+		return repo
+	}
+
+	repo.styles = styles
+	repo.cfg, repo.err = messageConfig(commitassist.New(s.provider, styles), repoCfg)
+
+	return repo
+}
+
+// suggest suggests the message of the commit of the job. The cost is of all
+// requests of the job, also if it failed after a provider was paid, e.g.
+// when the answer of a model was invalid.
+func (s *batchSuggester) suggest(ctx context.Context, job batch.Job) batch.Result {
+	result := batch.Result{Job: job}
+
+	batchRepo := s.repo(job.Repo)
+	if batchRepo.err != nil {
+		result.Error = batchRepo.err.Error()
+		return result
+	}
+
+	repo := git.New(job.Repo)
+
+	commits, err := repo.Log(ctx, git.LogOptions{Revision: job.Revision, MaxCount: 1})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if len(commits) == 0 {
+		result.Error = "no such commit"
+		return result
+	}
+
+	result.Message = commits[0].Message
+
+	gitDiff, err := repo.Show(ctx, job.Revision)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if strings.TrimSpace(gitDiff) == "" {
+		result.Error = "the commit has no changes"
+		return result
+	}
+
+	meter := batch.NewMeter(s.provider)
+
+	// The config is copied, since the workers share it.
+	cfg := batchRepo.cfg
+
+	response, err := commitassist.New(meter, batchRepo.styles).GetCommitMessage(ctx, tokens.Truncate(gitDiff, maxBatchDiffTokens), &cfg)
+
+	result.Cost = meter.Cost()
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Suggested = response.Message
+	result.Provider = response.Provider
+
+	return result
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/semver"
+	"github.com/urfave/cli"
+)
+
+// exitNoRelease is the exit code of bump when no commit requires a release.
+const exitNoRelease = 9
+
+//nolint:gochecknoglobals
+var (
+	bumpAIFlag         bool
+	bumpFromFlag       string
+	bumpOutputFlag     string
+	bumpPrereleaseFlag bool
+)
+
+//nolint:gochecknoglobals
+var bumpCommand = cli.Command{
+	Name:   "bump",
+	Usage:  "recommend the next semantic version from the commits since the last version tag",
+	Action: bumpAction,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "from",
+			Usage:       "the tag of the current version, the highest semantic version tag reachable from HEAD if not set",
+			Destination: &bumpFromFlag,
+		},
+		&cli.BoolFlag{
+			Name:        "ai",
+			Usage:       "if the model should classify the commits that are not conventional commits, instead of assuming patch",
+			Destination: &bumpAIFlag,
+		},
+		&cli.BoolFlag{
+			Name:        "prerelease",
+			Usage:       "if prerelease tags, e.g. v1.3.0-rc.1, can be the current version",
+			Destination: &bumpPrereleaseFlag,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "the output format, \"text\" (the next version followed by the justification) or \"json\"",
+			Value:       outputText,
+			Destination: &bumpOutputFlag,
+		},
+	},
+}
+
+// bumpCommit is the justification of a commit.
+type bumpCommit struct {
+	Hash    string       `json:"hash"`
+	Subject string       `json:"subject"`
+	Bump    semver.Level `json:"bump"`
+	Reason  string       `json:"reason"`
+}
+
+// bumpOutput is printed with --output json.
+type bumpOutput struct {
+	// Current is the tag of the current version, empty if there is none.
+	Current string       `json:"current"`
+	Next    string       `json:"next"`
+	Bump    semver.Level `json:"bump"`
+	Commits []bumpCommit `json:"commits"`
+}
+
+func bumpAction(_ *cli.Context) error {
+	if bumpOutputFlag != outputText && bumpOutputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", bumpOutputFlag, outputText, outputJSON)
+	}
+
+	ctx := context.Background()
+	repo := git.New(".")
+
+	current, tag := currentVersion(ctx, repo)
+
+	revision := git.HEAD
+	if tag != "" {
+		revision = tag + ".." + git.HEAD
+	}
+
+	commits, err := repo.Log(ctx, git.LogOptions{Revision: revision, NoMerges: true})
+	if err != nil {
+		log.Fatalf("could not get the commits: %s", err)
+	}
+
+	output := bumpOutput{
+		Current: tag,
+		Commits: make([]bumpCommit, 0, len(commits)),
+	}
+
+	var unclassified []int
+
+	for i, commit := range commits {
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+
+		level, reason, ok := semver.Classify(commit.Message)
+		if !ok {
+			level, reason = semver.Patch, "not a conventional commit, assumed to be a patch"
+			unclassified = append(unclassified, i)
+		}
+
+		output.Commits = append(output.Commits, bumpCommit{Hash: commit.Hash, Subject: subject, Bump: level, Reason: reason})
+	}
+
+	if bumpAIFlag && len(unclassified) > 0 {
+		classifyCommits(ctx, commits, unclassified, output.Commits)
+	}
+
+	for _, commit := range output.Commits {
+		if commit.Bump > output.Bump {
+			output.Bump = commit.Bump
+		}
+	}
+
+	output.Next = current.Bump(output.Bump).String()
+
+	if bumpOutputFlag == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(output); err != nil {
+			log.Fatalf("could not print the version: %s", err)
+		}
+	} else {
+		printBump(output)
+	}
+
+	if output.Bump == semver.None {
+		os.Exit(exitNoRelease)
+	}
+
+	return nil
+}
+
+// currentVersion returns the version of --from, or of the latest version tag.
+// Without tags the version is 0.0.0.
+func currentVersion(ctx context.Context, repo *git.Repo) (semver.Version, string) {
+	if bumpFromFlag != "" {
+		current, err := semver.Parse(bumpFromFlag)
+		if err != nil {
+			log.Fatalf("could not parse --from: %s", err)
+		}
+
+		return current, bumpFromFlag
+	}
+
+	tags, err := repo.Tags(ctx)
+	if err != nil {
+		log.Fatalf("could not get the tags: %s", err)
+	}
+
+	current, tag, ok := semver.Latest(tags, bumpPrereleaseFlag)
+	if !ok {
+		return semver.Version{Prefix: "v"}, ""
+	}
+
+	return current, tag
+}
+
+// classifyCommits asks the model to classify the commits at the indexes. If
+// it fails they are kept as patches if fallbackFlag is set.
+func classifyCommits(ctx context.Context, commits []git.Commit, indexes []int, justifications []bumpCommit) {
+	_, styles := mustLoadConfig()
+
+	messages := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		messages = append(messages, commits[i].Message)
+	}
+
+	response, err := commitassist.New(mustNewProvider(), styles).ClassifyCommits(ctx, messages)
+	if err != nil {
+		if !fallbackFlag {
+			handleError(err)
+		}
+
+		log.Printf("could not classify the commits, assuming patch: %s", err)
+
+		return
+	}
+
+	for j, i := range indexes {
+		justifications[i].Bump = response.Classifications[j].Level
+		justifications[i].Reason = response.Classifications[j].Reason
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+}
+
+func printBump(output bumpOutput) {
+	fmt.Println(output.Next)
+
+	from := output.Current
+	if from == "" {
+		from = "the first commit"
+	}
+
+	fmt.Printf("\n%s bump since %s:\n\n", output.Bump, from)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	for _, commit := range output.Commits {
+		hash := commit.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t(%s)\n", commit.Bump, hash, commit.Subject, commit.Reason)
+	}
+
+	// Errors writing to stdout are not handled, like in fmt.Println.
+	_ = w.Flush()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/philiplinell/commit-msg/internal/changelog"
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/urfave/cli"
+)
+
+const outputMarkdown = "markdown"
+
+//nolint:gochecknoglobals
+var (
+	changelogFromFlag    string
+	changelogToFlag      string
+	changelogReleaseFlag string
+	changelogDateFlag    string
+	changelogNoAIFlag    bool
+	changelogOutputFlag  string
+	changelogPrependFlag string
+)
+
+//nolint:gochecknoglobals
+var changelogCommand = cli.Command{
+	Name:   "changelog",
+	Usage:  "write the release notes of a range of commits in the format of Keep a Changelog",
+	Action: changelogAction,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "from",
+			Usage:       "the revision after which the release starts, e.g. the previous tag \"v1.2.0\". All commits if not set",
+			Destination: &changelogFromFlag,
+		},
+		&cli.StringFlag{
+			Name:        "to",
+			Usage:       "the last revision of the release",
+			Value:       git.HEAD,
+			Destination: &changelogToFlag,
+		},
+		&cli.StringFlag{
+			Name:        "release",
+			Usage:       "the version of the release, e.g. \"1.3.0\"",
+			Value:       changelog.Unreleased,
+			Destination: &changelogReleaseFlag,
+		},
+		&cli.StringFlag{
+			Name:        "date",
+			Usage:       "the date of the release, today if not set. Not used for Unreleased",
+			Destination: &changelogDateFlag,
+		},
+		&cli.BoolFlag{
+			Name:        "no-ai",
+			Usage:       "if the notes should be the commit messages as they are, without asking the model to rewrite them",
+			Destination: &changelogNoAIFlag,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "the output format, \"markdown\" or \"json\"",
+			Value:       outputMarkdown,
+			Destination: &changelogOutputFlag,
+		},
+		&cli.StringFlag{
+			Name:        "prepend",
+			Usage:       "the changelog file, e.g. CHANGELOG.md, to add the release to instead of printing it. A release with the same version is replaced",
+			Destination: &changelogPrependFlag,
+		},
+	},
+}
+
+func changelogAction(_ *cli.Context) error {
+	if changelogOutputFlag != outputMarkdown && changelogOutputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", changelogOutputFlag, outputMarkdown, outputJSON)
+	}
+
+	if changelogPrependFlag != "" && changelogOutputFlag == outputJSON {
+		log.Fatal("--prepend cannot be used with --output json")
+	}
+
+	revision := changelogToFlag
+	if changelogFromFlag != "" {
+		revision = changelogFromFlag + ".." + changelogToFlag
+	}
+
+	ctx := context.Background()
+
+	commits, err := git.New(".").Log(ctx, git.LogOptions{Revision: revision, NoMerges: true})
+	if err != nil {
+		log.Fatalf("could not get the commits: %s", err)
+	}
+
+	date := ""
+	if changelogReleaseFlag != changelog.Unreleased {
+		date = changelogDateFlag
+		if date == "" {
+			date = time.Now().Format("2006-01-02")
+		}
+	}
+
+	changelogCommits := make([]changelog.Commit, 0, len(commits))
+	for _, commit := range commits {
+		changelogCommits = append(changelogCommits, changelog.Commit{Hash: commit.Hash, Message: commit.Message})
+	}
+
+	release := changelog.Build(changelogReleaseFlag, date, changelogCommits)
+
+	if !changelogNoAIFlag && len(release.Entries()) > 0 {
+		release = rewriteReleaseNotes(ctx, release)
+	}
+
+	if changelogPrependFlag != "" {
+		existing, err := os.ReadFile(changelogPrependFlag)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatalf("could not read %q: %s", changelogPrependFlag, err)
+		}
+
+		//nolint:gosec
+		if err := os.WriteFile(changelogPrependFlag, []byte(changelog.Prepend(string(existing), release)), 0o644); err != nil {
+			log.Fatalf("could not write %q: %s", changelogPrependFlag, err)
+		}
+
+		return nil
+	}
+
+	if changelogOutputFlag == outputJSON {
+		out, err := release.JSON()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println(out)
+
+		return nil
+	}
+
+	fmt.Println(release.Markdown())
+
+	return nil
+}
+
+// rewriteReleaseNotes asks the model to rewrite the notes. If it fails the
+// notes are kept as they are if fallbackFlag is set.
+func rewriteReleaseNotes(ctx context.Context, release changelog.Release) changelog.Release {
+	repoCfg, styles := mustLoadConfig()
+
+	response, err := commitassist.New(mustNewProvider(), styles).RewriteReleaseNotes(ctx, release, messageLanguage(repoCfg))
+	if err != nil {
+		if !fallbackFlag {
+			handleError(err)
+		}
+
+		log.Printf("could not rewrite the release notes, using the commit messages: %s", err)
+
+		return release
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	return response.Release
+}
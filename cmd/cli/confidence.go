@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/confidence"
+)
+
+// exitAbstained is the exit code when the suggestion is below the confidence
+// threshold and not used.
+const exitAbstained = 7
+
+// applyConfidencePolicy returns the response to use when its confidence is
+// below the threshold of the policy: the response if the user accepts it, or
+// a fallback message. Otherwise the program exits with exitAbstained.
+//
+// The confidence of a message of the heuristic provider, e.g. with --fallback
+// when the models failed, is unknown and below any threshold. With the
+// fallback action it is used as is, it is the fallback message.
+func applyConfidencePolicy(response commitassist.GetTypeResponse, policy confidence.Policy, gitDiff string, cfg *commitassist.MessageConfig) commitassist.GetTypeResponse {
+	if !policy.Below(response.Confidence) {
+		return response
+	}
+
+	heuristic := response.Provider == providerHeuristic
+
+	switch policy.LowAction() {
+	case confidence.Prompt:
+		question := fmt.Sprintf("The confidence of the suggestion is %s (%s):\n\n%s\n\nUse it anyway? [y/N] ",
+			formatConfidence(response.Confidence), response.Structured.Reasoning, response.Message)
+
+		if confirm(question) {
+			return response
+		}
+	case confidence.Fallback:
+		if heuristic {
+			log.Print("the confidence of the message derived from the changed files is unknown, using it as the fallback")
+			return response
+		}
+
+		log.Printf("the confidence %.2f is below %.2f, using a message derived from the changed files", response.Confidence, policy.Threshold)
+
+		fallback, err := commitassist.FallbackMessage(gitDiff, cfg)
+		if err != nil {
+			handleError(err)
+		}
+
+		fallback.Cost = response.Cost
+
+		return fallback
+	case confidence.Abstain:
+	}
+
+	fmt.Printf("Abstained, the confidence %s is below %.2f: %s\n", formatConfidence(response.Confidence), policy.Threshold, response.Structured.Reasoning)
+	os.Exit(exitAbstained)
+
+	return response
+}
+
+// formatConfidence returns the confidence with two decimals, or "unknown".
+func formatConfidence(c float64) string {
+	if c == confidence.Unknown {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%.2f", c)
+}
+
+// confirm asks the question on the terminal, also when stdin and stdout are
+// used by git. It returns false if there is no terminal.
+func confirm(question string) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		log.Printf("could not open the terminal to ask: %s", err)
+		return false
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, question)
+
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/config"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+// loadConfig returns the configuration and the styles of the repository in
+// dir. A relative styles directory is relative to dir.
+func loadConfig(dir string) (config.Config, *style.Registry, error) {
+	repoCfg, err := config.Load(dir)
+	if err != nil {
+		return config.Config{}, nil, fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	if repoCfg.StylesDir == "" {
+		repoCfg.StylesDir = config.DefaultStylesDir
+	}
+
+	if !filepath.IsAbs(repoCfg.StylesDir) {
+		repoCfg.StylesDir = filepath.Join(dir, repoCfg.StylesDir)
+	}
+
+	styles, err := loadStyles(repoCfg)
+	if err != nil {
+		return config.Config{}, nil, fmt.Errorf("could not load styles: %w", err)
+	}
+
+	return repoCfg, styles, nil
+}
+
+// mustLoadConfig is loadConfig for the working directory, it exits on error.
+func mustLoadConfig() (config.Config, *style.Registry) {
+	repoCfg, styles, err := loadConfig(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return repoCfg, styles
+}
+
+// messageLanguage returns the language of the messages, --language overrides
+// the configuration.
+func messageLanguage(repoCfg config.Config) string {
+	if languageFlag != "" {
+		return languageFlag
+	}
+
+	return repoCfg.Language
+}
+
+// messageConfig returns the configuration of the commit messages, the
+// configuration of the repository overridden by the flags.
+func messageConfig(client *commitassist.Client, repoCfg config.Config) (commitassist.MessageConfig, error) {
+	validStyle, err := client.ValidateMessageStyle(styleFlag)
+	if err != nil {
+		return commitassist.MessageConfig{}, fmt.Errorf("could not validate style %q: %w", styleFlag, err)
+	}
+
+	lang := messageLanguage(repoCfg)
+	if _, err := language.Parse(lang); err != nil {
+		return commitassist.MessageConfig{}, fmt.Errorf("could not parse language: %w", err)
+	}
+
+	return commitassist.MessageConfig{
+		Style:                       validStyle,
+		ConventionalCommitCompliant: conventionalCommit || repoCfg.ConventionalCommit,
+		ConventionalRules:           repoCfg.Conventional,
+		Issues:                      repoCfg.Issues,
+		Gitmoji:                     repoCfg.Gitmoji,
+		Language:                    lang,
+	}, nil
+}
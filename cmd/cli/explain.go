@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/explain"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/urfave/cli"
+)
+
+//nolint:gochecknoglobals
+var (
+	explainFileFlag   string
+	explainOutputFlag string
+	explainStagedFlag bool
+)
+
+//nolint:gochecknoglobals
+var explainCommand = cli.Command{
+	Name:      "explain",
+	Usage:     "explain a diff to a reviewer, file by file, with the risks to look at",
+	ArgsUsage: "[<rev>]",
+	Description: `Explains the changes of the commit rev, the staged changes with --staged, or
+   the diff in a file with --file. The staged changes are explained if none is
+   given.`,
+	Action: explainAction,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "staged",
+			Usage:       "if the staged changes should be explained",
+			Destination: &explainStagedFlag,
+		},
+		&cli.StringFlag{
+			Name:        "file",
+			Usage:       "the file with the diff to explain, e.g. a patch, \"-\" for stdin",
+			Destination: &explainFileFlag,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "the output format, \"markdown\" or \"json\"",
+			Value:       outputMarkdown,
+			Destination: &explainOutputFlag,
+		},
+	},
+}
+
+// explainJSONOutput is printed with --output json.
+type explainJSONOutput struct {
+	explain.Explanation
+
+	Provider string  `json:"provider"`
+	Cost     float64 `json:"cost"`
+}
+
+func explainAction(c *cli.Context) error {
+	if explainOutputFlag != outputMarkdown && explainOutputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", explainOutputFlag, outputMarkdown, outputJSON)
+	}
+
+	sources := c.NArg()
+	if explainStagedFlag {
+		sources++
+	}
+
+	if explainFileFlag != "" {
+		sources++
+	}
+
+	if sources > 1 {
+		log.Fatal("expected one of a revision, --staged or --file")
+	}
+
+	gitDiff := explainDiff(c)
+	if strings.TrimSpace(gitDiff) == "" {
+		log.Fatal("there are no changes to explain")
+	}
+
+	repoCfg, styles := mustLoadConfig()
+
+	response, err := commitassist.New(mustNewProvider(), styles).ExplainDiff(context.Background(), gitDiff, messageLanguage(repoCfg))
+	if err != nil {
+		handleError(err)
+	}
+
+	if explainOutputFlag == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(explainJSONOutput{
+			Explanation: response.Explanation,
+			Provider:    response.Provider,
+			Cost:        response.Cost,
+		})
+	} else {
+		_, err = fmt.Print(response.Explanation.Markdown())
+	}
+
+	if err != nil {
+		log.Fatalf("could not print the explanation: %s", err)
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	return nil
+}
+
+// explainDiff returns the diff to explain.
+func explainDiff(c *cli.Context) string {
+	ctx := context.Background()
+	repo := git.New(".")
+
+	switch {
+	case c.NArg() == 1:
+		gitDiff, err := repo.Show(ctx, c.Args().First())
+		if err != nil {
+			log.Fatalf("could not get the diff of %q: %s", c.Args().First(), err)
+		}
+
+		return gitDiff
+	case explainFileFlag == "-":
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("could not read stdin: %s", err)
+		}
+
+		return string(content)
+	case explainFileFlag != "":
+		content, err := os.ReadFile(explainFileFlag)
+		if err != nil {
+			log.Fatalf("could not read file %q: %s", explainFileFlag, err)
+		}
+
+		return string(content)
+	default:
+		gitDiff, err := repo.DiffIndex(ctx, git.HEAD)
+		if err != nil {
+			log.Fatalf("could not get the staged changes: %s", err)
+		}
+
+		return gitDiff
+	}
+}
@@ -1,36 +1,61 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"strings"
 	"time"
 
-	"github.com/caarlos0/env"
+	"github.com/philiplinell/commit-msg/internal/apidiff"
 	"github.com/philiplinell/commit-msg/internal/build"
 	"github.com/philiplinell/commit-msg/internal/commitassist"
-	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/commitfile"
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/fewshot"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/issue"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/trailer"
 	"github.com/urfave/cli"
 )
 
-type config struct {
+type envConfig struct {
 	APIKey string `env:"OPENAI_API_KEY"`
+
+	AnthropicAPIKey  string `env:"ANTHROPIC_API_KEY"`
+	AnthropicBaseURL string `env:"ANTHROPIC_BASE_URL"`
+
+	GeminiAPIKey  string `env:"GEMINI_API_KEY"`
+	GeminiBaseURL string `env:"GEMINI_BASE_URL"`
+	GeminiAuth    string `env:"GEMINI_AUTH"`
 }
 
 //nolint:gochecknoglobals
 var (
+	breakingChanges    bool
 	conventionalCommit bool
 	costFlag           bool
+	fallbackFlag       bool
 	filename           string
-	style              string
+	hedgeFlag          string
+	languageFlag       string
+	lowConfidenceFlag  string
+	minConfidenceFlag  float64
+	outputFlag         string
+	providerFlag       string
+	reviewFlag         bool
+	selfCheckFlag      bool
+	styleFlag          string
 	timeoutFlag        string
+	writeFlag          bool
 )
 
+// recentCommits is the number of commits searched for co-authors to suggest.
+const recentCommits = 50
+
 func main() {
 	buildInfo, err := build.GetInfo()
 	if err != nil {
@@ -54,9 +79,14 @@ func main() {
 				Usage:       "if the commit should be conventional commit compliant",
 				Destination: &conventionalCommit,
 			},
+			&cli.BoolFlag{
+				Name:        "breaking-changes",
+				Usage:       "if breaking changes to exported Go identifiers should be detected by comparing HEAD and the staged files, within the timeout",
+				Destination: &breakingChanges,
+			},
 			&cli.StringFlag{
 				Name:        "timeout",
-				Usage:       "the timeout for the request to OpenAI",
+				Usage:       "the timeout for the request to each provider",
 				Value:       "5s",
 				Destination: &timeoutFlag,
 			},
@@ -64,15 +94,80 @@ func main() {
 				Name:        "file",
 				Usage:       "the file where the changes are. Usually this will be $COMMIT_MSG_FILE set in prepare-commit-msg hook",
 				Destination: &filename,
-				Required:    true,
+			},
+			&cli.BoolFlag{
+				Name:        "write",
+				Usage:       "if the message should be written to the file given by --file, merging the trailers already in it, instead of printed to stdout",
+				Destination: &writeFlag,
 			},
 			&cli.StringFlag{
 				Name: "style",
 				Usage: "the style of the commit message, e.g. " +
-					fmt.Sprintf("%q, %q, %q or %q", commitassist.DescriptiveAndNeutral, commitassist.ConversationalAndCasual, commitassist.ListBased, commitassist.ProblemSolution),
-				Destination: &style,
+					fmt.Sprintf("%q, %q, %q, %q, %q or %q", commitassist.DescriptiveAndNeutral, commitassist.ConversationalAndCasual, commitassist.ListBased, commitassist.ProblemSolution, commitassist.RepoStyle, commitassist.Gitmoji) +
+					", or a style loaded from the styles directory (see \"styles list\")",
+				Destination: &styleFlag,
 				Value:       string(commitassist.DescriptiveAndNeutral),
 			},
+			&cli.StringFlag{
+				Name:        "output",
+				Usage:       "the output format, \"text\" or \"json\" (the message and its fields, e.g. the conventional commit type and the confidence of the model)",
+				Value:       outputText,
+				Destination: &outputFlag,
+			},
+			&cli.Float64Flag{
+				Name:        "min-confidence",
+				Usage:       "the lowest confidence, from 0 to 1, of a suggestion that is used. Overrides the threshold in the configuration",
+				Destination: &minConfidenceFlag,
+			},
+			&cli.StringFlag{
+				Name:        "low-confidence",
+				Usage:       "what to do with a suggestion below the confidence threshold: \"abstain\" (exit code 7), \"prompt\" or \"fallback\" (a message derived from the changed files). Overrides the action in the configuration",
+				Destination: &lowConfidenceFlag,
+			},
+			&cli.BoolFlag{
+				Name:        "self-check",
+				Usage:       "if the model should be asked if the suggestion matches the diff, in a second request, to get a better confidence",
+				Destination: &selfCheckFlag,
+			},
+			&cli.StringFlag{
+				Name:        "provider",
+				Usage:       "the providers of the suggestions in the order they are tried, separated by comma, e.g. \"openai\", \"anthropic\", \"gemini\" or \"heuristic\" (derived from the changed files, without a model)",
+				Value:       providerOpenAI,
+				Destination: &providerFlag,
+			},
+			&cli.BoolTFlag{
+				Name:        "fallback",
+				Usage:       "if the heuristic provider should be used when the other providers fail, e.g. on timeout or a missing API key",
+				Destination: &fallbackFlag,
+			},
+			&cli.StringFlag{
+				Name:        "hedge",
+				Usage:       "the delay after which the next provider is also tried if the previous one has not answered, e.g. \"2s\". Disabled if not set",
+				Destination: &hedgeFlag,
+			},
+			&cli.BoolFlag{
+				Name:        "review",
+				Usage:       "if the changes should be reviewed for likely mistakes before the message is suggested, printing the findings as warnings (see the review command)",
+				Destination: &reviewFlag,
+			},
+			&cli.StringFlag{
+				Name:        "language",
+				Usage:       "the language of the commit message as a BCP 47 tag, e.g. \"de\" or \"sv-SE\". Overrides the language in the configuration, English if neither is set",
+				Destination: &languageFlag,
+			},
+		},
+		Commands: []cli.Command{
+			stylesCommand,
+			prCommand,
+			changelogCommand,
+			bumpCommand,
+			splitCommand,
+			rewordCommand,
+			squashCommand,
+			sequenceEditorCommand,
+			explainCommand,
+			reviewCommand,
+			batchCommand,
 		},
 		Action:  cliAction,
 		Version: version,
@@ -83,55 +178,123 @@ func main() {
 	}
 }
 
-func cliAction(_ *cli.Context) error {
-	cfg := config{}
-	if err := env.Parse(&cfg); err != nil {
-		log.Fatal(err)
+// commentChar returns core.commentChar of the repository. Outside a
+// repository, e.g. for a diff file, "#" is used. With "auto" git only picks
+// another char if a line of the message starts with "#".
+func commentChar(ctx context.Context, repo *git.Repo) string {
+	char, err := repo.CommentChar(ctx)
+	if err != nil || char == "auto" {
+		return commitfile.DefaultCommentChar
 	}
 
-	httpClient := http.DefaultClient
-
-	openAiClient := openai.NewClient(httpClient, cfg.APIKey)
-	commitClient := commitassist.New(openAiClient)
+	return char
+}
 
-	timeout, err := time.ParseDuration(timeoutFlag)
-	if err != nil {
-		log.Fatalf("could not parse timeout duration: %s", err)
+func cliAction(c *cli.Context) error {
+	if filename == "" {
+		log.Fatal("the --file flag is required")
 	}
 
-	requestContext, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	if outputFlag != outputText && outputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", outputFlag, outputText, outputJSON)
+	}
 
 	var response commitassist.GetTypeResponse
 
-	gitDiff, err := readFile()
+	commitMsgFile, err := commitfile.Read(filename, commentChar(context.Background(), git.New(".")))
 	if err != nil {
 		//nolint:gocritic
 		log.Fatalf("could not read file %q: %s", filename, err)
 	}
 
-	commitMessageCfg := commitassist.MessageConfig{
-		Style:                       commitassist.DescriptiveAndNeutral,
-		ConventionalCommitCompliant: conventionalCommit,
-	}
+	repoCfg, styles := mustLoadConfig()
 
-	validStyle, err := commitassist.ValidateMessageStyle(style)
+	commitClient := commitassist.New(mustNewProvider(), styles)
+
+	commitMessageCfg, err := messageConfig(commitClient, repoCfg)
 	if err != nil {
-		log.Fatalf("could not validate style %q: %s", style, err)
+		log.Fatal(err)
+	}
+
+	policy := repoCfg.Confidence
+	if c.IsSet("min-confidence") {
+		policy.Threshold = minConfidenceFlag
+	}
+
+	if lowConfidenceFlag != "" {
+		policy.Action = confidence.Action(lowConfidenceFlag)
 	}
 
-	commitMessageCfg.Style = validStyle
+	policy.SelfCheck = policy.SelfCheck || selfCheckFlag
 
-	response, err = commitClient.GetCommitMessage(requestContext, gitDiff, &commitMessageCfg)
+	if err := policy.Validate(); err != nil {
+		log.Fatalf("invalid confidence policy: %s", err)
+	}
+
+	commitMessageCfg.SelfCheck = policy.SelfCheck
+
+	if repoCfg.Issues.Placement != issue.None {
+		branch, err := git.New(".").CurrentBranch(context.Background())
+		if err != nil {
+			log.Printf("could not get the current branch, skipping issue references: %s", err)
+		}
+
+		commitMessageCfg.Branch = branch
+	}
+
+	gitDiff := commitMsgFile.Content
 
+	if reviewFlag {
+		warnFindings(repoCfg, gitDiff)
+	}
+
+	if breakingChanges {
+		commitMessageCfg.APIChanges = detectAPIChanges(gitDiff)
+	}
+
+	commitMessageCfg.Trailers, commitMessageCfg.SuggestedTrailers = collectTrailers(repoCfg.Trailers)
+
+	if commitMessageCfg.Style == commitassist.RepoStyle {
+		commitMessageCfg.Examples = selectExamples(gitDiff, repoCfg.RepoStyle)
+	}
+
+	if writeFlag {
+		// The trailers already in the file are merged into the message,
+		// before the configured ones.
+		commitMessageCfg.Trailers = append(trailer.Parse(commitMsgFile.Message), commitMessageCfg.Trailers...)
+	}
+
+	response, err = commitClient.GetCommitMessage(context.Background(), gitDiff, &commitMessageCfg)
 	if err != nil {
 		handleError(err)
 	}
 
-	fmt.Println(response.Message)
+	response = applyConfidencePolicy(response, policy, gitDiff, &commitMessageCfg)
+
+	if writeFlag {
+		if err := commitMsgFile.Write(filename, response.Message, response.SuggestedTrailers); err != nil {
+			log.Fatalf("could not write the message: %s", err)
+		}
+	}
+
+	if outputFlag == outputJSON {
+		if err := printJSON(response); err != nil {
+			log.Fatalf("could not print the message: %s", err)
+		}
+
+		return nil
+	}
+
+	if !writeFlag {
+		fmt.Println(response.Message)
+
+		for _, suggested := range response.SuggestedTrailers {
+			fmt.Printf("# %s\n", suggested)
+		}
+	}
 
 	if costFlag {
-		fmt.Printf("Cost %.2f cent\n", response.Cost)
+		fmt.Printf("Cost %.2f cent (%s)\n", response.Cost, response.Provider)
 	}
 
 	return nil
@@ -143,9 +306,18 @@ func handleError(err error) {
 		fmt.Println(e)
 		os.Exit(3)
 	case commitassist.UnexpectedStateError:
-		fmt.Println("Unexpected number of messages returned")
+		fmt.Printf("Unexpected response: %s\n", e)
 		os.Exit(2)
+	case commitassist.InvalidMessageError:
+		fmt.Printf("The suggested message does not follow the repository rules: %s\n", e)
+		os.Exit(6)
 	default:
+		var blocked provider.BlockedError
+		if errors.As(e, &blocked) {
+			fmt.Printf("The request was blocked by the safety filters of the provider: %s\n", blocked)
+			os.Exit(8)
+		}
+
 		if errors.Is(e, context.DeadlineExceeded) {
 			fmt.Println("Request timed out.")
 			fmt.Printf("See API status at %q\n", "https://status.openai.com/")
@@ -157,26 +329,93 @@ func handleError(err error) {
 	}
 }
 
-// readFile will read the file contents, ignoring lines starting with #
-// (comments) and return a string.
-func readFile() (string, error) {
-	file, err := os.Open(filename)
+// collectTrailers returns the configured trailers and the suggested
+// co-authors. Failing to get the identity of the committer or the recent
+// commits is logged.
+func collectTrailers(cfg trailer.Config) (trailers, suggested []trailer.Trailer) {
+	if !cfg.SignOff && !cfg.SuggestCoAuthors {
+		return cfg.Trailers(""), nil
+	}
+
+	ctx := context.Background()
+	repo := git.New(".")
+
+	identity, err := repo.Identity(ctx)
 	if err != nil {
-		return "", fmt.Errorf("open file %q: %w", filename, err)
+		log.Printf("could not get the git identity: %s", err)
 	}
-	defer file.Close()
 
-	fileScanner := bufio.NewScanner(file)
+	trailers = cfg.Trailers(identity)
 
-	sb := strings.Builder{}
+	if !cfg.SuggestCoAuthors || identity == "" {
+		return trailers, nil
+	}
 
-	for fileScanner.Scan() {
-		currentLine := fileScanner.Text()
-		if strings.HasPrefix(currentLine, "#") {
-			continue
-		}
-		sb.WriteString(currentLine)
+	messages, err := repo.RecentMessages(ctx, identity, recentCommits)
+	if err != nil {
+		log.Printf("could not get recent commits: %s", err)
+		return trailers, nil
+	}
+
+	exclude := append([]string{identity}, cfg.CoAuthors...)
+	for _, coAuthor := range trailer.SuggestCoAuthors(messages, exclude, 3) {
+		suggested = append(suggested, trailer.Trailer{Key: trailer.CoAuthoredBy, Value: coAuthor})
+	}
+
+	return trailers, suggested
+}
+
+// selectExamples returns previous commits relevant to the diff. Failing to
+// read the history is logged and results in no examples.
+func selectExamples(gitDiff string, cfg fewshot.Config) []fewshot.Example {
+	files, err := diff.Parse(gitDiff)
+	if err != nil {
+		log.Printf("could not parse diff, examples are selected by recency: %s", err)
+	}
+
+	staged := make([]string, 0, len(files))
+	for _, file := range files {
+		staged = append(staged, file.Path())
+	}
+
+	examples, err := fewshot.Select(context.Background(), git.New("."), staged, cfg)
+	if err != nil {
+		log.Printf("could not select examples from the history: %s", err)
+		return nil
+	}
+
+	return examples
+}
+
+// detectAPIChanges returns the breaking changes to exported Go identifiers
+// between HEAD and the index. The detection is best effort, a failure is
+// logged and results in no changes. It is skipped without staged Go files,
+// and stopped after the timeout, since type checking may build the imported
+// packages.
+func detectAPIChanges(gitDiff string) apidiff.Report {
+	files, err := diff.Parse(gitDiff)
+	if err != nil {
+		log.Printf("could not parse diff, skipping breaking change detection: %s", err)
+		return apidiff.Report{}
+	}
+
+	if !apidiff.HasGoSources(files) {
+		return apidiff.Report{}
+	}
+
+	timeout, err := time.ParseDuration(timeoutFlag)
+	if err != nil {
+		log.Fatalf("could not parse timeout duration: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	report, err := apidiff.Analyze(ctx, git.New("."), git.HEAD, git.Index, files)
+	if err != nil {
+		log.Printf("could not detect breaking changes: %s", err)
+		return apidiff.Report{}
 	}
 
-	return sb.String(), nil
+	return report
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/confidence"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// jsonOutput is printed with --output json.
+type jsonOutput struct {
+	Message string `json:"message"`
+
+	commitassist.StructuredMessage
+
+	SuggestedTrailers []string `json:"suggestedTrailers"`
+
+	// Confidence replaces the confidence of the model in StructuredMessage
+	// with the combined one, negative if unknown.
+	Confidence float64            `json:"confidence"`
+	Signals    confidence.Signals `json:"signals"`
+
+	// Provider is the name of the provider that suggested the message.
+	Provider string `json:"provider"`
+
+	// Cost is the cost of the requests in cent.
+	Cost float64 `json:"cost"`
+}
+
+func printJSON(response commitassist.GetTypeResponse) error {
+	output := jsonOutput{
+		Message:           response.Message,
+		StructuredMessage: response.Structured,
+		SuggestedTrailers: []string{},
+		Confidence:        response.Confidence,
+		Signals:           response.Signals,
+		Provider:          response.Provider,
+		Cost:              response.Cost,
+	}
+
+	for _, suggested := range response.SuggestedTrailers {
+		output.SuggestedTrailers = append(output.SuggestedTrailers, suggested.String())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/pullrequest"
+	"github.com/urfave/cli"
+)
+
+//nolint:gochecknoglobals
+var (
+	prOutFileFlag string
+	prOutputFlag  string
+)
+
+//nolint:gochecknoglobals
+var prCommand = cli.Command{
+	Name:      "pr",
+	Usage:     "suggest the title and description of a pull request of the commits since base",
+	ArgsUsage: "<base>",
+	Action:    prAction,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "the file to write the pull request to, stdout if not set",
+			Destination: &prOutFileFlag,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "the output format, \"text\" (the title, a blank line and the description) or \"json\"",
+			Value:       outputText,
+			Destination: &prOutputFlag,
+		},
+	},
+}
+
+// prJSONOutput is printed with --output json.
+type prJSONOutput struct {
+	Title    string  `json:"title"`
+	Body     string  `json:"body"`
+	Provider string  `json:"provider"`
+	Cost     float64 `json:"cost"`
+}
+
+func prAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		log.Fatal("expected the base ref, e.g. main")
+	}
+
+	if prOutputFlag != outputText && prOutputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", prOutputFlag, outputText, outputJSON)
+	}
+
+	base := c.Args().First()
+	ctx := context.Background()
+	repo := git.New(".")
+
+	commits, err := repo.Log(ctx, git.LogOptions{Revision: base + ".." + git.HEAD, NoMerges: true})
+	if err != nil {
+		log.Fatalf("could not get the commits: %s", err)
+	}
+
+	if len(commits) == 0 {
+		log.Fatalf("no commits since %q", base)
+	}
+
+	gitDiff, err := repo.Diff(ctx, base)
+	if err != nil {
+		log.Fatalf("could not get the diff: %s", err)
+	}
+
+	repoCfg, styles := mustLoadConfig()
+
+	template, err := pullrequest.FindTemplate(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prCfg := commitassist.PullRequestConfig{
+		Template: template,
+		Language: messageLanguage(repoCfg),
+	}
+
+	for _, commit := range commits {
+		prCfg.Messages = append(prCfg.Messages, commit.Message)
+	}
+
+	response, err := commitassist.New(mustNewProvider(), styles).GetPullRequest(ctx, gitDiff, &prCfg)
+	if err != nil {
+		handleError(err)
+	}
+
+	out := io.Writer(os.Stdout)
+
+	if prOutFileFlag != "" {
+		file, err := os.Create(prOutFileFlag)
+		if err != nil {
+			log.Fatalf("could not create %q: %s", prOutFileFlag, err)
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	if prOutputFlag == outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(prJSONOutput{
+			Title:    response.Title,
+			Body:     response.Body,
+			Provider: response.Provider,
+			Cost:     response.Cost,
+		})
+	} else {
+		_, err = fmt.Fprintf(out, "%s\n\n%s\n", response.Title, response.Body)
+	}
+
+	if err != nil {
+		log.Fatalf("could not write the pull request: %s", err)
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env"
+	"github.com/philiplinell/commit-msg/internal/anthropic"
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/gemini"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+const (
+	providerOpenAI    = "openai"
+	providerAnthropic = "anthropic"
+	providerGemini    = "gemini"
+	providerHeuristic = "heuristic"
+)
+
+// mustNewProvider returns the providers of the flags, configured by the
+// environment. It exits if they cannot be configured.
+func mustNewProvider() provider.Provider {
+	p, err := newProviderFromFlags(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return p
+}
+
+// newProviderFromFlags returns the providers of the flags, configured by the
+// environment. If wrap is set each model provider is wrapped, e.g. to limit
+// the requests to the models, see newProvider.
+func newProviderFromFlags(wrap func(provider.Provider) provider.Provider) (provider.Provider, error) {
+	cfg := envConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return nil, err
+	}
+
+	timeout, err := time.ParseDuration(timeoutFlag)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse timeout duration: %w", err)
+	}
+
+	var hedge time.Duration
+	if hedgeFlag != "" {
+		hedge, err = time.ParseDuration(hedgeFlag)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse hedge duration: %w", err)
+		}
+	}
+
+	return newProvider(providerFlag, cfg, timeout, hedge, wrap)
+}
+
+// newProvider returns a chain of the providers in names, separated by comma.
+// If fallbackFlag is set the heuristic provider is tried last, and a provider
+// without an API key is skipped. If wrap is set the providers other than the
+// heuristic are wrapped by it. Each provider has the timeout, so the callers
+// of the providers need no deadline of their own.
+func newProvider(names string, cfg envConfig, timeout, hedge time.Duration, wrap func(provider.Provider) provider.Provider) (provider.Provider, error) {
+	var (
+		providers    []provider.Provider
+		hasHeuristic bool
+	)
+
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case providerHeuristic:
+			providers = append(providers, commitassist.Heuristic{})
+			hasHeuristic = true
+		case providerOpenAI:
+			if cfg.APIKey == "" && fallbackFlag {
+				log.Printf("OPENAI_API_KEY is not set, skipping the %s provider", providerOpenAI)
+				continue
+			}
+
+			providers = append(providers, provider.NewOpenAI(openai.NewClient(http.DefaultClient, cfg.APIKey)))
+		case providerAnthropic:
+			if cfg.AnthropicAPIKey == "" && fallbackFlag {
+				log.Printf("ANTHROPIC_API_KEY is not set, skipping the %s provider", providerAnthropic)
+				continue
+			}
+
+			client := anthropic.NewClient(http.DefaultClient, cfg.AnthropicAPIKey, cfg.AnthropicBaseURL)
+			providers = append(providers, provider.NewAnthropic(client))
+		case providerGemini:
+			if cfg.GeminiAPIKey == "" && fallbackFlag {
+				log.Printf("GEMINI_API_KEY is not set, skipping the %s provider", providerGemini)
+				continue
+			}
+
+			auth := gemini.Auth(cfg.GeminiAuth)
+			if err := auth.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid GEMINI_AUTH: %w", err)
+			}
+
+			client := gemini.NewClient(http.DefaultClient, cfg.GeminiAPIKey, cfg.GeminiBaseURL, auth)
+			providers = append(providers, provider.NewGemini(client))
+		default:
+			return nil, fmt.Errorf("invalid provider %q, must be %q, %q, %q or %q", name, providerOpenAI, providerAnthropic, providerGemini, providerHeuristic)
+		}
+	}
+
+	if fallbackFlag && !hasHeuristic {
+		providers = append(providers, commitassist.Heuristic{})
+	}
+
+	if wrap != nil {
+		for i, p := range providers {
+			if _, ok := p.(commitassist.Heuristic); !ok {
+				providers[i] = wrap(p)
+			}
+		}
+	}
+
+	return provider.NewChain(provider.ChainConfig{
+		Timeout: timeout,
+		Hedge:   hedge,
+		OnFailure: func(name string, err error) {
+			// The caller handles requests the heuristic does not support,
+			// e.g. by grouping the hunks of split without a model.
+			if errors.Is(err, provider.ErrUnsupported) {
+				return
+			}
+
+			log.Printf("the %s provider failed: %s", name, err)
+		},
+	}, providers...), nil
+}
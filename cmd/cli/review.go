@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/config"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/review"
+	"github.com/urfave/cli"
+)
+
+// exitReviewBlocked is the exit code of review when the findings block the
+// commit.
+const exitReviewBlocked = 10
+
+//nolint:gochecknoglobals
+var (
+	reviewOutputFlag    string
+	reviewThresholdFlag string
+	reviewWarnFlag      bool
+)
+
+//nolint:gochecknoglobals
+var reviewCommand = cli.Command{
+	Name:  "review",
+	Usage: "look for likely mistakes in the staged changes, e.g. in the pre-commit hook",
+	Description: `Reports debug prints, TODOs, swallowed errors, leaked secrets and mismatched
+   test changes in the staged changes, with their file and line. The exit code
+   is 10 if a finding is at or above the threshold, which blocks the commit in
+   the pre-commit hook.`,
+	Action: reviewAction,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "threshold",
+			Usage:       "the lowest severity blocking the commit, \"info\", \"warning\" or \"error\". Overrides the threshold in the configuration",
+			Destination: &reviewThresholdFlag,
+		},
+		&cli.BoolFlag{
+			Name:        "warn",
+			Usage:       "if the findings should only be printed, never blocking the commit",
+			Destination: &reviewWarnFlag,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "the output format, \"text\" or \"json\"",
+			Value:       outputText,
+			Destination: &reviewOutputFlag,
+		},
+	},
+}
+
+// reviewOutput is printed with --output json.
+type reviewOutput struct {
+	Findings []review.Finding `json:"findings"`
+	Blocked  bool             `json:"blocked"`
+	Provider string           `json:"provider"`
+	Cost     float64          `json:"cost"`
+}
+
+func reviewAction(_ *cli.Context) error {
+	if reviewOutputFlag != outputText && reviewOutputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", reviewOutputFlag, outputText, outputJSON)
+	}
+
+	repoCfg, err := config.Load(".")
+	if err != nil {
+		log.Fatalf("could not load configuration: %s", err)
+	}
+
+	policy := repoCfg.Review
+
+	if reviewThresholdFlag != "" {
+		policy.Threshold, err = review.ParseSeverity(reviewThresholdFlag)
+		if err != nil {
+			log.Fatalf("invalid threshold: %s", err)
+		}
+	}
+
+	if reviewWarnFlag {
+		policy.Action = review.Warn
+	}
+
+	gitDiff, err := git.New(".").DiffIndex(context.Background(), git.HEAD)
+	if err != nil {
+		log.Fatalf("could not get the staged changes: %s", err)
+	}
+
+	if strings.TrimSpace(gitDiff) == "" {
+		return nil
+	}
+
+	response, err := reviewDiff(repoCfg, gitDiff)
+	if err != nil {
+		handleError(err)
+	}
+
+	output := reviewOutput{
+		Findings: response.Findings,
+		Blocked:  policy.Blocks(response.Findings),
+		Provider: response.Provider,
+		Cost:     response.Cost,
+	}
+
+	if reviewOutputFlag == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(output); err != nil {
+			log.Fatalf("could not print the findings: %s", err)
+		}
+	} else {
+		for _, finding := range output.Findings {
+			fmt.Println(finding)
+		}
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	if output.Blocked {
+		log.Print("The commit is blocked by the findings, fix them or commit with --no-verify")
+		os.Exit(exitReviewBlocked)
+	}
+
+	return nil
+}
+
+// reviewDiff reviews the diff. It returns the errors, also of configuring the
+// provider, so the review can be optional, see warnFindings.
+func reviewDiff(repoCfg config.Config, gitDiff string) (commitassist.ReviewResponse, error) {
+	styles, err := loadStyles(repoCfg)
+	if err != nil {
+		return commitassist.ReviewResponse{}, fmt.Errorf("could not load styles: %w", err)
+	}
+
+	p, err := newProviderFromFlags(nil)
+	if err != nil {
+		return commitassist.ReviewResponse{}, err
+	}
+
+	return commitassist.New(p, styles).ReviewDiff(context.Background(), gitDiff, messageLanguage(repoCfg))
+}
+
+// warnFindings reviews the diff before the message is suggested, and prints
+// the findings as warnings. It never fails.
+func warnFindings(repoCfg config.Config, gitDiff string) {
+	response, err := reviewDiff(repoCfg, gitDiff)
+	if err != nil {
+		log.Printf("could not review the changes: %s", err)
+		return
+	}
+
+	for _, finding := range response.Findings {
+		log.Printf("review: %s", finding)
+	}
+}
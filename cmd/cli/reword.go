@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/config"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/reword"
+	"github.com/philiplinell/commit-msg/internal/style"
+	"github.com/philiplinell/commit-msg/internal/trailer"
+	"github.com/urfave/cli"
+)
+
+// rewordColumnWidth is the width of a column of the review.
+const rewordColumnWidth = 50
+
+//nolint:gochecknoglobals
+var (
+	rewordForceFlag bool
+	rewordYesFlag   bool
+)
+
+//nolint:gochecknoglobals
+var rewordCommand = cli.Command{
+	Name:      "reword",
+	Usage:     "suggest new messages for the commits of a range, and rewrite them on approval",
+	ArgsUsage: "<range>",
+	Description: `Suggests a message for each commit of the range, e.g. main..HEAD, from its
+   own diff, and shows the current and the suggested messages side by side. On
+   approval the messages are rewritten, the trees of the commits are not
+   changed. The range must end at HEAD of the current branch.`,
+	Action: rewordAction,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "if commits on protected or remote-tracking branches may be rewritten",
+			Destination: &rewordForceFlag,
+		},
+		&cli.BoolFlag{
+			Name:        "yes",
+			Usage:       "if the messages should be rewritten without asking",
+			Destination: &rewordYesFlag,
+		},
+	},
+}
+
+func rewordAction(c *cli.Context) error {
+	if c.NArg() != 1 || !strings.Contains(c.Args().First(), "..") {
+		log.Fatal("expected a range, e.g. main..HEAD")
+	}
+
+	revision := c.Args().First()
+	ctx := context.Background()
+	repo := git.New(".")
+
+	branch, err := repo.CurrentBranch(ctx)
+	if err != nil {
+		log.Fatalf("could not get the current branch, only a checked out branch can be reworded: %s", err)
+	}
+
+	head, err := repo.RevParse(ctx, git.HEAD)
+	if err != nil {
+		log.Fatalf("could not get the current commit: %s", err)
+	}
+
+	repoCfg, styles := mustLoadConfig()
+
+	commits, err := reword.Commits(ctx, repo, revision, head)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !rewordForceFlag {
+		if err := reword.CheckShared(ctx, repo, branch, commits[0].Hash, repoCfg.IsProtected); err != nil {
+			log.Fatalf("refusing to rewrite, %s. Use --force to rewrite anyway", err)
+		}
+	}
+
+	suggestMessages(ctx, repo, repoCfg, styles, commits)
+
+	changed := 0
+
+	for _, commit := range commits {
+		printReview(commit)
+
+		if commit.Suggested != commit.Message {
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		log.Print("All messages are unchanged")
+		return nil
+	}
+
+	if !rewordYesFlag && !confirm(fmt.Sprintf("Reword %d of %d commits on %s? [y/N] ", changed, len(commits), branch)) {
+		log.Print("Nothing was rewritten")
+		return nil
+	}
+
+	backup, err := reword.Rewrite(ctx, repo, branch, head, commits)
+	if err != nil {
+		log.Fatalf("%s, the branch is unchanged", err)
+	}
+
+	log.Printf("Reworded %d commits, restore the branch with \"git reset --soft %s\"", changed, backup)
+
+	return nil
+}
+
+// suggestMessages suggests a message for each commit from its diff. The
+// trailers of the current message, e.g. Signed-off-by, are kept.
+func suggestMessages(ctx context.Context, repo *git.Repo, repoCfg config.Config, styles *style.Registry, commits []reword.Commit) {
+	commitClient := commitassist.New(mustNewProvider(), styles)
+
+	baseCfg, err := messageConfig(commitClient, repoCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cost := 0.0
+
+	for i := range commits {
+		commit := &commits[i]
+
+		gitDiff, err := repo.Show(ctx, commit.Hash)
+		if err != nil {
+			log.Fatalf("could not get the diff of %.7s: %s", commit.Hash, err)
+		}
+
+		messageCfg := baseCfg
+		messageCfg.Trailers = trailer.Parse(commit.Message)
+
+		response, err := commitClient.GetCommitMessage(ctx, gitDiff, &messageCfg)
+		if err != nil {
+			handleError(err)
+		}
+
+		commit.Suggested = strings.TrimSpace(response.Message)
+		cost += response.Cost
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent", cost)
+	}
+}
+
+// printReview prints the current and the suggested message side by side.
+func printReview(commit reword.Commit) {
+	if commit.Suggested == commit.Message {
+		fmt.Printf("%.7s (unchanged)\n", commit.Hash)
+	} else {
+		fmt.Printf("%.7s\n", commit.Hash)
+	}
+
+	for _, line := range reword.SideBySide(commit.Message, commit.Suggested, rewordColumnWidth) {
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/rebase"
+	"github.com/urfave/cli"
+)
+
+// sequenceEditorEnv is set when the editor is run, to detect that the editor
+// of git is commit-msg itself.
+const sequenceEditorEnv = "COMMIT_MSG_SEQUENCE_EDITOR"
+
+//nolint:gochecknoglobals
+var autosquashPrefixes = []string{"fixup! ", "squash! ", "amend! "}
+
+//nolint:gochecknoglobals
+var (
+	sequenceEditorApplyFlag  bool
+	sequenceEditorEditorFlag string
+)
+
+//nolint:gochecknoglobals
+var sequenceEditorCommand = cli.Command{
+	Name:      "sequence-editor",
+	Usage:     "annotate the todo list of an interactive rebase, then open it in the editor",
+	ArgsUsage: "<file>",
+	Description: `Adds a summary of what each commit changes below its line in the todo list,
+   flags messages that do not match the diff and suggests commits to fixup or
+   squash, as comments. Use it as GIT_SEQUENCE_EDITOR, or as core.editor with
+   --editor set. Other files than the todo list are opened in the editor as is.`,
+	Action: sequenceEditorAction,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "apply",
+			Usage:       "if the suggested fixups and squashes should be applied to the todo list, not only suggested",
+			Destination: &sequenceEditorApplyFlag,
+		},
+		&cli.StringFlag{
+			Name:        "editor",
+			Usage:       "the editor to open the file in, the editor of git if not set. Use \":\" to not open an editor",
+			Destination: &sequenceEditorEditorFlag,
+		},
+	},
+}
+
+func sequenceEditorAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		log.Fatal("expected the file to edit")
+	}
+
+	filename := c.Args().First()
+
+	if rebase.IsTodo(filename) {
+		// The rebase must not fail because of the annotations, so errors are
+		// only logged.
+		if err := annotateTodo(filename); err != nil {
+			log.Printf("could not annotate the todo list: %s", err)
+		}
+	}
+
+	runEditor(filename)
+
+	return nil
+}
+
+func annotateTodo(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not read file %q: %w", filename, err)
+	}
+
+	todo := rebase.Parse(string(content))
+
+	lines := []*rebase.Line{}
+	for _, line := range todo.Commits() {
+		if line.Command != rebase.Drop {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	repo := git.New(".")
+
+	// Git reads lines not starting with the comment char as commands, and
+	// with "auto" the char is not known.
+	commentChar, err := repo.CommentChar(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get core.commentChar: %w", err)
+	}
+
+	if commentChar == "auto" {
+		return errors.New("core.commentChar is auto, the comment char of the todo list is not known")
+	}
+
+	todo.CommentChar = commentChar
+
+	commits := make([]commitassist.CommitDiff, 0, len(lines))
+
+	for _, line := range lines {
+		history, err := repo.Log(ctx, git.LogOptions{Revision: line.Hash, MaxCount: 1})
+		if err != nil || len(history) == 0 {
+			return fmt.Errorf("could not get the message of %s: %w", line.Hash, err)
+		}
+
+		gitDiff, err := repo.Show(ctx, line.Hash)
+		if err != nil {
+			return fmt.Errorf("could not get the diff of %s: %w", line.Hash, err)
+		}
+
+		commits = append(commits, commitassist.CommitDiff{Message: history[0].Message, Diff: gitDiff})
+	}
+
+	repoCfg, styles, err := loadConfig(".")
+	if err != nil {
+		return err
+	}
+
+	p, err := newProviderFromFlags(nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := commitassist.New(p, styles).AnalyzeCommits(ctx, commits, messageLanguage(repoCfg))
+	if err != nil {
+		return err
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	apply := sequenceEditorApplyFlag && todo.IsLinear()
+	if sequenceEditorApplyFlag && !apply {
+		log.Print("the todo list has commands depending on the order of the commits, the suggestions are not applied")
+	}
+
+	for i, line := range lines {
+		analysis := response.Commits[i]
+
+		if analysis.Summary != "" {
+			line.Comments = append(line.Comments, "summary: "+analysis.Summary)
+		}
+
+		if analysis.Mismatch != "" {
+			line.Comments = append(line.Comments, "mismatch: "+analysis.Mismatch)
+		}
+
+		// Commits already grouped, e.g. by --autosquash, are left as they
+		// are.
+		if analysis.Grouping == commitassist.NoGrouping || !suggestGrouping(line, commits[i].Message) {
+			continue
+		}
+
+		target := lines[analysis.Target]
+		command := rebase.Fixup
+
+		if analysis.Grouping == commitassist.SquashGrouping {
+			command = rebase.Squash
+		}
+
+		if !apply {
+			line.Comments = append(line.Comments, fmt.Sprintf("suggestion: %s into %s %s", command, target.Hash, target.Subject))
+			continue
+		}
+
+		if err := todo.MoveAfter(line, target, command); err != nil {
+			return err
+		}
+
+		line.Comments = append(line.Comments, fmt.Sprintf("applied: %s into %s %s", command, target.Hash, target.Subject))
+	}
+
+	//nolint:gosec
+	if err := os.WriteFile(filename, []byte(todo.String()), 0o644); err != nil {
+		return fmt.Errorf("write file %q: %w", filename, err)
+	}
+
+	return nil
+}
+
+// suggestGrouping reports if a grouping should be suggested for the commit,
+// i.e. if it is picked and is not marked for --autosquash.
+func suggestGrouping(line *rebase.Line, message string) bool {
+	if line.Command == rebase.Fixup || line.Command == rebase.Squash {
+		return false
+	}
+
+	for _, prefix := range autosquashPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runEditor opens the file in the editor of --editor, or in the editor of
+// git, and exits with its exit code if it fails.
+func runEditor(filename string) {
+	editor := sequenceEditorEditorFlag
+
+	if editor == "" {
+		if os.Getenv(sequenceEditorEnv) != "" {
+			log.Fatal("the editor of git is commit-msg, set the editor to run with --editor")
+		}
+
+		out, err := exec.Command("git", "var", "GIT_EDITOR").Output()
+		if err != nil {
+			log.Fatalf("could not get the editor of git: %s", err)
+		}
+
+		editor = strings.TrimSpace(string(out))
+	}
+
+	if editor == ":" {
+		return
+	}
+
+	// Like git, the editor is run by the shell, so it may have arguments.
+	//nolint:gosec
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, filename)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), sequenceEditorEnv+"=1")
+
+	if err := cmd.Run(); err != nil {
+		log.Fatalf("the editor %q failed: %s", editor, err)
+	}
+}
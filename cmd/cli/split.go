@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/split"
+	"github.com/urfave/cli"
+)
+
+//nolint:gochecknoglobals
+var (
+	splitApplyFlag  bool
+	splitOutputFlag string
+)
+
+//nolint:gochecknoglobals
+var splitCommand = cli.Command{
+	Name:  "split",
+	Usage: "suggest splitting the staged changes into focused commits",
+	Description: `Groups the staged hunks by intent and suggests a commit with a message for
+   each group. Nothing is changed unless --apply is set.`,
+	Action: splitAction,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "apply",
+			Usage:       "if the suggested commits should be made, the staged changes are saved to a patch file first",
+			Destination: &splitApplyFlag,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "the output format, \"text\" or \"json\"",
+			Value:       outputText,
+			Destination: &splitOutputFlag,
+		},
+	},
+}
+
+// splitCommitOutput is a commit printed with --output json.
+type splitCommitOutput struct {
+	Message string   `json:"message"`
+	Reason  string   `json:"reason"`
+	Hunks   []string `json:"hunks"`
+}
+
+// splitOutput is printed with --output json.
+type splitOutput struct {
+	Commits  []splitCommitOutput `json:"commits"`
+	Provider string              `json:"provider"`
+	Cost     float64             `json:"cost"`
+}
+
+func splitAction(_ *cli.Context) error {
+	if splitOutputFlag != outputText && splitOutputFlag != outputJSON {
+		log.Fatalf("invalid output %q, must be %q or %q", splitOutputFlag, outputText, outputJSON)
+	}
+
+	ctx := context.Background()
+	repo := git.New(".")
+
+	gitDiff, err := repo.StagedDiff(ctx)
+	if err != nil {
+		log.Fatalf("could not get the staged changes: %s", err)
+	}
+
+	if strings.TrimSpace(gitDiff) == "" {
+		log.Fatal("there are no staged changes")
+	}
+
+	repoCfg, styles := mustLoadConfig()
+
+	splitClient := commitassist.New(mustNewProvider(), styles)
+
+	messageCfg, err := messageConfig(splitClient, repoCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// With --fallback the hunks are grouped by the heuristic if all models
+	// fail.
+	response, err := splitClient.SuggestSplit(ctx, gitDiff, &messageCfg)
+	if err != nil {
+		handleError(err)
+	}
+
+	output := splitOutput{
+		Commits:  []splitCommitOutput{},
+		Provider: response.Provider,
+		Cost:     response.Cost,
+	}
+
+	for _, commit := range response.Commits {
+		hunks := []string{}
+		for _, id := range commit.Units {
+			hunks = append(hunks, hunkLabel(response.Units[id]))
+		}
+
+		output.Commits = append(output.Commits, splitCommitOutput{
+			Message: commit.Message,
+			Reason:  commit.Reason,
+			Hunks:   hunks,
+		})
+	}
+
+	if splitOutputFlag == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(output); err != nil {
+			log.Fatalf("could not print the split: %s", err)
+		}
+	} else {
+		printSplit(output)
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	if splitApplyFlag {
+		applySplit(ctx, repo, gitDiff, response)
+	}
+
+	return nil
+}
+
+// hunkLabel returns the path and the hunk header of the unit, e.g.
+// "main.go @@ -1,3 +1,4 @@".
+func hunkLabel(unit diff.Unit) string {
+	label := unit.File().Path()
+
+	if header, _, _ := strings.Cut(unit.Hunk, "\n"); header != "" {
+		if end := strings.Index(header[2:], "@@"); end >= 0 {
+			header = header[:end+4]
+		}
+
+		label += " " + header
+	}
+
+	return label
+}
+
+func printSplit(output splitOutput) {
+	for i, commit := range output.Commits {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("Commit %d of %d: %s\n", i+1, len(output.Commits), commit.Reason)
+
+		for _, hunk := range commit.Hunks {
+			fmt.Printf("  %s\n", hunk)
+		}
+
+		fmt.Println()
+
+		for _, line := range strings.Split(commit.Message, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+}
+
+// applySplit makes the suggested commits, see split.Apply. The staged changes
+// are saved first, so they can be restored if a hunk does not apply.
+func applySplit(ctx context.Context, repo *git.Repo, gitDiff string, response commitassist.SplitResponse) {
+	backup, err := os.CreateTemp("", "commit-msg-split-*.patch")
+	if err != nil {
+		log.Fatalf("could not save the staged changes: %s", err)
+	}
+
+	if _, err := backup.WriteString(gitDiff); err != nil {
+		log.Fatalf("could not save the staged changes: %s", err)
+	}
+
+	if err := backup.Close(); err != nil {
+		log.Fatalf("could not save the staged changes: %s", err)
+	}
+
+	log.Printf("The staged changes are saved to %s", backup.Name())
+
+	head, err := repo.RevParse(ctx, git.HEAD)
+	if err != nil {
+		log.Fatalf("could not get the current commit: %s", err)
+	}
+
+	restore := fmt.Sprintf("restore the staged changes with \"git reset %s && git apply --cached %s\"", head, backup.Name())
+
+	hashes, err := split.Apply(ctx, repo, response.Units, response.Commits)
+
+	for i, hash := range hashes {
+		log.Printf("Committed %.7s %s", hash, strings.SplitN(response.Commits[i].Message, "\n", 2)[0])
+	}
+
+	if err != nil {
+		log.Fatalf("%s, %s", err, restore)
+	}
+}
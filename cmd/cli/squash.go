@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/squash"
+	"github.com/urfave/cli"
+)
+
+//nolint:gochecknoglobals
+var (
+	squashFileFlag  string
+	squashWriteFlag bool
+)
+
+//nolint:gochecknoglobals
+var squashCommand = cli.Command{
+	Name:      "squash",
+	Usage:     "suggest one message for commits squashed into one",
+	ArgsUsage: "[<range>]",
+	Description: `Suggests a message summarizing the net diff of the squashed commits, keeping
+   their trailers and issue references. The commits are those of the range, e.g.
+   main..HEAD, or read from the message file of the squash, .git/SQUASH_MSG if
+   --file is not set.`,
+	Action: squashAction,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "file",
+			Usage:       "the message file of the squash, e.g. $COMMIT_MSG_FILE in the prepare-commit-msg hook",
+			Destination: &squashFileFlag,
+		},
+		&cli.BoolFlag{
+			Name:        "write",
+			Usage:       "if the message should be written to the message file, with the squashed messages commented out, instead of printed to stdout",
+			Destination: &squashWriteFlag,
+		},
+	},
+}
+
+func squashAction(c *cli.Context) error {
+	if c.NArg() > 1 {
+		log.Fatal("expected at most one range, e.g. main..HEAD")
+	}
+
+	ctx := context.Background()
+	repo := git.New(".")
+
+	var (
+		messages []string
+		gitDiff  string
+		content  string
+		comment  string
+	)
+
+	filename := squashFileFlag
+
+	switch {
+	case c.NArg() == 1:
+		if squashWriteFlag {
+			log.Fatal("--write cannot be used with a range")
+		}
+
+		messages, gitDiff = squashRange(ctx, repo, c.Args().First())
+	default:
+		if filename == "" {
+			var err error
+
+			filename, err = repo.GitPath(ctx, "SQUASH_MSG")
+			if err != nil {
+				log.Fatalf("could not find the squash message: %s", err)
+			}
+		}
+
+		raw, err := os.ReadFile(filename)
+		if err != nil {
+			log.Fatalf("could not read file %q: %s", filename, err)
+		}
+
+		content = string(raw)
+		comment = commentChar(ctx, repo)
+
+		squashed := squash.Parse(content, comment)
+		if len(squashed.Commits) == 0 {
+			log.Fatalf("no squashed messages in %q", filename)
+		}
+
+		// In an interactive rebase the squashed commits are amended into
+		// HEAD.
+		base := git.HEAD
+		if squashed.Rebase {
+			base = git.HEAD + "^"
+		}
+
+		gitDiff, err = repo.DiffIndex(ctx, base)
+		if err != nil {
+			log.Fatalf("could not get the diff: %s", err)
+		}
+
+		messages = squashed.Messages()
+	}
+
+	if strings.TrimSpace(gitDiff) == "" {
+		log.Fatal("the squashed commits have no changes")
+	}
+
+	repoCfg, styles := mustLoadConfig()
+
+	commitClient := commitassist.New(mustNewProvider(), styles)
+
+	messageCfg, err := messageConfig(commitClient, repoCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	messageCfg.SquashedMessages = messages
+
+	response, err := commitClient.GetCommitMessage(ctx, gitDiff, &messageCfg)
+	if err != nil {
+		handleError(err)
+	}
+
+	if squashWriteFlag {
+		if err := writeSquashFile(filename, response.Message, content, comment); err != nil {
+			log.Fatalf("could not write the message: %s", err)
+		}
+	} else {
+		fmt.Println(response.Message)
+	}
+
+	if costFlag {
+		log.Printf("Cost %.2f cent (%s)", response.Cost, response.Provider)
+	}
+
+	return nil
+}
+
+// squashRange returns the messages of the commits of the range, oldest
+// first, and their net diff.
+func squashRange(ctx context.Context, repo *git.Repo, revision string) ([]string, string) {
+	from, to, found := strings.Cut(revision, "..")
+	if !found || from == "" || strings.HasPrefix(to, ".") {
+		log.Fatal("expected a range, e.g. main..HEAD")
+	}
+
+	if to == "" {
+		to = git.HEAD
+	}
+
+	commits, err := repo.Log(ctx, git.LogOptions{Revision: revision, NoMerges: true})
+	if err != nil {
+		log.Fatalf("could not get the commits: %s", err)
+	}
+
+	if len(commits) == 0 {
+		log.Fatalf("no commits in %q", revision)
+	}
+
+	messages := make([]string, 0, len(commits))
+	for i := len(commits) - 1; i >= 0; i-- {
+		messages = append(messages, commits[i].Message)
+	}
+
+	gitDiff, err := repo.DiffRange(ctx, from, to)
+	if err != nil {
+		log.Fatalf("could not get the diff: %s", err)
+	}
+
+	return messages, gitDiff
+}
+
+// writeSquashFile writes the message to the file, followed by the content of
+// the file commented out with the comment char, so the squashed messages can
+// still be seen.
+func writeSquashFile(filename, message, content, commentChar string) error {
+	lines := []string{message, ""}
+
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, commentChar):
+			lines = append(lines, line)
+		case line == "":
+			lines = append(lines, commentChar)
+		default:
+			lines = append(lines, commentChar+" "+line)
+		}
+	}
+
+	//nolint:gosec
+	if err := os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write file %q: %w", filename, err)
+	}
+
+	return nil
+}
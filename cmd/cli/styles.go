@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/philiplinell/commit-msg/internal/config"
+	"github.com/philiplinell/commit-msg/internal/style"
+	"github.com/urfave/cli"
+)
+
+//nolint:gochecknoglobals
+var stylesCommand = cli.Command{
+	Name:  "styles",
+	Usage: "list and show the styles of commit messages",
+	Subcommands: []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "list the available styles",
+			Action: stylesListAction,
+		},
+		{
+			Name:      "show",
+			Usage:     "show the prompt and examples of a style",
+			ArgsUsage: "<name>",
+			Action:    stylesShowAction,
+		},
+	},
+}
+
+// loadStyles returns the built-in styles and the styles in the styles
+// directory of the repository.
+func loadStyles(repoCfg config.Config) (*style.Registry, error) {
+	styles, err := style.Builtin()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := repoCfg.StylesDir
+	if dir == "" {
+		dir = config.DefaultStylesDir
+	}
+
+	if err := styles.LoadDir(dir); err != nil {
+		return nil, err
+	}
+
+	return styles, nil
+}
+
+func stylesListAction(_ *cli.Context) error {
+	_, styles := mustLoadConfig()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	for _, name := range styles.Names() {
+		s, err := styles.Get(name, style.Data{})
+		if err != nil {
+			log.Fatalf("could not get style %q: %s", name, err)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\n", s.Name, strings.Join(strings.Fields(s.Description), " "))
+	}
+
+	return w.Flush()
+}
+
+func stylesShowAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		log.Fatal("expected the name of a style")
+	}
+
+	_, styles := mustLoadConfig()
+
+	s, err := styles.Get(c.Args().First(), style.Data{ConventionalCommit: conventionalCommit})
+	if err != nil {
+		log.Fatalf("could not get style: %s", err)
+	}
+
+	fmt.Printf("Name: %s\nSource: %s\n\n%s\n\nPrompt:\n%s\n", s.Name, s.Source, s.Description, s.Prompt)
+
+	for i, example := range s.Examples {
+		fmt.Printf("\nExample %d diff:\n%s\n\nExample %d message:\n%s\n", i+1, example.Diff, i+1, example.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+/*
+Package anthropic is a client of the Anthropic Messages API, see
+https://docs.anthropic.com/en/api/messages.
+
+The system prompt is a top-level field of the request, and the messages
+alternate between the user and the assistant, starting with the user. JSON
+following a schema is requested with a tool the model must use, see
+MessagesRequestWithTool.
+*/
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the Anthropic API.
+	DefaultBaseURL = "https://api.anthropic.com"
+
+	messagesPath = "/v1/messages"
+
+	// apiVersion is the value of the anthropic-version header.
+	apiVersion = "2023-06-01"
+
+	// DefaultMaxTokens is the maximum number of tokens of the answer, which
+	// the API requires, if none is given.
+	DefaultMaxTokens = 1024
+
+	// stopMaxTokens is the stop reason of an answer cut off at the maximum
+	// number of tokens.
+	stopMaxTokens = "max_tokens"
+)
+
+// Client is the Anthropic API client.
+type Client struct {
+	httpClient Doer
+	apiKey     string
+	baseURL    string
+}
+
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewClient creates a new Anthropic API client. The baseURL is
+// DefaultBaseURL if empty, another one can be used e.g. for a proxy or a stub
+// server in tests.
+func NewClient(httpClient Doer, apiKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		apiKey:     apiKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type aiModel string
+
+const (
+	// Claude3_5Haiku - The fastest and cheapest Claude model, supporting
+	// tool use.
+	Claude3_5Haiku aiModel = "claude-3-5-haiku-latest"
+)
+
+// Cost returns the cost in dollars of a request with the input and output
+// tokens.
+func (m aiModel) Cost(inputTokens, outputTokens int) float64 {
+	if inputTokens < 0 || outputTokens < 0 {
+		return 0.0
+	}
+
+	switch m {
+	case Claude3_5Haiku:
+		// $0.80 / 1M input tokens and $4 / 1M output tokens.
+		return (float64(inputTokens)*0.8 + float64(outputTokens)*4) / 1000000
+	default:
+		return 0.0
+	}
+}
+
+// Role is the role of a message, the messages alternate between UserRole and
+// AssistantRole.
+type Role string
+
+const (
+	UserRole      Role = "user"
+	AssistantRole Role = "assistant"
+)
+
+// Message is a message of the conversation.
+type Message struct {
+	Role    Role           `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is a part of a message. In requests it is text, in responses
+// it can also be the use of a tool.
+type ContentBlock struct {
+	// Type is "text" or "tool_use".
+	Type string `json:"type"`
+
+	// Text is set for "text" blocks.
+	Text string `json:"text,omitempty"`
+
+	// Name and Input are set for "tool_use" blocks, Input is the JSON the
+	// model calls the tool with.
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// TextBlock returns a text content block.
+func TextBlock(text string) ContentBlock {
+	return ContentBlock{Type: "text", Text: text}
+}
+
+// Tool is a tool the model can use. The input of the tool follows the
+// schema, which is how JSON is requested from the model.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// MessagesResponse is the answer of the model.
+type MessagesResponse struct {
+	Model aiModel
+
+	// Cost is the cost for the request in dollars.
+	Cost float64
+
+	// Content are the content blocks of the answer.
+	Content []ContentBlock
+
+	// StopReason is why the model stopped, e.g. "end_turn", "tool_use" or
+	// "max_tokens".
+	StopReason string
+}
+
+// Truncated reports if the answer was cut off at the maximum number of
+// tokens, e.g. a tool input that is not valid JSON.
+func (r MessagesResponse) Truncated() bool {
+	return r.StopReason == stopMaxTokens
+}
+
+// ToolInput returns the input of the first use of the tool with the name,
+// and false if the model did not use it.
+func (r MessagesResponse) ToolInput(name string) (json.RawMessage, bool) {
+	for _, block := range r.Content {
+		if block.Type == "tool_use" && block.Name == name {
+			return block.Input, true
+		}
+	}
+
+	return nil, false
+}
+
+// MessagesRequestWithTool does a request to the messages API where the model
+// must use the tool, i.e. answer with JSON following its input schema.
+//
+// temperature must be a value between 0 and 1 (inclusive), lower is more
+// deterministic. maxTokens limits the answer, DefaultMaxTokens is used if it
+// is not positive.
+func (c *Client) MessagesRequestWithTool(ctx context.Context, system string, messages []Message, model aiModel, temperature float32, maxTokens int, tool Tool) (MessagesResponse, error) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	return c.messagesRequest(ctx, messagesRequest{
+		Model:       string(model),
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		Tools:       []Tool{tool},
+		ToolChoice:  &toolChoice{Type: "tool", Name: tool.Name},
+	})
+}
+
+func (c *Client) messagesRequest(ctx context.Context, requestBody messagesRequest) (MessagesResponse, error) {
+	if requestBody.Temperature < 0 || requestBody.Temperature > 1 {
+		return MessagesResponse{}, fmt.Errorf("temperature must be between 0 and 1 (inclusive), got %f", requestBody.Temperature)
+	}
+
+	requestBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return MessagesResponse{}, fmt.Errorf("could not marshal body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+messagesPath, bytes.NewBuffer(requestBytes))
+	if err != nil {
+		return MessagesResponse{}, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return MessagesResponse{}, fmt.Errorf("could not do request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResponse rawErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResponse); err == nil && errResponse.Error.Message != "" {
+			return MessagesResponse{}, fmt.Errorf("got status code %q, expected %d: %s", resp.Status, http.StatusOK, errResponse.Error.Message)
+		}
+
+		return MessagesResponse{}, fmt.Errorf("got status code %q, expected %d", resp.Status, http.StatusOK)
+	}
+
+	var mResponse rawMessagesResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&mResponse)
+	if err != nil {
+		return MessagesResponse{}, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	model := aiModel(requestBody.Model)
+
+	return MessagesResponse{
+		Model:      model,
+		Cost:       model.Cost(mResponse.Usage.InputTokens, mResponse.Usage.OutputTokens),
+		Content:    mResponse.Content,
+		StopReason: mResponse.StopReason,
+	}, nil
+}
+
+type messagesRequest struct {
+	Model       string      `json:"model"`
+	System      string      `json:"system,omitempty"`
+	Messages    []Message   `json:"messages"`
+	MaxTokens   int         `json:"max_tokens"`
+	Temperature float32     `json:"temperature"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *toolChoice `json:"tool_choice,omitempty"`
+}
+
+type toolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type rawUsageResponse struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type rawMessagesResponse struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Role       string           `json:"role"`
+	Model      string           `json:"model"`
+	Content    []ContentBlock   `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      rawUsageResponse `json:"usage"`
+}
+
+type rawErrorResponse struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
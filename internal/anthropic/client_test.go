@@ -0,0 +1,132 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/anthropic"
+)
+
+const messagesResponse = `{
+  "id": "msg_01",
+  "type": "message",
+  "role": "assistant",
+  "model": "claude-3-5-haiku-20241022",
+  "content": [{"type": "tool_use", "id": "toolu_01", "name": "answer", "input": {"subject": "Add search"}}],
+  "stop_reason": "tool_use",
+  "usage": {"input_tokens": 1000, "output_tokens": 100}
+}`
+
+func TestAIModelCost(t *testing.T) {
+	testCases := []struct {
+		inputTokens  int
+		outputTokens int
+		expectedCost float64
+	}{
+		{
+			inputTokens:  1000000,
+			outputTokens: 0,
+			expectedCost: 0.8,
+		},
+		{
+			inputTokens:  0,
+			outputTokens: 1000000,
+			expectedCost: 4,
+		},
+		{
+			inputTokens:  -1,
+			outputTokens: 10,
+			expectedCost: 0.0,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			got := anthropic.Claude3_5Haiku.Cost(tc.inputTokens, tc.outputTokens)
+
+			if got != tc.expectedCost {
+				t.Errorf("got %v, want %v", got, tc.expectedCost)
+			}
+		})
+	}
+}
+
+func TestMessagesRequestWithTool(t *testing.T) {
+	var (
+		header      http.Header
+		path        string
+		requestBody struct {
+			Model      string              `json:"model"`
+			System     string              `json:"system"`
+			Messages   []anthropic.Message `json:"messages"`
+			MaxTokens  int                 `json:"max_tokens"`
+			Tools      []anthropic.Tool    `json:"tools"`
+			ToolChoice struct {
+				Type string `json:"type"`
+				Name string `json:"name"`
+			} `json:"tool_choice"`
+		}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		path = r.URL.Path
+
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Error(err)
+		}
+
+		_, _ = w.Write([]byte(messagesResponse))
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient(server.Client(), "secret", server.URL+"/")
+	tool := anthropic.Tool{Name: "answer", InputSchema: json.RawMessage(`{"type":"object"}`)}
+	messages := []anthropic.Message{{Role: anthropic.UserRole, Content: []anthropic.ContentBlock{anthropic.TextBlock("diff")}}}
+
+	response, err := client.MessagesRequestWithTool(context.Background(), "You write commit messages.", messages, anthropic.Claude3_5Haiku, 0.2, 0, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/v1/messages" || header.Get("x-api-key") != "secret" || header.Get("anthropic-version") != "2023-06-01" {
+		t.Errorf("unexpected request to %q with header %v", path, header)
+	}
+
+	if requestBody.Model != "claude-3-5-haiku-latest" || requestBody.System != "You write commit messages." || requestBody.MaxTokens != anthropic.DefaultMaxTokens {
+		t.Errorf("unexpected request %+v", requestBody)
+	}
+
+	if len(requestBody.Tools) != 1 || requestBody.ToolChoice.Type != "tool" || requestBody.ToolChoice.Name != "answer" {
+		t.Errorf("unexpected tools %+v and tool choice %+v", requestBody.Tools, requestBody.ToolChoice)
+	}
+
+	input, ok := response.ToolInput("answer")
+	if !ok || string(input) != `{"subject": "Add search"}` {
+		t.Errorf("got tool input %q, want the input of the tool use", input)
+	}
+
+	if response.Cost != anthropic.Claude3_5Haiku.Cost(1000, 100) {
+		t.Errorf("got cost %v", response.Cost)
+	}
+}
+
+func TestMessagesRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient(server.Client(), "", server.URL)
+
+	_, err := client.MessagesRequestWithTool(context.Background(), "", nil, anthropic.Claude3_5Haiku, 0.2, 0, anthropic.Tool{Name: "answer"})
+	if err == nil || err.Error() != `got status code "401 Unauthorized", expected 200: invalid x-api-key` {
+		t.Errorf("got %v, want the error message of the API", err)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Package apidiff detects changes to exported Go identifiers that break
+backwards compatibility.
+
+The Go packages touched by a diff are type checked twice, once as they are in
+the old revision (e.g. HEAD) and once as they are in the new revision (e.g.
+the index), and the exported identifiers are compared. The following changes
+are reported:
+
+  - removed exported functions, types, variables and constants, renamed ones
+    are reported as removed
+  - changed function, method, variable and constant types
+  - removed or changed exported struct fields
+  - methods added to, removed from or changed in interfaces
+
+Imported packages are resolved from compiled export data when possible. An
+import that cannot be resolved does not stop the analysis, but types from it
+cannot be compared.
+*/
+package apidiff
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+// Tree gives access to the files of a revision, see package git.
+type Tree interface {
+	// ListFiles returns the paths of the files directly in dir.
+	ListFiles(ctx context.Context, rev, dir string) ([]string, error)
+
+	// ReadFile returns the content of the file at p.
+	ReadFile(ctx context.Context, rev, p string) ([]byte, error)
+}
+
+// Change is a single breaking change.
+type Change struct {
+	// Package is the directory of the package.
+	Package string
+
+	// Message describes the change, e.g. "func New was removed".
+	Message string
+}
+
+// String returns the change with the package, e.g. "internal/openai: func
+// New was removed".
+func (c Change) String() string {
+	return c.Package + ": " + c.Message
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	Changes []Change
+}
+
+// Breaking returns true if there is at least one breaking change.
+func (r Report) Breaking() bool {
+	return len(r.Changes) > 0
+}
+
+// Facts returns the changes formatted as a list to be used in a prompt.
+func (r Report) Facts() string {
+	if !r.Breaking() {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("The following changes to exported Go identifiers break backwards compatibility:\n")
+
+	for _, change := range r.Changes {
+		sb.WriteString("- " + change.String() + "\n")
+	}
+
+	return sb.String()
+}
+
+// Footer returns a draft of the "BREAKING CHANGE:" footer describing the
+// changes.
+func (r Report) Footer() string {
+	switch len(r.Changes) {
+	case 0:
+		return ""
+	case 1:
+		return "BREAKING CHANGE: " + r.Changes[0].String()
+	default:
+		lines := []string{"BREAKING CHANGE: the exported API changed:"}
+		for _, change := range r.Changes {
+			lines = append(lines, "- "+change.String())
+		}
+
+		return strings.Join(lines, "\n")
+	}
+}
+
+// HasGoSources reports if any of the files is a Go file Analyze compares,
+// i.e. not a test file.
+func HasGoSources(files []diff.File) bool {
+	return len(packageDirs(files)) > 0
+}
+
+// Analyze compares the exported identifiers of the Go packages touched by
+// files between oldRev and newRev. Files that are not Go files, test files and
+// main packages are skipped. The imports are no longer resolved once ctx is
+// done, and the context error is returned.
+func Analyze(ctx context.Context, tree Tree, oldRev, newRev string, files []diff.File) (Report, error) {
+	imp := newTolerantImporter(ctx)
+	report := Report{}
+
+	for _, dir := range packageDirs(files) {
+		if err := ctx.Err(); err != nil {
+			return Report{}, err
+		}
+
+		oldPkg, err := loadPackage(ctx, tree, imp, oldRev, dir)
+		if err != nil {
+			return Report{}, fmt.Errorf("could not load %q at %q: %w", dir, oldRev, err)
+		}
+
+		newPkg, err := loadPackage(ctx, tree, imp, newRev, dir)
+		if err != nil {
+			return Report{}, fmt.Errorf("could not load %q at %q: %w", dir, newRev, err)
+		}
+
+		switch {
+		case oldPkg == nil || oldPkg.Name() == "main":
+			// New packages can not break anything.
+			continue
+		case newPkg == nil:
+			report.Changes = append(report.Changes, Change{Package: dir, Message: fmt.Sprintf("package %s was removed", oldPkg.Name())})
+		default:
+			report.Changes = append(report.Changes, comparePackages(dir, oldPkg, newPkg)...)
+		}
+	}
+
+	return report, nil
+}
+
+// packageDirs returns the sorted directories of the changed Go files.
+func packageDirs(files []diff.File) []string {
+	seen := map[string]bool{}
+
+	for _, file := range files {
+		for _, p := range []string{file.OldPath, file.NewPath} {
+			if isGoSource(p) {
+				seen[path.Dir(p)] = true
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(seen))
+	for dir := range seen {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+func isGoSource(p string) bool {
+	return strings.HasSuffix(p, ".go") && !strings.HasSuffix(p, "_test.go") && !strings.Contains("/"+p, "/testdata/")
+}
+
+// loadPackage type checks the package in dir at rev. nil is returned if there
+// are no Go files in dir.
+func loadPackage(ctx context.Context, tree Tree, imp types.Importer, rev, dir string) (*types.Package, error) {
+	paths, err := tree.ListFiles(ctx, rev, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+
+	for _, p := range paths {
+		if !isGoSource(p) {
+			continue
+		}
+
+		content, err := tree.ReadFile(ctx, rev, p)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := parser.ParseFile(fset, p, content, parser.SkipObjectResolution)
+		if err != nil {
+			// A file that does not parse is left out, the rest of the
+			// package can still be compared.
+			continue
+		}
+
+		// Only keep files of the same package, e.g. ignore "//go:build
+		// ignore" files declaring package main in a library.
+		if len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	conf := types.Config{
+		Importer: imp,
+		// Errors are expected, e.g. for unresolved imports, and should not
+		// stop the type checking.
+		Error: func(error) {},
+	}
+
+	pkg, _ := conf.Check(dir, fset, files, nil)
+
+	return pkg, nil
+}
+
+// tolerantImporter imports packages from export data and falls back to an
+// empty package if that is not possible, or if ctx is done. The default
+// importer may run "go list", which can not be canceled.
+type tolerantImporter struct {
+	ctx      context.Context
+	importer types.Importer
+	fakes    map[string]*types.Package
+}
+
+func newTolerantImporter(ctx context.Context) *tolerantImporter {
+	return &tolerantImporter{
+		ctx:      ctx,
+		importer: importer.Default(),
+		fakes:    map[string]*types.Package{},
+	}
+}
+
+func (i *tolerantImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := i.fakes[importPath]; ok {
+		return pkg, nil
+	}
+
+	if i.ctx.Err() == nil {
+		if pkg, err := i.importer.Import(importPath); err == nil {
+			return pkg, nil
+		}
+	}
+
+	pkg := types.NewPackage(importPath, path.Base(importPath))
+	pkg.MarkComplete()
+	i.fakes[importPath] = pkg
+
+	return pkg, nil
+}
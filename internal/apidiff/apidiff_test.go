@@ -0,0 +1,203 @@
+package apidiff_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/apidiff"
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+// fakeTree maps revision to path to content.
+type fakeTree map[string]map[string]string
+
+func (f fakeTree) ListFiles(_ context.Context, rev, dir string) ([]string, error) {
+	files := []string{}
+
+	for p := range f[rev] {
+		if path.Dir(p) == dir {
+			files = append(files, p)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (f fakeTree) ReadFile(_ context.Context, rev, p string) ([]byte, error) {
+	return []byte(f[rev][p]), nil
+}
+
+const oldSource = `package store
+
+type Store interface {
+	Get(key string) (string, error)
+}
+
+type Options struct {
+	Name    string
+	Timeout int
+	secret  string
+}
+
+func (o *Options) Validate() error { return nil }
+
+func Open(name string) (*Options, error) { return nil, nil }
+
+func Close() {}
+
+const Version = "1"
+
+var Default = Options{}
+`
+
+const newSource = `package store
+
+type Store interface {
+	Get(key string) (string, error)
+	Delete(key string) error
+}
+
+type Options struct {
+	Name    string
+	Timeout float64
+}
+
+func (o *Options) Validate(strict bool) error { return nil }
+
+func Open(name string, readOnly bool) (*Options, error) { return nil, nil }
+
+func Shutdown() {}
+
+const Version = "2"
+`
+
+func TestAnalyze(t *testing.T) {
+	tree := fakeTree{
+		"HEAD": {
+			"store/store.go":      oldSource,
+			"store/store_test.go": "package store\n\nfunc TestX() {}",
+			"cmd/main.go":         "package main\n\nfunc Run() {}",
+			"README.md":           "# Store",
+		},
+		"": {
+			"store/store.go":      newSource,
+			"store/store_test.go": "package store",
+			"cmd/main.go":         "package main",
+			"README.md":           "# Store v2",
+		},
+	}
+
+	files := []diff.File{
+		{OldPath: "store/store.go", NewPath: "store/store.go"},
+		{OldPath: "store/store_test.go", NewPath: "store/store_test.go"},
+		{OldPath: "cmd/main.go", NewPath: "cmd/main.go"},
+		{OldPath: "README.md", NewPath: "README.md"},
+	}
+
+	report, err := apidiff.Analyze(context.Background(), tree, "HEAD", "", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, change := range report.Changes {
+		got = append(got, change.String())
+	}
+
+	sort.Strings(got)
+
+	expected := []string{
+		"store: field Options.Timeout changed type from int to float64",
+		"store: func Close was removed",
+		"store: func Open changed type from func(name string) (*Options, error) to func(name string, readOnly bool) (*Options, error)",
+		"store: method Delete was added to interface Store",
+		"store: method Options.Validate changed signature from func() error to func(strict bool) error",
+		"store: var Default was removed",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n%q\nwant\n%q", got, expected)
+	}
+
+	if !report.Breaking() || report.Footer() == "" {
+		t.Error("expected a breaking change footer")
+	}
+}
+
+func TestAnalyzeNoRename(t *testing.T) {
+	tree := fakeTree{
+		"HEAD": {"store/store.go": "package store\n\nfunc A(s string) error { return nil }\n"},
+		"":     {"store/store.go": "package store\n\nfunc B(s string) error { return nil }\n"},
+	}
+
+	report, err := apidiff.Analyze(context.Background(), tree, "HEAD", "", []diff.File{{OldPath: "store/store.go", NewPath: "store/store.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Changes) != 1 || report.Changes[0].String() != "store: func A was removed" {
+		t.Errorf("got %v, want only the removal of A", report.Changes)
+	}
+}
+
+func TestAnalyzeSkipsNewPackages(t *testing.T) {
+	tree := fakeTree{
+		"HEAD": {},
+		"":     {"store/store.go": newSource},
+	}
+
+	report, err := apidiff.Analyze(context.Background(), tree, "HEAD", "", []diff.File{{NewPath: "store/store.go", IsNew: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Breaking() {
+		t.Errorf("got changes %v, want none", report.Changes)
+	}
+}
+
+func TestAnalyzeCanceled(t *testing.T) {
+	tree := fakeTree{
+		"HEAD": {"store/store.go": oldSource},
+		"":     {"store/store.go": newSource},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := apidiff.Analyze(ctx, tree, "HEAD", "", []diff.File{{OldPath: "store/store.go", NewPath: "store/store.go"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestHasGoSources(t *testing.T) {
+	testCases := []struct {
+		files    []diff.File
+		expected bool
+	}{
+		{files: nil},
+		{files: []diff.File{{OldPath: "README.md", NewPath: "README.md"}}},
+		{files: []diff.File{{OldPath: "store/store_test.go", NewPath: "store/store_test.go"}}},
+		{files: []diff.File{{OldPath: "store/testdata/x.go", NewPath: "store/testdata/x.go"}}},
+		{files: []diff.File{{OldPath: "README.md"}, {OldPath: "store/store.go"}}, expected: true},
+		{files: []diff.File{{NewPath: "store/store.go", IsNew: true}}, expected: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(fmt.Sprint(tc.files), func(t *testing.T) {
+			if got := apidiff.HasGoSources(tc.files); got != tc.expected {
+				t.Errorf("got %t, want %t", got, tc.expected)
+			}
+		})
+	}
+}
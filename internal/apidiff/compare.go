@@ -0,0 +1,213 @@
+package apidiff
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+// comparePackages returns the breaking changes between the exported
+// identifiers of oldPkg and newPkg.
+func comparePackages(dir string, oldPkg, newPkg *types.Package) []Change {
+	qualifier := func(p *types.Package) string {
+		if p.Path() == dir {
+			return ""
+		}
+
+		return p.Name()
+	}
+
+	c := comparer{dir: dir, qualifier: qualifier}
+
+	oldScope, newScope := oldPkg.Scope(), newPkg.Scope()
+	removed := []types.Object{}
+
+	for _, name := range oldScope.Names() {
+		if !token.IsExported(name) {
+			continue
+		}
+
+		oldObj := oldScope.Lookup(name)
+
+		newObj := newScope.Lookup(name)
+		if newObj == nil {
+			removed = append(removed, oldObj)
+			continue
+		}
+
+		c.compareObjects(oldObj, newObj)
+	}
+
+	// A rename cannot be told from a removal and an unrelated addition of
+	// the same type, e.g. "func A(string) error" and "func B(string) error",
+	// so both are reported as removed.
+	for _, oldObj := range removed {
+		c.add("%s %s was removed", kind(oldObj), oldObj.Name())
+	}
+
+	return c.changes
+}
+
+type comparer struct {
+	dir       string
+	qualifier types.Qualifier
+	changes   []Change
+}
+
+func (c *comparer) add(format string, args ...any) {
+	c.changes = append(c.changes, Change{Package: c.dir, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *comparer) typeString(t types.Type) string {
+	return types.TypeString(t, c.qualifier)
+}
+
+func (c *comparer) compareObjects(oldObj, newObj types.Object) {
+	if kind(oldObj) != kind(newObj) {
+		c.add("%s %s was changed to a %s", kind(oldObj), oldObj.Name(), kind(newObj))
+		return
+	}
+
+	oldType, oldIsType := oldObj.(*types.TypeName)
+	newType, newIsType := newObj.(*types.TypeName)
+
+	if oldIsType && newIsType {
+		c.compareTypes(oldType, newType)
+		return
+	}
+
+	oldStr, newStr := c.typeString(oldObj.Type()), c.typeString(newObj.Type())
+	if oldStr != newStr {
+		c.add("%s %s changed type from %s to %s", kind(oldObj), oldObj.Name(), oldStr, newStr)
+	}
+}
+
+//nolint:cyclop
+func (c *comparer) compareTypes(oldType, newType *types.TypeName) {
+	name := oldType.Name()
+	oldUnderlying, newUnderlying := oldType.Type().Underlying(), newType.Type().Underlying()
+
+	switch o := oldUnderlying.(type) {
+	case *types.Struct:
+		n, ok := newUnderlying.(*types.Struct)
+		if !ok {
+			c.add("type %s was changed from a struct to %s", name, c.typeString(newUnderlying))
+			break
+		}
+
+		c.compareStructs(name, o, n)
+	case *types.Interface:
+		n, ok := newUnderlying.(*types.Interface)
+		if !ok {
+			c.add("type %s was changed from an interface to %s", name, c.typeString(newUnderlying))
+			break
+		}
+
+		c.compareInterfaces(name, o, n)
+
+		return
+	default:
+		oldStr, newStr := c.typeString(oldUnderlying), c.typeString(newUnderlying)
+		if oldStr != newStr {
+			c.add("type %s changed from %s to %s", name, oldStr, newStr)
+		}
+	}
+
+	c.compareMethods(name, oldType.Type(), newType.Type())
+}
+
+func (c *comparer) compareStructs(name string, oldStruct, newStruct *types.Struct) {
+	newFields := map[string]*types.Var{}
+	for i := 0; i < newStruct.NumFields(); i++ {
+		newFields[newStruct.Field(i).Name()] = newStruct.Field(i)
+	}
+
+	for i := 0; i < oldStruct.NumFields(); i++ {
+		oldField := oldStruct.Field(i)
+		if !oldField.Exported() {
+			continue
+		}
+
+		newField, ok := newFields[oldField.Name()]
+		if !ok || !newField.Exported() {
+			c.add("field %s.%s was removed", name, oldField.Name())
+			continue
+		}
+
+		oldStr, newStr := c.typeString(oldField.Type()), c.typeString(newField.Type())
+		if oldStr != newStr {
+			c.add("field %s.%s changed type from %s to %s", name, oldField.Name(), oldStr, newStr)
+		}
+	}
+}
+
+func (c *comparer) compareInterfaces(name string, oldIface, newIface *types.Interface) {
+	oldMethods := map[string]*types.Func{}
+	for i := 0; i < oldIface.NumMethods(); i++ {
+		oldMethods[oldIface.Method(i).Name()] = oldIface.Method(i)
+	}
+
+	for i := 0; i < newIface.NumMethods(); i++ {
+		newMethod := newIface.Method(i)
+
+		oldMethod, ok := oldMethods[newMethod.Name()]
+		if !ok {
+			c.add("method %s was added to interface %s", newMethod.Name(), name)
+			continue
+		}
+
+		delete(oldMethods, newMethod.Name())
+
+		oldStr, newStr := c.typeString(oldMethod.Type()), c.typeString(newMethod.Type())
+		if oldStr != newStr {
+			c.add("method %s.%s changed signature from %s to %s", name, newMethod.Name(), oldStr, newStr)
+		}
+	}
+
+	for i := 0; i < oldIface.NumMethods(); i++ {
+		if _, ok := oldMethods[oldIface.Method(i).Name()]; ok && oldIface.Method(i).Exported() {
+			c.add("method %s was removed from interface %s", oldIface.Method(i).Name(), name)
+		}
+	}
+}
+
+// compareMethods compares the exported methods declared on the named types
+// (including pointer receivers).
+func (c *comparer) compareMethods(name string, oldType, newType types.Type) {
+	oldSet := types.NewMethodSet(types.NewPointer(oldType))
+	newSet := types.NewMethodSet(types.NewPointer(newType))
+
+	for i := 0; i < oldSet.Len(); i++ {
+		oldMethod := oldSet.At(i).Obj()
+		if !oldMethod.Exported() {
+			continue
+		}
+
+		// The package is not needed to look up exported methods.
+		selection := newSet.Lookup(nil, oldMethod.Name())
+		if selection == nil {
+			c.add("method %s.%s was removed", name, oldMethod.Name())
+			continue
+		}
+
+		oldStr, newStr := c.typeString(oldMethod.Type()), c.typeString(selection.Obj().Type())
+		if oldStr != newStr {
+			c.add("method %s.%s changed signature from %s to %s", name, oldMethod.Name(), oldStr, newStr)
+		}
+	}
+}
+
+func kind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Func:
+		return "func"
+	case *types.TypeName:
+		return "type"
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		return "var"
+	default:
+		return "identifier"
+	}
+}
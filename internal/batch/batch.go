@@ -0,0 +1,200 @@
+/*
+Package batch runs a job, e.g. suggesting a message, for many commits in one
+or more repositories, with a pool of workers, rate limits and a budget.
+
+The results are written as JSON lines, which are also the checkpoint of the
+batch: ReadResults returns the jobs that are done, so an interrupted batch can
+be resumed by skipping them.
+*/
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned by Run when the budget is spent.
+var ErrBudgetExceeded = errors.New("the budget is spent")
+
+// Job is a commit to run the job for.
+type Job struct {
+	// Repo is the directory of the repository.
+	Repo string `json:"repo"`
+
+	Revision string `json:"revision"`
+}
+
+// Key identifies the job in the checkpoint.
+func (j Job) Key() string {
+	return j.Repo + "@" + j.Revision
+}
+
+// Result is the result of a job, written as a line of JSON.
+type Result struct {
+	Job
+
+	// Message is the current message of the commit.
+	Message string `json:"message"`
+
+	// Suggested is the suggested message, empty on error.
+	Suggested string `json:"suggested,omitempty"`
+
+	// Provider is the name of the provider that suggested the message.
+	Provider string `json:"provider,omitempty"`
+
+	// Cost is the cost of the requests in cent.
+	Cost float64 `json:"cost"`
+
+	// Error is empty if the job succeeded. Failed jobs are run again when
+	// the batch is resumed.
+	Error string `json:"error,omitempty"`
+}
+
+// Runner runs jobs concurrently.
+type Runner struct {
+	// Workers is the number of jobs run at the same time, at least one.
+	Workers int
+
+	// Budget is the most that may be spent in cent, no limit if zero. When
+	// it is spent no more jobs are started, and the running jobs are
+	// finished.
+	Budget float64
+
+	// Do runs a job. Errors are returned in the result.
+	Do func(ctx context.Context, job Job) Result
+
+	// OnResult is called with each result and the number of results so
+	// far, from one goroutine at a time.
+	OnResult func(result Result, done int)
+}
+
+// Run runs the jobs and returns what was spent, including spent from
+// earlier runs, e.g. the spent of ReadResults when the batch is resumed. It
+// returns ErrBudgetExceeded if the budget was spent before all jobs were
+// started.
+func (r Runner) Run(ctx context.Context, jobs []Job, spent float64) (float64, error) {
+	if r.Budget > 0 && spent >= r.Budget {
+		return spent, ErrBudgetExceeded
+	}
+
+	workers := r.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan Job)
+	results := make(chan Result)
+
+	go func() {
+		defer close(queue)
+
+		for _, job := range jobs {
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		skipped bool
+		wg      sync.WaitGroup
+	)
+
+	// A worker adds the cost of its job before it takes the next one, so no
+	// job starts after the budget is spent, only the running ones finish.
+	start := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Budget > 0 && spent >= r.Budget {
+			skipped = true
+			return false
+		}
+
+		return true
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range queue {
+				if !start() {
+					continue
+				}
+
+				result := r.Do(ctx, job)
+
+				mu.Lock()
+				spent += result.Cost
+				mu.Unlock()
+
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	done := 0
+
+	for result := range results {
+		done++
+
+		if r.OnResult != nil {
+			r.OnResult(result, done)
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return spent, ctx.Err()
+	case skipped:
+		return spent, ErrBudgetExceeded
+	default:
+		return spent, nil
+	}
+}
+
+// ReadResults reads the results written by an earlier run of the batch, and
+// returns the keys of the jobs that succeeded and what was spent. Lines that
+// cannot be decoded, e.g. a line cut off when the batch was killed, are
+// skipped.
+func ReadResults(r io.Reader) (done map[string]bool, spent float64, err error) {
+	done = map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var result Result
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			continue
+		}
+
+		spent += result.Cost
+
+		if result.Error == "" {
+			done[result.Key()] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("read results: %w", err)
+	}
+
+	return done, spent, nil
+}
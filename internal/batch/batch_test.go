@@ -0,0 +1,203 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/philiplinell/commit-msg/internal/batch"
+)
+
+func jobs(n int) []batch.Job {
+	result := make([]batch.Job, n)
+	for i := range result {
+		result[i] = batch.Job{Repo: ".", Revision: fmt.Sprint(i)}
+	}
+
+	return result
+}
+
+func TestRunnerRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jobs     int
+		workers  int
+		budget   float64
+		spent    float64
+		cost     float64
+		err      error
+		maxDone  int
+		minDone  int
+		expected float64
+	}{
+		{name: "no budget", jobs: 10, workers: 3, cost: 1, minDone: 10, maxDone: 10, expected: 10},
+		{name: "within budget", jobs: 4, workers: 2, budget: 10, cost: 2, minDone: 4, maxDone: 4, expected: 8},
+		{name: "budget spent", jobs: 10, workers: 1, budget: 3, cost: 1, err: batch.ErrBudgetExceeded, minDone: 3, maxDone: 3, expected: 3},
+		{name: "budget spent in flight", jobs: 10, workers: 2, budget: 3, cost: 1, err: batch.ErrBudgetExceeded, minDone: 3, maxDone: 5},
+		{name: "budget spent before", jobs: 10, workers: 2, budget: 3, spent: 3, cost: 1, err: batch.ErrBudgetExceeded, expected: 3},
+		{name: "no workers", jobs: 2, workers: 0, cost: 1, minDone: 2, maxDone: 2, expected: 2},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			var running, maxRunning int32
+
+			runner := batch.Runner{
+				Workers: tc.workers,
+				Budget:  tc.budget,
+				Do: func(ctx context.Context, job batch.Job) batch.Result {
+					n := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+
+					for {
+						m := atomic.LoadInt32(&maxRunning)
+						if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+							break
+						}
+					}
+
+					time.Sleep(time.Millisecond)
+
+					return batch.Result{Job: job, Cost: tc.cost}
+				},
+			}
+
+			done := 0
+			runner.OnResult = func(result batch.Result, n int) {
+				done++
+				if n != done {
+					t.Errorf("expected result %d, got %d", done, n)
+				}
+			}
+
+			spent, err := runner.Run(context.Background(), jobs(tc.jobs), tc.spent)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+
+			if done < tc.minDone || done > tc.maxDone {
+				t.Errorf("expected %d to %d results, got %d", tc.minDone, tc.maxDone, done)
+			}
+
+			if tc.expected != 0 && spent != tc.expected {
+				t.Errorf("expected %.0f spent, got %.0f", tc.expected, spent)
+			}
+
+			if spent != tc.spent+float64(done)*tc.cost {
+				t.Errorf("expected the spent to add up, got %.0f for %d results", spent, done)
+			}
+
+			workers := int32(tc.workers)
+			if workers < 1 {
+				workers = 1
+			}
+
+			if maxRunning > workers {
+				t.Errorf("expected at most %d running jobs, got %d", workers, maxRunning)
+			}
+		})
+	}
+}
+
+func TestRunnerRunNoJobAfterBudget(t *testing.T) {
+	// Repeated, since a job started after the budget is spent depends on the
+	// scheduling.
+	for i := 0; i < 100; i++ {
+		var started int32
+
+		runner := batch.Runner{
+			Workers: 1,
+			Budget:  2,
+			Do: func(ctx context.Context, job batch.Job) batch.Result {
+				atomic.AddInt32(&started, 1)
+
+				return batch.Result{Job: job, Cost: 1}
+			},
+		}
+
+		spent, err := runner.Run(context.Background(), jobs(10), 0)
+		if !errors.Is(err, batch.ErrBudgetExceeded) {
+			t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+		}
+
+		if started != 2 || spent != 2 {
+			t.Fatalf("expected 2 jobs started and 2 spent, got %d and %.0f", started, spent)
+		}
+	}
+}
+
+func TestRunnerRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runner := batch.Runner{
+		Workers: 2,
+		Do: func(ctx context.Context, job batch.Job) batch.Result {
+			cancel()
+
+			return batch.Result{Job: job, Error: ctx.Err().Error()}
+		},
+	}
+
+	_, err := runner.Run(ctx, jobs(100), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestReadResults(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		done     []string
+		expected float64
+	}{
+		{name: "empty"},
+		{
+			name: "done and failed",
+			input: `{"repo":".","revision":"a","message":"m","suggested":"s","cost":1.5}
+{"repo":".","revision":"b","message":"m","cost":0.5,"error":"timeout"}
+{"repo":"other","revision":"a","message":"m","suggested":"s","cost":1}
+`,
+			done:     []string{".@a", "other@a"},
+			expected: 3,
+		},
+		{
+			name: "cut off line",
+			input: `{"repo":".","revision":"a","message":"m","suggested":"s","cost":1}
+{"repo":".","revision":"b","mess`,
+			done:     []string{".@a"},
+			expected: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			done, spent, err := batch.ReadResults(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(done) != len(tc.done) {
+				t.Errorf("expected %v done, got %v", tc.done, done)
+			}
+
+			for _, key := range tc.done {
+				if !done[key] {
+					t.Errorf("expected %q to be done", key)
+				}
+			}
+
+			if spent != tc.expected {
+				t.Errorf("expected %.1f spent, got %.1f", tc.expected, spent)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the requests and the tokens per minute, across all workers.
+// The limits are token buckets, so a burst of up to a minute's worth is
+// allowed after an idle minute.
+type Limiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewLimiter returns a limiter of the requests and the tokens per minute. A
+// limit of zero is no limit.
+func NewLimiter(requestsPerMinute, tokensPerMinute int) *Limiter {
+	now := time.Now()
+
+	return &Limiter{
+		requests: newBucket(requestsPerMinute, now),
+		tokens:   newBucket(tokensPerMinute, now),
+	}
+}
+
+// Wait blocks until a request of the tokens is allowed, or the context is
+// done. A request of more tokens than the limit per minute waits for a full
+// bucket.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	l.mu.Lock()
+	now := time.Now()
+	delay := l.requests.reserve(1, now)
+
+	if d := l.tokens.reserve(float64(tokens), now); d > delay {
+		delay = d
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bucket is a token bucket refilled at limit per minute. It is nil without a
+// limit.
+type bucket struct {
+	limit     float64
+	available float64
+	last      time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &bucket{limit: float64(perMinute), available: float64(perMinute), last: now}
+}
+
+// reserve takes n from the bucket, and returns how long to wait until they
+// were available. The bucket may go negative, so later reservations wait for
+// the earlier ones.
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.available += now.Sub(b.last).Minutes() * b.limit
+	if b.available > b.limit {
+		b.available = b.limit
+	}
+
+	b.last = now
+
+	if n > b.limit {
+		n = b.limit
+	}
+
+	b.available -= n
+
+	if b.available >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.available / b.limit * float64(time.Minute))
+}
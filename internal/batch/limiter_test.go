@@ -0,0 +1,51 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philiplinell/commit-msg/internal/batch"
+)
+
+func TestLimiterWait(t *testing.T) {
+	testCases := []struct {
+		name     string
+		requests int
+		tokens   int
+		waits    []int
+		blocked  bool
+	}{
+		{name: "no limits", waits: []int{1000000, 1000000, 1000000}},
+		{name: "within requests", requests: 3, waits: []int{1, 1, 1}},
+		{name: "requests exceeded", requests: 3, waits: []int{1, 1, 1, 1}, blocked: true},
+		{name: "within tokens", tokens: 1000, waits: []int{500, 400, 100}},
+		{name: "tokens exceeded", tokens: 1000, waits: []int{500, 400, 200}, blocked: true},
+		{name: "more tokens than the limit", tokens: 1000, waits: []int{5000}},
+		{name: "more tokens than the limit exceeded", tokens: 1000, waits: []int{5000, 1}, blocked: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := batch.NewLimiter(tc.requests, tc.tokens)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			var err error
+
+			for _, tokens := range tc.waits {
+				if err = limiter.Wait(ctx, tokens); err != nil {
+					break
+				}
+			}
+
+			if tc.blocked != errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected blocked %t, got %v", tc.blocked, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package batch
+
+import (
+	"context"
+	"sync"
+
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+// limitedProvider waits for the limiter before each request.
+type limitedProvider struct {
+	provider.Provider
+
+	limiter      *Limiter
+	answerTokens int
+}
+
+// Limit returns the provider waiting for the limiter before each request, so
+// each request to a model is limited, also those of a fallback, a hedge or a
+// self-check. The tokens of a request are estimated from its messages, plus
+// answerTokens for the answer.
+func Limit(p provider.Provider, limiter *Limiter, answerTokens int) provider.Provider {
+	return limitedProvider{Provider: p, limiter: limiter, answerTokens: answerTokens}
+}
+
+func (p limitedProvider) Complete(ctx context.Context, request provider.Request) (provider.Response, error) {
+	n := p.answerTokens
+	for _, message := range request.Messages {
+		n += tokens.Estimate(message.Content)
+	}
+
+	if err := p.limiter.Wait(ctx, n); err != nil {
+		return provider.Response{}, err
+	}
+
+	return p.Provider.Complete(ctx, request)
+}
+
+// Meter sums the cost of the requests to a provider, including the cost of
+// failed requests, e.g. of a chain whose first provider answered with an
+// invalid message.
+type Meter struct {
+	provider.Provider
+
+	mu   sync.Mutex
+	cost float64
+}
+
+// NewMeter returns a meter of the requests to the provider.
+func NewMeter(p provider.Provider) *Meter {
+	return &Meter{Provider: p}
+}
+
+// Complete completes the request and adds its cost.
+func (m *Meter) Complete(ctx context.Context, request provider.Request) (provider.Response, error) {
+	response, err := m.Provider.Complete(ctx, request)
+
+	m.mu.Lock()
+	m.cost += response.Cost
+	m.mu.Unlock()
+
+	return response, err
+}
+
+// Cost returns the cost of the requests so far in cent.
+func (m *Meter) Cost() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.cost * 100
+}
@@ -0,0 +1,96 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philiplinell/commit-msg/internal/batch"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+type fakeProvider struct {
+	cost float64
+	err  error
+}
+
+func (fakeProvider) Name() string {
+	return "fake"
+}
+
+func (f fakeProvider) Complete(_ context.Context, _ provider.Request) (provider.Response, error) {
+	return provider.Response{Content: "{}", Cost: f.cost}, f.err
+}
+
+func TestMeter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider fakeProvider
+		requests int
+		expected float64
+	}{
+		{name: "answered", provider: fakeProvider{cost: 0.01}, requests: 2, expected: 2},
+		{name: "failed after paying", provider: fakeProvider{cost: 0.02, err: errors.New("invalid answer")}, requests: 1, expected: 2},
+		{name: "no requests", provider: fakeProvider{cost: 0.01}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			meter := batch.NewMeter(tc.provider)
+
+			for i := 0; i < tc.requests; i++ {
+				_, _ = meter.Complete(context.Background(), provider.Request{})
+			}
+
+			if got := meter.Cost(); got < tc.expected-1e-9 || got > tc.expected+1e-9 {
+				t.Errorf("got %f cent, want %f", got, tc.expected)
+			}
+
+			if meter.Name() != "fake" {
+				t.Errorf("got name %q, want the name of the provider", meter.Name())
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	request := provider.Request{Messages: []openai.Message{{Role: openai.UserRole, Content: "hello"}}}
+
+	testCases := []struct {
+		name     string
+		requests int
+		tokens   int
+		calls    int
+		blocked  bool
+	}{
+		{name: "within requests", requests: 2, calls: 2},
+		{name: "requests exceeded", requests: 2, calls: 3, blocked: true},
+		{name: "answer tokens exceeded", tokens: 250, calls: 3, blocked: true},
+		{name: "within tokens", tokens: 1000, calls: 3},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			limited := batch.Limit(fakeProvider{}, batch.NewLimiter(tc.requests, tc.tokens), 100)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			var err error
+
+			for i := 0; i < tc.calls && err == nil; i++ {
+				_, err = limited.Complete(ctx, request)
+			}
+
+			if tc.blocked != errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected blocked %t, got %v", tc.blocked, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Package changelog builds release notes from commit messages, in the format
+of Keep a Changelog (https://keepachangelog.com/en/1.1.0/).
+
+Conventional commits are grouped by type: feat is Added, fix is Fixed, perf,
+refactor and revert are Changed, and deprecate, remove and security get the
+sections of the same name. Types that do not affect the users, e.g. docs,
+test, ci and chore, are left out. Other messages are grouped by the first word
+of the subject, e.g. "Add" or "Fix", and are Changed by default.
+*/
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+)
+
+// Unreleased is the version of the changes that are not released yet.
+const Unreleased = "Unreleased"
+
+// The sections of Keep a Changelog, in the order they are rendered.
+const (
+	Added      = "Added"
+	Changed    = "Changed"
+	Deprecated = "Deprecated"
+	Removed    = "Removed"
+	Fixed      = "Fixed"
+	Security   = "Security"
+)
+
+//nolint:gochecknoglobals
+var sectionOrder = []string{Added, Changed, Deprecated, Removed, Fixed, Security}
+
+//nolint:gochecknoglobals
+var typeSections = map[string]string{
+	"feat":      Added,
+	"fix":       Fixed,
+	"perf":      Changed,
+	"refactor":  Changed,
+	"revert":    Changed,
+	"deprecate": Deprecated,
+	"remove":    Removed,
+	"security":  Security,
+}
+
+// verbSections are the sections of messages that are not conventional
+// commits, by the first word of the subject.
+//
+//nolint:gochecknoglobals
+var verbSections = map[string]string{
+	"add":       Added,
+	"implement": Added,
+	"introduce": Added,
+	"support":   Added,
+	"fix":       Fixed,
+	"correct":   Fixed,
+	"resolve":   Fixed,
+	"remove":    Removed,
+	"delete":    Removed,
+	"drop":      Removed,
+	"deprecate": Deprecated,
+	"merge":     "",
+	"bump":      "",
+	"release":   "",
+	"wip":       "",
+	"fixup!":    "",
+	"squash!":   "",
+	"amend!":    "",
+}
+
+// Commit is a commit to include in the release notes.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Entry is a change in the release notes.
+type Entry struct {
+	// Text is the user-facing description of the change.
+	Text string `json:"text"`
+
+	// Scope is the conventional commit scope, if any.
+	Scope string `json:"scope,omitempty"`
+
+	// Breaking is true if the change breaks backwards compatibility.
+	Breaking bool `json:"breaking,omitempty"`
+
+	// Hash is the hash of the commit.
+	Hash string `json:"hash"`
+
+	// Message is the original commit message.
+	Message string `json:"message"`
+}
+
+// Section is a group of changes, e.g. Added.
+type Section struct {
+	Title   string  `json:"title"`
+	Entries []Entry `json:"entries"`
+}
+
+// Release is the release notes of a version.
+type Release struct {
+	// Version is e.g. "1.3.0" or Unreleased.
+	Version string `json:"version"`
+
+	// Date is the date of the release, e.g. "2023-05-01", empty for
+	// Unreleased.
+	Date string `json:"date,omitempty"`
+
+	Sections []Section `json:"sections"`
+}
+
+// Build returns the release notes of the commits, most recent first as
+// returned by git log. Within a section the entries are sorted by scope,
+// keeping the order of the commits.
+func Build(version, date string, commits []Commit) Release {
+	entries := map[string][]Entry{}
+
+	for _, commit := range commits {
+		section, entry, ok := classify(commit)
+		if !ok {
+			continue
+		}
+
+		entries[section] = append(entries[section], entry)
+	}
+
+	release := Release{
+		Version:  version,
+		Date:     date,
+		Sections: []Section{},
+	}
+
+	for _, title := range sectionOrder {
+		if len(entries[title]) == 0 {
+			continue
+		}
+
+		sectionEntries := entries[title]
+		sort.SliceStable(sectionEntries, func(i, j int) bool {
+			return sectionEntries[i].Scope < sectionEntries[j].Scope
+		})
+
+		release.Sections = append(release.Sections, Section{Title: title, Entries: sectionEntries})
+	}
+
+	return release
+}
+
+// classify returns the section and entry of the commit, and false if the
+// commit should not be in the release notes.
+func classify(commit Commit) (string, Entry, bool) {
+	message := strings.TrimSpace(commit.Message)
+	_, rest := gitmoji.Cut(message)
+
+	entry := Entry{Hash: commit.Hash, Message: message}
+
+	if parsed, err := conventional.Parse(rest); err == nil {
+		entry.Text = capitalize(parsed.Description)
+		entry.Scope = parsed.Scope
+		entry.Breaking = parsed.BreakingChange() != ""
+
+		section, ok := typeSections[parsed.Type]
+		if !ok {
+			if !entry.Breaking {
+				return "", Entry{}, false
+			}
+
+			// A breaking change is always user-facing.
+			section = Changed
+		}
+
+		return section, entry, true
+	}
+
+	subject, _, _ := strings.Cut(rest, "\n")
+	subject = strings.TrimSpace(subject)
+
+	if subject == "" {
+		return "", Entry{}, false
+	}
+
+	entry.Text = capitalize(subject)
+
+	verb, _, _ := strings.Cut(strings.ToLower(subject), " ")
+
+	section, ok := verbSections[verb]
+	switch {
+	case !ok:
+		return Changed, entry, true
+	case section == "":
+		return "", Entry{}, false
+	}
+
+	return section, entry, true
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// Entries returns the entries of all sections, in the order they are
+// rendered.
+func (r Release) Entries() []Entry {
+	entries := []Entry{}
+	for _, section := range r.Sections {
+		entries = append(entries, section.Entries...)
+	}
+
+	return entries
+}
+
+// Heading returns the heading of the release, e.g. "## [1.3.0] - 2023-05-01".
+func (r Release) Heading() string {
+	if r.Date == "" {
+		return fmt.Sprintf("## [%s]", r.Version)
+	}
+
+	return fmt.Sprintf("## [%s] - %s", r.Version, r.Date)
+}
+
+// Markdown returns the release notes, starting with the heading.
+func (r Release) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString(r.Heading())
+
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "\n\n### %s\n", section.Title)
+
+		for _, entry := range section.Entries {
+			b.WriteString("\n- ")
+
+			if entry.Breaking {
+				b.WriteString("**BREAKING:** ")
+			}
+
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+			}
+
+			b.WriteString(strings.ReplaceAll(strings.TrimSpace(entry.Text), "\n", "\n  "))
+		}
+	}
+
+	return b.String()
+}
+
+// JSON returns the release notes as indented JSON.
+func (r Release) JSON() (string, error) {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("could not encode the release notes: %w", err)
+	}
+
+	return string(out), nil
+}
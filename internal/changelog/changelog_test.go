@@ -0,0 +1,98 @@
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/changelog"
+)
+
+func TestBuild(t *testing.T) {
+	commits := []changelog.Commit{
+		{Hash: "a1", Message: "feat(search): add search by email"},
+		{Hash: "a2", Message: "fix: handle empty queries"},
+		{Hash: "a3", Message: "docs: describe the search"},
+		{Hash: "a4", Message: "feat(api)!: remove the v1 endpoints\n\nBREAKING CHANGE: use /v2 instead"},
+		{Hash: "a5", Message: "Remove the legacy importer"},
+		{Hash: "a6", Message: "Tweak the logging"},
+		{Hash: "a7", Message: "✨ feat: add dark mode"},
+		{Hash: "a8", Message: "Bump the version"},
+		{Hash: "a9", Message: "chore!: require Go 1.19"},
+	}
+
+	expected := `## [1.3.0] - 2023-05-01
+
+### Added
+
+- Add dark mode
+- **BREAKING:** **api:** Remove the v1 endpoints
+- **search:** Add search by email
+
+### Changed
+
+- Tweak the logging
+- **BREAKING:** Require Go 1.19
+
+### Removed
+
+- Remove the legacy importer
+
+### Fixed
+
+- Handle empty queries`
+
+	release := changelog.Build("1.3.0", "2023-05-01", commits)
+
+	if got := release.Markdown(); got != expected {
+		t.Errorf("got\n%s\nwant\n%s", got, expected)
+	}
+
+	if len(release.Entries()) != 7 {
+		t.Errorf("got %d entries, want 7", len(release.Entries()))
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	release := changelog.Build("1.3.0", "2023-05-01", []changelog.Commit{{Hash: "a1", Message: "feat: add search"}})
+
+	testCases := []struct {
+		name      string
+		changelog string
+		expected  string
+	}{
+		{
+			name:      "new changelog",
+			changelog: "",
+			expected:  changelog.Header + "\n\n## [1.3.0] - 2023-05-01\n\n### Added\n\n- Add search\n",
+		},
+		{
+			name:      "before previous releases",
+			changelog: "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Add search\n\n## [1.2.0] - 2023-04-01\n\n### Fixed\n\n- Fix login\n\n[1.2.0]: https://example.com/v1.2.0\n",
+			expected:  "# Changelog\n\n## [1.3.0] - 2023-05-01\n\n### Added\n\n- Add search\n\n## [1.2.0] - 2023-04-01\n\n### Fixed\n\n- Fix login\n\n[1.2.0]: https://example.com/v1.2.0\n",
+		},
+		{
+			name:      "replaces the same version",
+			changelog: "# Changelog\n\n## [1.3.0] - 2023-04-30\n\n### Added\n\n- Add serch\n\n## [1.2.0] - 2023-04-01\n",
+			expected:  "# Changelog\n\n## [1.3.0] - 2023-05-01\n\n### Added\n\n- Add search\n\n## [1.2.0] - 2023-04-01\n",
+		},
+		{
+			name:      "without releases",
+			changelog: "# Changelog\n\nNotes.\n",
+			expected:  "# Changelog\n\nNotes.\n\n## [1.3.0] - 2023-05-01\n\n### Added\n\n- Add search\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			got := changelog.Prepend(tc.changelog, release)
+			if got != tc.expected {
+				t.Errorf("got\n%q\nwant\n%q", got, tc.expected)
+			}
+
+			if again := changelog.Prepend(got, release); again != got {
+				t.Errorf("prepending again changed the changelog to\n%q", again)
+			}
+		})
+	}
+}
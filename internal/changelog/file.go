@@ -0,0 +1,91 @@
+package changelog
+
+import (
+	"strings"
+)
+
+// Header is the beginning of a new changelog.
+const Header = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).`
+
+// Prepend returns the changelog with the release notes added before the
+// previous releases. A release with the same version, e.g. from a previous
+// run, is replaced, so that prepending is idempotent. A new release also
+// replaces the Unreleased section. An empty changelog gets Header first.
+func Prepend(changelog string, release Release) string {
+	changelog = strings.ReplaceAll(changelog, "\r\n", "\n")
+	if strings.TrimSpace(changelog) == "" {
+		changelog = Header + "\n"
+	}
+
+	lines := strings.Split(changelog, "\n")
+
+	// The release is inserted at the first release heading, or at the end.
+	start, end := len(lines), len(lines)
+
+	for i, line := range lines {
+		version, ok := releaseVersion(line)
+		if !ok {
+			continue
+		}
+
+		if start == len(lines) {
+			start = i
+			end = i
+		}
+
+		if version != release.Version && version != Unreleased {
+			break
+		}
+
+		// Replace the release, and the Unreleased section before it.
+		end = nextRelease(lines, i)
+	}
+
+	before := strings.TrimRight(strings.Join(lines[:start], "\n"), "\n")
+	after := strings.TrimLeft(strings.Join(lines[end:], "\n"), "\n")
+
+	parts := []string{before, release.Markdown()}
+	if after != "" {
+		parts = append(parts, strings.TrimRight(after, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// releaseVersion returns the version of a release heading, e.g. "1.3.0" for
+// "## [1.3.0] - 2023-05-01".
+func releaseVersion(line string) (string, bool) {
+	if !strings.HasPrefix(line, "## ") {
+		return "", false
+	}
+
+	heading := strings.TrimSpace(strings.TrimPrefix(line, "## "))
+	heading = strings.TrimPrefix(heading, "[")
+
+	version, _, _ := strings.Cut(heading, " ")
+	version = strings.TrimSuffix(version, "]")
+
+	return version, version != ""
+}
+
+// nextRelease returns the index of the release heading after the one at i, or
+// the index of the first link reference definition at the end.
+func nextRelease(lines []string, i int) int {
+	for j := i + 1; j < len(lines); j++ {
+		if _, ok := releaseVersion(lines[j]); ok || isLinkDefinition(lines[j]) {
+			return j
+		}
+	}
+
+	return len(lines)
+}
+
+// isLinkDefinition returns true for the links of the versions at the end of a
+// changelog, e.g. "[1.3.0]: https://github.com/...".
+func isLinkDefinition(line string) bool {
+	return strings.HasPrefix(line, "[") && strings.Contains(line, "]: ")
+}
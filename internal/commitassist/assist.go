@@ -2,10 +2,24 @@ package commitassist
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/philiplinell/commit-msg/internal/apidiff"
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/fewshot"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+	"github.com/philiplinell/commit-msg/internal/issue"
+	"github.com/philiplinell/commit-msg/internal/language"
 	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/squash"
+	"github.com/philiplinell/commit-msg/internal/style"
+	"github.com/philiplinell/commit-msg/internal/trailer"
 )
 
 type UnexpectedStateError struct {
@@ -25,22 +39,47 @@ func (e UnsureError) Error() string {
 }
 
 type Client struct {
-	client *openai.Client
+	provider provider.Provider
+	styles   *style.Registry
 }
 
-func New(client *openai.Client) *Client {
+// New returns a client suggesting messages with the provider, in the styles
+// of the registry (see package style).
+func New(p provider.Provider, styles *style.Registry) *Client {
 	return &Client{
-		client: client,
+		provider: p,
+		styles:   styles,
 	}
 }
 
 type GetTypeResponse struct {
 	Message string
 
-	// Cost is the cost of the request in cent.
+	// Structured are the fields of Message, e.g. the conventional commit
+	// type, and the confidence and reasoning of the model.
+	Structured StructuredMessage
+
+	// SuggestedTrailers are trailers that might apply to the commit but
+	// were not added to the message, e.g. recent co-authors.
+	SuggestedTrailers []trailer.Trailer
+
+	// Confidence is how confident the model is in the message, from 0 to 1,
+	// the lowest of the Signals. It is negative if unknown, e.g. for a
+	// message from FallbackMessage.
+	Confidence float64
+	Signals    confidence.Signals
+
+	// Provider is the name of the provider that suggested the message, see
+	// package provider.
+	Provider string
+
+	// Cost is the cost of the requests in cent, combined for all providers
+	// that were tried.
 	Cost float64
 }
 
+// Style is the name of a style in the registry of the client. The built-in
+// styles are listed below, more can be loaded from template files.
 type Style string
 
 const (
@@ -63,63 +102,166 @@ const (
 	// and then details the solution that was implemented. It's especially
 	// useful when the commit addresses specific bugs or issues.
 	ProblemSolution Style = "ProblemSolution"
+
+	// RepoStyle: This style follows the previous commits of the repository,
+	// which are used as examples (see MessageConfig.Examples). Without
+	// examples it is the same as DescriptiveAndNeutral.
+	RepoStyle Style = "RepoStyle"
+
+	// Gitmoji: This style prefixes the subject with a gitmoji from the
+	// official list (see package gitmoji), selected by the type of change.
+	// It can be combined with conventional commits.
+	Gitmoji Style = "Gitmoji"
 )
 
-// ValidateMessageStyle returns an error if the assumedStyle is not a valid.
-func ValidateMessageStyle(assumedStyle string) (Style, error) {
-	switch assumedStyle {
-	case string(DescriptiveAndNeutral), string(ConversationalAndCasual), string(ListBased), string(ProblemSolution):
-		return Style(assumedStyle), nil
-	default:
+// ValidateMessageStyle returns an error if the assumedStyle is not a style
+// in the registry.
+func (o *Client) ValidateMessageStyle(assumedStyle string) (Style, error) {
+	if !o.styles.Has(assumedStyle) {
 		return "", fmt.Errorf("invalid style %q", assumedStyle)
 	}
+
+	return Style(assumedStyle), nil
+}
+
+// InvalidMessageError is returned when the message returned by the model
+// does not follow the rules of the repository and could not be repaired.
+type InvalidMessageError struct {
+	Msg string
+}
+
+func (e InvalidMessageError) Error() string {
+	return e.Msg
 }
 
 type MessageConfig struct {
 	Style                       Style
 	ConventionalCommitCompliant bool
+
+	// ConventionalRules is the vocabulary of types and scopes allowed in
+	// conventional commits. Only used if ConventionalCommitCompliant is true.
+	ConventionalRules conventional.Rules
+
+	// APIChanges are the breaking changes to exported Go identifiers found
+	// in the diff, see package apidiff.
+	APIChanges apidiff.Report
+
+	// Branch is the name of the current branch. Issue references found in
+	// it are added to the message as configured by Issues.
+	Branch string
+	Issues issue.Config
+
+	// Trailers are added to the message, e.g. the trailers already in the
+	// commit message file followed by "Signed-off-by". Trailers already in
+	// the message are not added again.
+	Trailers []trailer.Trailer
+
+	// SuggestedTrailers are returned in the response unless they are already
+	// in the message.
+	SuggestedTrailers []trailer.Trailer
+
+	// Examples are previous commits of the repository, used instead of the
+	// built-in example when Style is RepoStyle.
+	Examples []fewshot.Example
+
+	// Gitmoji configures the gitmojis when Style is Gitmoji.
+	Gitmoji gitmoji.Config
+
+	// Language is the BCP 47 tag of the language of the subject and body,
+	// e.g. "de" or "sv-SE". Empty is English. Conventional commit types and
+	// trailers are always in English.
+	Language string
+
+	// SelfCheck asks the model if the message matches the diff, in a second
+	// request, see confidence.Signals.
+	SelfCheck bool
+
+	// SquashedMessages are the messages of the commits squashed into this
+	// one, oldest first, see package squash. The message then summarizes
+	// the net diff, and the trailers and issue references of the squashed
+	// messages are kept.
+	SquashedMessages []string
 }
 
 // GetCommitMessage returns a commit message based on the git diff provided.
 //nolint:funlen
 func (o *Client) GetCommitMessage(ctx context.Context, gitDiff string, cfg *MessageConfig) (GetTypeResponse, error) {
-	// styleDescriptions is a map of the style to a description of the style,
-	// to be used in the prompt to the OpenAI API. It should be used after "The
-	// style of the commit messages should be ".
-	styleDescriptions := map[Style]string{
-		DescriptiveAndNeutral:   "descriptive and neutral. Use clear, concise language to describe the changes. The message should be objective and factual, focusing solely on what was done, without injecting personal opinions or unnecessary context",
-		ConversationalAndCasual: "conversational and casual using informal language or even a touch of humor to describe the changes. You should aim to make the commit messages engaging, yet still professional and informative",
-		ListBased:               "list-based. Use bullet points or numbered lists to itemize the changes made. Each point should be concise, specific, and self-explanatory. This style is particularly suitable for commits that involve multiple changes or updates. Despite the structured format, ensure the message provides enough context to understand the changes without having to look at the code",
-		ProblemSolution:         "problem-solution oriented. Begin by clearly outlining the problem or issue that was addressed. Follow this with a concise explanation of the solution implemented to fix the problem. This style encourages a logical and methodical approach to describing changes, and is particularly effective for commits aimed at fixing bugs or improving functionality",
-	}
-
 	if cfg == nil {
 		cfg = &MessageConfig{
 			Style: DescriptiveAndNeutral,
 		}
 	}
 
-	if _, err := ValidateMessageStyle(string(cfg.Style)); err != nil {
+	styleName := cfg.Style
+	if styleName == RepoStyle && len(cfg.Examples) == 0 {
+		styleName = DescriptiveAndNeutral
+	}
+
+	lang, err := language.Parse(cfg.Language)
+	if err != nil {
+		return GetTypeResponse{}, err
+	}
+
+	messageStyle, err := o.styles.Get(string(styleName), style.Data{
+		ConventionalCommit: cfg.ConventionalCommitCompliant,
+		Language:           lang.Base,
+	})
+	if err != nil {
 		return GetTypeResponse{}, err
 	}
 
+	// A diff that cannot be parsed results in no files, and the inference
+	// falls back to a generic type.
+	files, _ := diff.Parse(gitDiff)
+	inference := cfg.ConventionalRules.Infer(files)
+
 	conventionalCommitContent := ""
 	if cfg.ConventionalCommitCompliant {
 		conventionalCommitContent = "Use the conventional commit standard, including any breaking changes, which should be denoted with a '!' (e.g., 'feat!')."
+		conventionalCommitContent += "\n" + conventionalConstraints(cfg.ConventionalRules)
+		conventionalCommitContent += "\n" + conventionalHint(inference)
+	}
+
+	gitmojiContent := ""
+	if styleName == Gitmoji {
+		gitmojiContent = gitmojiHint(cfg.Gitmoji, inference, cfg.APIChanges.Breaking())
+	}
+
+	squashContent := ""
+	if len(cfg.SquashedMessages) > 0 {
+		squashContent = `The changes squash several commits into one, their messages are given
+before the diff. Summarize the net effect of the changes in one coherent
+message instead of listing the commits, and leave out changes that were
+reverted or fixed by later commits. Keep issue references such as #123 or
+PROJ-1234.`
+	}
+
+	trailerContent := ""
+	if len(cfg.Trailers) > 0 || len(cfg.SquashedMessages) > 0 {
+		trailerContent = "Do not add trailers such as Signed-off-by or Co-authored-by, they are added automatically."
 	}
 
-	return o.doChatCompletionRequest(ctx, []openai.Message{
+	languageContent := languageInstructions(lang, messageStyle.Examples)
+
+	breakingChangeContent := ""
+	if cfg.APIChanges.Breaking() {
+		breakingChangeContent = cfg.APIChanges.Facts() +
+			"Mention these changes in the commit body and end the message with a footer like this draft:\n" +
+			cfg.APIChanges.Footer()
+	}
+
+	messages := []openai.Message{
 		{
 			Role: openai.SystemRole,
 			Content: fmt.Sprintf(`You are an insightful assistant that crafts
 commit messages. The commit messages should accurately and succinctly explain
 the changes made in the files, detailing the reason for changes and the effect
 they will have on the project. Your responses should consist of the commit
-subject and the commit body, separated by newlines.
+subject and the commit body, as separate fields of a JSON object.
 
 The commit subject should:
 - Be brief (50 characters or less)
-- Use the imperative mood (e.g., "Add", "Fix", "Change")
+- Use the %s
 
 The commit body should:
 - Further explain the changes in detail if necessary
@@ -130,94 +272,244 @@ Make sure they provide enough context to understand the changes without having t
 
 The style of the commit message should be %s.
 %s
-`, styleDescriptions[cfg.Style], conventionalCommitContent),
-		},
-		{
-			Role: openai.UserRole,
-			Content: `diff --git a/README.md b/README.md
-new file mode 100644
-index 0000000..ca34b6a
---- /dev/null
-+++ b/README.md
-@@ -0,0 +1,21 @@
-+# Commit Message
-+
-+Create a commit message suggestion from the git diff using the openAI API.
-+
-+Note that this means that filename and lines changed is sent to openAI. If that
-+bothers you - don't use this tool.`,
-		},
-		{
-			Role:    openai.AssistantRole,
-			Content: getExpectedMessage(cfg.Style, cfg.ConventionalCommitCompliant),
+%s
+%s
+%s
+%s
+%s
+`, lang.Mood, messageStyle.Prompt, languageContent, gitmojiContent, conventionalCommitContent, breakingChangeContent, squashContent, trailerContent),
 		},
+	}
 
-		// This is the final message that the assistant should respond to.
-		{
+	messages = append(messages, exampleMessages(messageStyle, cfg, lang)...)
+
+	if len(cfg.SquashedMessages) > 0 {
+		messages = append(messages, openai.Message{
 			Role:    openai.UserRole,
-			Content: gitDiff,
-		},
+			Content: "The messages of the squashed commits, oldest first:\n\n" + strings.Join(cfg.SquashedMessages, "\n\n---\n\n"),
+		})
+	}
+
+	// This is the final message that the assistant should respond to.
+	messages = append(messages, openai.Message{
+		Role:    openai.UserRole,
+		Content: gitDiff,
 	})
+
+	response, err := o.doChatCompletionRequest(ctx, messages, cfg.ConventionalCommitCompliant)
+	if err != nil {
+		return GetTypeResponse{}, err
+	}
+
+	if cfg.SelfCheck {
+		selfCheck, cost, err := o.selfCheck(ctx, gitDiff, response.Message)
+		if err != nil {
+			return GetTypeResponse{}, err
+		}
+
+		response.Signals.SelfCheck = selfCheck
+		response.Cost += cost
+	}
+
+	response.Confidence = response.Signals.Combined()
+
+	return finishMessage(response, cfg, styleName, inference, lang)
+}
+
+// finishMessage corrects the message to follow the configuration and adds
+// issue references and trailers.
+func finishMessage(response GetTypeResponse, cfg *MessageConfig, styleName Style, inference conventional.Inference, lang language.Language) (GetTypeResponse, error) {
+	var err error
+
+	// The gitmoji is removed while the rest of the message is corrected.
+	gitmojiPrefix := ""
+	if styleName == Gitmoji {
+		gitmojiPrefix, response.Message = gitmoji.Cut(response.Message)
+	}
+
+	if cfg.ConventionalCommitCompliant {
+		response.Message, err = correctConventionalMessage(response.Message, cfg.ConventionalRules, inference, cfg.APIChanges, lang)
+		if err != nil {
+			return GetTypeResponse{}, InvalidMessageError{err.Error()}
+		}
+	}
+
+	// The references are added while the gitmoji is removed, so they follow
+	// it and the conventional header can still be parsed.
+	if cfg.Branch != "" && cfg.Issues.Placement != issue.None {
+		refs, err := cfg.Issues.Extract(cfg.Branch)
+		if err != nil {
+			return GetTypeResponse{}, err
+		}
+
+		// A reference as scope would replace a scope allowed by the rules,
+		// so it is added as a trailer instead.
+		issues := cfg.Issues
+		if issues.Placement == issue.Scope && len(cfg.ConventionalRules.Scopes) > 0 {
+			issues.Placement = issue.Trailer
+		}
+
+		response.Message = issues.Apply(response.Message, refs, cfg.ConventionalRules.Delimiter())
+	}
+
+	if styleName == Gitmoji {
+		response.Message = correctGitmojiMessage(gitmojiPrefix, response.Message, cfg, inference)
+	}
+
+	trailers := cfg.Trailers
+
+	if len(cfg.SquashedMessages) > 0 {
+		refs := issue.Config{Placement: issue.Trailer, TrailerToken: cfg.Issues.TrailerToken}
+		response.Message = refs.Apply(response.Message, squash.References(cfg.SquashedMessages), "")
+
+		trailers = append(squash.Trailers(cfg.SquashedMessages), trailers...)
+	}
+
+	if len(trailers) > 0 {
+		response.Message = trailer.Append(response.Message, trailers...)
+	}
+
+	existing := trailer.Parse(response.Message)
+	for _, suggested := range cfg.SuggestedTrailers {
+		if !containsTrailer(existing, suggested) {
+			response.SuggestedTrailers = append(response.SuggestedTrailers, suggested)
+		}
+	}
+
+	response.Structured = response.Structured.describe(response.Message, cfg.ConventionalCommitCompliant)
+
+	return response, nil
 }
 
-func (o *Client) doChatCompletionRequest(ctx context.Context, messages []openai.Message) (GetTypeResponse, error) {
-	content, err := o.client.ChatCompletionRequest(ctx, messages, openai.GPT3_5Turbo, 0.2)
+func (o *Client) doChatCompletionRequest(ctx context.Context, messages []openai.Message, conventionalCommit bool) (GetTypeResponse, error) {
+	content, err := o.provider.Complete(ctx, provider.Request{
+		Messages:    messages,
+		Schema:      messageJSONSchema,
+		Temperature: 0.2,
+	})
+	if errors.Is(err, provider.ErrUnexpectedResponse) {
+		return GetTypeResponse{}, UnexpectedStateError{err.Error()}
+	}
+
 	if err != nil {
-		return GetTypeResponse{}, fmt.Errorf("could not do ChatCompletionRequest: %w", err)
+		return GetTypeResponse{}, err
 	}
 
-	if len(content.Messages) != 1 {
-		return GetTypeResponse{}, UnexpectedStateError{fmt.Sprintf("unexpected number of messages returned, got %d", len(content.Messages))}
+	providerName := content.Provider
+	if providerName == "" {
+		providerName = o.provider.Name()
 	}
 
-	message := content.Messages[0]
+	// The heuristic is not a model, the confidence of its message is unknown
+	// and left to the caller, see FallbackMessage.
+	heuristic := providerName == Heuristic{}.Name()
+
+	structured, err := parseStructuredMessage(content.Content, conventionalCommit, heuristic)
+	if err != nil {
+		return GetTypeResponse{}, UnexpectedStateError{err.Error()}
+	}
 
-	if strings.Contains(message, "unsure") {
-		return GetTypeResponse{}, UnsureError{message}
+	if structured.Confidence == 0 {
+		return GetTypeResponse{}, UnsureError{structured.Reasoning}
 	}
 
+	signals := confidence.UnknownSignals()
+	signals.Model = structured.Confidence
+	signals.Tokens = confidence.FromLogprobs(content.Logprobs)
+
 	return GetTypeResponse{
-		Message: message,
-		Cost:    content.Cost * 100,
+		Message:    structured.Text(conventionalCommit),
+		Structured: structured,
+		Signals:    signals,
+		Provider:   providerName,
+		Cost:       content.Cost * 100,
 	}, nil
 }
 
-func getExpectedMessage(style Style, conventionalCommitCompliant bool) string {
-	var expectedMessage string
-	switch style {
-	case DescriptiveAndNeutral:
-		expectedMessage = "Add README.md to explain the tool usage\n\n" +
-			"This commit adds a new README.md file that serves as a comprehensive guide for utilizing the recently developed tool. The README.md file contains explicit instructions and essential information regarding the functionality of the tool, as well as the details of its interaction with the OpenAI API. It provides insights into the tool's capabilities, along with specific details on the files and lines that are affected during its operation"
-
-	case ConversationalAndCasual:
-		expectedMessage = "Unleashing a brand new README.md to demystify our OpenAI-powered commit message wizardry!\n\n" +
-			"Hey folks,\n" +
-			"We just slapped a shiny new README.md into the mix! 🎉\n" +
-			"This bad boy's job is to school you all about our super cool, freshly baked tool that spits out commit message suggestions - all powered by the magic of OpenAI (no wizards were harmed in the process, promise! 🧙.\n" +
-			"It's got everything - the ins, the outs, the what-have-yous about our tool. Oh, and it's also gonna give you the lowdown on the stuff we're sending over to OpenAI (don't worry, it's just filenames and changed lines, not your secret cookie recipes! 🍪).\n" +
-			"So strap in, take a gander at the README, and let's get those commit messages singing! 🎵"
-
-	case ListBased:
-		expectedMessage = "Introducing README.md to illuminate tool usage\n\n " +
-			"In this commit:\n\n" +
-			"- A new README.md file has been added\n" +
-			"- Its purpose: to offer detailed instructions and critical notes about our fresh tool that generates commit message suggestions\n" +
-			"- What's covered in the README:\n" +
-			"  - The tool's functionality\n" +
-			"  - The type of data sent to OpenAI, like filenames and lines changed\n"
-
-	case ProblemSolution:
-		expectedMessage = "Addressing the lack of clarity with new README.md\n\n" +
-			"Problem: Users were left in the dark about how to use our new commit message suggestion tool, and there was ambiguity regarding what data was being sent to OpenAI.\n\n" +
-			"Solution: In this commit, we've introduced a README.md file that does the following:\n\n" +
-			"- Provides detailed instructions and important notes about the usage of the tool\n" +
-			"- Sheds light on the tool's functionality\n" +
-			"- Outlines the specific data it sends to OpenAI, such as filenames and lines changed"
-	}
-
-	if conventionalCommitCompliant {
-		expectedMessage = "feat: " + expectedMessage
-	}
-
-	return expectedMessage
+// maxLongAnswerTokens limits the answers that grow with the diff, e.g. the
+// description of a pull request or a split, which may not fit the default
+// limit of a provider.
+const maxLongAnswerTokens = 8192
+
+// completion is the result of a request of a feature, see complete.
+type completion struct {
+	// Provider is the name of the provider that answered.
+	Provider string
+
+	// Cost is the cost of the request in cent, also of the providers that
+	// failed.
+	Cost float64
+}
+
+// complete sends the request to the provider and decodes its JSON answer into
+// answer, named by what in errors, e.g. "the split". The error wraps
+// provider.ErrUnsupported if no provider supports the request, e.g. only the
+// heuristic, so the caller can answer without a model. The cost is returned
+// also on errors.
+func (o *Client) complete(ctx context.Context, request provider.Request, answer interface{}, what string) (completion, error) {
+	content, err := o.provider.Complete(ctx, request)
+
+	result := completion{
+		Provider: content.Provider,
+		Cost:     content.Cost * 100,
+	}
+
+	if result.Provider == "" {
+		result.Provider = o.provider.Name()
+	}
+
+	if errors.Is(err, provider.ErrUnexpectedResponse) {
+		return result, UnexpectedStateError{err.Error()}
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal([]byte(content.Content), answer); err != nil {
+		return result, UnexpectedStateError{fmt.Sprintf("could not decode %s: %s", what, err)}
+	}
+
+	return result, nil
+}
+
+func containsTrailer(trailers []trailer.Trailer, t trailer.Trailer) bool {
+	for _, existing := range trailers {
+		if existing.Equal(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exampleMessages returns the example diffs and the expected answers. With
+// RepoStyle these are the previous commits of the repository, otherwise the
+// examples of the style.
+func exampleMessages(messageStyle style.Style, cfg *MessageConfig, lang language.Language) []openai.Message {
+	examples := messageStyle.Examples
+
+	if messageStyle.Name == string(RepoStyle) {
+		examples = make([]style.Example, 0, len(cfg.Examples))
+		for _, example := range cfg.Examples {
+			examples = append(examples, style.Example{Diff: example.Diff, Message: example.Message})
+		}
+	} else if cfg.ConventionalCommitCompliant {
+		examples = conventionalExamples(examples, lang)
+	}
+
+	if messageStyle.Name == string(Gitmoji) {
+		examples = gitmojiExamples(examples, cfg.Gitmoji.Form)
+	}
+
+	messages := make([]openai.Message, 0, 2*len(examples))
+
+	for _, example := range examples {
+		messages = append(messages,
+			openai.Message{Role: openai.UserRole, Content: example.Diff},
+			openai.Message{Role: openai.AssistantRole, Content: exampleAnswer(example)},
+		)
+	}
+
+	return messages
 }
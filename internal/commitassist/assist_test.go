@@ -0,0 +1,307 @@
+package commitassist_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/issue"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/style"
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+const stagedDiff = `diff --git a/internal/search/search.go b/internal/search/search.go
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/internal/search/search.go
+@@ -0,0 +1,3 @@
++package search
++
++func Users() {}
+`
+
+type fakeProvider struct {
+	content string
+	err     error
+}
+
+func (fakeProvider) Name() string {
+	return "fake"
+}
+
+func (f fakeProvider) Complete(_ context.Context, _ provider.Request) (provider.Response, error) {
+	return provider.Response{Content: f.content}, f.err
+}
+
+func newClient(t *testing.T, content string) *commitassist.Client {
+	t.Helper()
+
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return commitassist.New(fakeProvider{content: content}, styles)
+}
+
+func TestGetCommitMessageStructured(t *testing.T) {
+	testCases := []struct {
+		cfg      commitassist.MessageConfig
+		content  string
+		expected string
+	}{
+		{
+			cfg:      commitassist.MessageConfig{Style: commitassist.DescriptiveAndNeutral},
+			content:  `{"subject":"Add user search","body":"Users can be searched by name.","type":"feat","scope":"","breaking":false,"confidence":0.9,"reasoning":"new package"}`,
+			expected: "Add user search\n\nUsers can be searched by name.",
+		},
+		{
+			cfg:      commitassist.MessageConfig{Style: commitassist.DescriptiveAndNeutral, ConventionalCommitCompliant: true},
+			content:  `{"subject":"add user search","body":"","type":"feat","scope":"search","breaking":false,"confidence":0.9,"reasoning":""}`,
+			expected: "feat(search): add user search",
+		},
+		{
+			cfg:      commitassist.MessageConfig{Style: commitassist.Gitmoji, ConventionalCommitCompliant: true},
+			content:  `{"subject":"✨ add user search","body":"","type":"feat","scope":"","breaking":false,"confidence":0.9,"reasoning":""}`,
+			expected: "✨ feat(search): add user search",
+		},
+		{
+			cfg:      commitassist.MessageConfig{Style: commitassist.DescriptiveAndNeutral, ConventionalCommitCompliant: true, Language: "sv"},
+			content:  `{"subject":"Ändra användarsökning","body":"","type":"feat","scope":"search","breaking":false,"confidence":0.9,"reasoning":""}`,
+			expected: "feat(search): ändra användarsökning",
+		},
+		{
+			cfg:      commitassist.MessageConfig{Style: commitassist.DescriptiveAndNeutral},
+			content:  `{"subject":"Explain what to do when unsure","body":"","type":"docs","scope":"","breaking":false,"confidence":0.8,"reasoning":""}`,
+			expected: "Explain what to do when unsure",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			response, err := newClient(t, tc.content).GetCommitMessage(context.Background(), stagedDiff, &tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Message != tc.expected {
+				t.Errorf("got %q, want %q", response.Message, tc.expected)
+			}
+
+			if response.Structured.Subject+bodySuffix(response.Structured.Body) != tc.expected {
+				t.Errorf("got fields %+v, want them to match the message", response.Structured)
+			}
+		})
+	}
+}
+
+func TestGetCommitMessageIssuesWithGitmoji(t *testing.T) {
+	content := `{"subject":"✨ add user search","body":"","type":"feat","scope":"","breaking":false,"confidence":0.9,"reasoning":""}`
+
+	testCases := []struct {
+		placement    issue.Placement
+		conventional bool
+		expected     string
+	}{
+		{placement: issue.Subject, conventional: true, expected: "✨ feat(search): PROJ-12 add user search"},
+		{placement: issue.Scope, conventional: true, expected: "✨ feat(PROJ-12): add user search"},
+		{placement: issue.Subject, expected: "✨ PROJ-12 add user search"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			cfg := commitassist.MessageConfig{
+				Style:                       commitassist.Gitmoji,
+				ConventionalCommitCompliant: tc.conventional,
+				Branch:                      "feature/PROJ-12-user-search",
+				Issues:                      issue.Config{Placement: tc.placement},
+			}
+
+			response, err := newClient(t, content).GetCommitMessage(context.Background(), stagedDiff, &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Message != tc.expected {
+				t.Errorf("got %q, want %q", response.Message, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGetCommitMessageIssueScopeWithScopeRules(t *testing.T) {
+	content := `{"subject":"add user search","body":"","type":"feat","scope":"search","breaking":false,"confidence":0.9,"reasoning":""}`
+
+	testCases := []struct {
+		scopes   []conventional.Scope
+		expected string
+	}{
+		{expected: "feat(PROJ-12): add user search"},
+		{scopes: []conventional.Scope{{Name: "search"}}, expected: "feat(search): add user search\n\nRefs: PROJ-12"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			cfg := commitassist.MessageConfig{
+				Style:                       commitassist.DescriptiveAndNeutral,
+				ConventionalCommitCompliant: true,
+				ConventionalRules:           conventional.Rules{Scopes: tc.scopes},
+				Branch:                      "feature/PROJ-12-user-search",
+				Issues:                      issue.Config{Placement: issue.Scope},
+			}
+
+			response, err := newClient(t, content).GetCommitMessage(context.Background(), stagedDiff, &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Message != tc.expected {
+				t.Errorf("got %q, want %q", response.Message, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGetCommitMessageUnsure(t *testing.T) {
+	content := `{"subject":"","body":"","type":"","scope":"","breaking":false,"confidence":0,"reasoning":"the diff is empty"}`
+
+	_, err := newClient(t, content).GetCommitMessage(context.Background(), stagedDiff, nil)
+
+	var unsure commitassist.UnsureError
+	if !errors.As(err, &unsure) || unsure.Msg != "the diff is empty" {
+		t.Errorf("got %v, want UnsureError", err)
+	}
+}
+
+func TestGetCommitMessageSquashed(t *testing.T) {
+	content := `{"subject":"Add user search","body":"","type":"feat","scope":"","breaking":false,"confidence":0.9,"reasoning":""}`
+
+	cfg := &commitassist.MessageConfig{
+		Style: commitassist.DescriptiveAndNeutral,
+		SquashedMessages: []string{
+			"Add search (#12)\n\nCo-authored-by: John Doe <john@example.com>\nChange-Id: I1111",
+			"fixup! Add search\n\nRefs: PROJ-1",
+		},
+		Trailers: []trailer.Trailer{{Key: trailer.SignedOffBy, Value: "Jane Doe <jane@example.com>"}},
+	}
+
+	response, err := newClient(t, content).GetCommitMessage(context.Background(), stagedDiff, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Add user search\n\nRefs: #12\nCo-authored-by: John Doe <john@example.com>\nRefs: PROJ-1\n" +
+		"Signed-off-by: Jane Doe <jane@example.com>"
+
+	if response.Message != expected {
+		t.Errorf("got %q, want %q", response.Message, expected)
+	}
+}
+
+func TestGetCommitMessageInvalidStructure(t *testing.T) {
+	for _, content := range []string{
+		`not json`,
+		`{"subject":"","body":"","type":"feat","scope":"","breaking":false,"confidence":0.5,"reasoning":""}`,
+		`{"subject":"Add search","body":"","type":"feat","scope":"","breaking":false,"confidence":2,"reasoning":""}`,
+		// Only the heuristic has an unknown confidence.
+		`{"subject":"Add search","body":"","type":"feat","scope":"","breaking":false,"confidence":-1,"reasoning":""}`,
+	} {
+		content := content // capture range variable
+
+		t.Run(content, func(t *testing.T) {
+			_, err := newClient(t, content).GetCommitMessage(context.Background(), stagedDiff, nil)
+
+			var unexpected commitassist.UnexpectedStateError
+			if !errors.As(err, &unexpected) {
+				t.Errorf("got %v, want UnexpectedStateError", err)
+			}
+		})
+	}
+}
+
+func TestGetCommitMessageBlocked(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = commitassist.New(fakeProvider{err: provider.BlockedError{Provider: "fake", Reason: "SAFETY"}}, styles).GetCommitMessage(context.Background(), stagedDiff, nil)
+
+	var (
+		blocked provider.BlockedError
+		unsure  commitassist.UnsureError
+	)
+
+	if !errors.As(err, &blocked) || errors.As(err, &unsure) {
+		t.Errorf("got %v, want the BlockedError and not UnsureError", err)
+	}
+}
+
+func bodySuffix(body string) string {
+	if body == "" {
+		return ""
+	}
+
+	return "\n\n" + body
+}
+
+func TestGetCommitMessageConfidence(t *testing.T) {
+	content := `{"subject":"Add user search","body":"","type":"feat","scope":"","breaking":false,"confidence":0.4,"reasoning":"unclear intent"}`
+
+	response, err := newClient(t, content).GetCommitMessage(context.Background(), stagedDiff, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without logprobs and self-check the confidence is the one reported by
+	// the model.
+	if response.Confidence != 0.4 || response.Signals.Model != 0.4 {
+		t.Errorf("got confidence %v (%+v), want 0.4", response.Confidence, response.Signals)
+	}
+}
+
+func TestFallbackMessage(t *testing.T) {
+	testCases := []struct {
+		cfg      *commitassist.MessageConfig
+		expected string
+	}{
+		{cfg: nil, expected: "Add search.go\n\n- internal/search/search.go (new): Users"},
+		{
+			cfg:      &commitassist.MessageConfig{Style: commitassist.DescriptiveAndNeutral, ConventionalCommitCompliant: true},
+			expected: "feat(search): add search.go\n\n- internal/search/search.go (new): Users",
+		},
+		{
+			cfg:      &commitassist.MessageConfig{Style: commitassist.Gitmoji},
+			expected: "✨ Add search.go\n\n- internal/search/search.go (new): Users",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			response, err := commitassist.FallbackMessage(stagedDiff, tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Message != tc.expected {
+				t.Errorf("got %q, want %q", response.Message, tc.expected)
+			}
+
+			if response.Confidence >= 0 {
+				t.Errorf("got confidence %v, want unknown", response.Confidence)
+			}
+		})
+	}
+}
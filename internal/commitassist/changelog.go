@@ -0,0 +1,144 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/changelog"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+// releaseNotesSchema is the JSON schema of releaseNotesAnswer.
+const releaseNotesSchema = `{
+  "type": "object",
+  "properties": {
+    "notes": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "text": {"type": "string"}
+        },
+        "required": ["id", "text"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["notes"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var releaseNotesJSONSchema = openai.JSONSchema{
+	Name:   "release_notes",
+	Schema: json.RawMessage(releaseNotesSchema),
+	Strict: true,
+}
+
+type releaseNotesAnswer struct {
+	Notes []struct {
+		ID   int    `json:"id"`
+		Text string `json:"text"`
+	} `json:"notes"`
+}
+
+// ReleaseNotesResponse is the release notes rewritten by the model.
+type ReleaseNotesResponse struct {
+	Release changelog.Release
+
+	// Provider is the name of the provider that rewrote the notes.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// RewriteReleaseNotes rewrites the text of the entries of the release into
+// user-facing release notes, based on the whole commit messages. Entries the
+// model does not answer for keep their text. lang is the BCP 47 tag of the
+// language of the notes, empty is English.
+func (o *Client) RewriteReleaseNotes(ctx context.Context, release changelog.Release, lang string) (ReleaseNotesResponse, error) {
+	parsedLang, err := language.Parse(lang)
+	if err != nil {
+		return ReleaseNotesResponse{}, err
+	}
+
+	languageContent := ""
+	if !parsedLang.IsEnglish() {
+		languageContent = fmt.Sprintf("Write the notes in %s (%s).", parsedLang.Name, parsedLang.Tag)
+	}
+
+	var changes strings.Builder
+
+	for i, entry := range release.Entries() {
+		fmt.Fprintf(&changes, "id: %d\nnote: %s\ncommit message:\n%s\n\n", i, entry.Text, entry.Message)
+	}
+
+	messages := []openai.Message{
+		{
+			Role: openai.SystemRole,
+			Content: fmt.Sprintf(`You write release notes for the users of a
+project. Each change has an id, a draft note and the commit message it is
+based on. Rewrite each draft into a note that tells a user what changed and
+why it matters to them, in one or two sentences. Do not mention internal
+details such as function names unless the users need them, and do not invent
+anything the commit message does not say. Answer with the id and the note of
+each change.
+%s`, languageContent),
+		},
+		{
+			Role:    openai.UserRole,
+			Content: changes.String(),
+		},
+	}
+
+	var answer releaseNotesAnswer
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages:    messages,
+		Schema:      releaseNotesJSONSchema,
+		Temperature: 0.2,
+		MaxTokens:   maxLongAnswerTokens,
+	}, &answer, "the release notes")
+	if err != nil {
+		return ReleaseNotesResponse{}, err
+	}
+
+	notes := map[int]string{}
+	for _, note := range answer.Notes {
+		if text := strings.TrimSpace(note.Text); text != "" {
+			notes[note.ID] = text
+		}
+	}
+
+	rewritten := release
+	rewritten.Sections = make([]changelog.Section, 0, len(release.Sections))
+
+	id := 0
+
+	for _, section := range release.Sections {
+		entries := make([]changelog.Entry, 0, len(section.Entries))
+
+		for _, entry := range section.Entries {
+			if text, ok := notes[id]; ok {
+				entry.Text = text
+			}
+
+			entries = append(entries, entry)
+			id++
+		}
+
+		rewritten.Sections = append(rewritten.Sections, changelog.Section{Title: section.Title, Entries: entries})
+	}
+
+	return ReleaseNotesResponse{
+		Release:  rewritten,
+		Provider: result.Provider,
+		Cost:     result.Cost,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package commitassist_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/changelog"
+)
+
+func TestRewriteReleaseNotes(t *testing.T) {
+	release := changelog.Build("1.3.0", "", []changelog.Commit{
+		{Hash: "a1", Message: "fix: handle empty queries"},
+		{Hash: "a2", Message: "feat(search): add search by email"},
+	})
+
+	content := `{"notes":[{"id":0,"text":"Users can now search by email address."},{"id":7,"text":"ignored"}]}`
+
+	response, err := newClient(t, content).RewriteReleaseNotes(context.Background(), release, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "## [1.3.0]\n\n### Added\n\n- **search:** Users can now search by email address.\n\n### Fixed\n\n- Handle empty queries"
+
+	if got := response.Release.Markdown(); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+
+	if release.Sections[0].Entries[0].Text != "Add search by email" {
+		t.Errorf("the release was modified: %+v", release)
+	}
+}
@@ -0,0 +1,140 @@
+package commitassist
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/philiplinell/commit-msg/internal/apidiff"
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+// conventionalConstraints returns the prompt fragment describing the types
+// and scopes allowed by the rules.
+func conventionalConstraints(rules conventional.Rules) string {
+	constraints := fmt.Sprintf("The type must be one of: %s.", strings.Join(rules.AllowedTypes(), ", "))
+
+	if names := rules.ScopeNames(); names != nil {
+		constraints += fmt.Sprintf(" The scope must be one of: %s, or be omitted.", strings.Join(names, ", "))
+		constraints += fmt.Sprintf(" Several scopes are separated by %q.", rules.Delimiter())
+	}
+
+	return constraints
+}
+
+// conventionalHint returns the prompt fragment describing the type and scope
+// inferred from the diff.
+func conventionalHint(inference conventional.Inference) string {
+	hint := fmt.Sprintf("Based on the changed files the commit type is likely %q (%s)", inference.Type, inference.Reason)
+
+	if inference.Scope != "" {
+		hint += fmt.Sprintf(" and the scope is likely %q", inference.Scope)
+	}
+
+	return hint + ". Prefer these unless the diff clearly suggests otherwise."
+}
+
+// correctConventionalMessage makes sure the first line of message is a
+// conventional commit header following the rules, using the inferred type
+// and scope when the model did not provide valid ones. Detected API changes
+// are marked with "!" and a "BREAKING CHANGE:" footer, unless the model
+// already added one.
+func correctConventionalMessage(message string, rules conventional.Rules, inference conventional.Inference, apiChanges apidiff.Report, lang language.Language) (string, error) {
+	subject, rest, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n")
+
+	commit, err := conventional.ParseHeader(subject)
+	if err != nil {
+		commit = conventional.Commit{
+			Type:        inference.Type,
+			Scope:       inference.Scope,
+			Description: strings.TrimSpace(subject),
+		}
+	}
+
+	commit.Description = lowerSubject(commit.Description, lang)
+
+	commit, err = rules.Repair(commit, inference)
+	if err != nil {
+		return "", fmt.Errorf("could not repair %q: %w", subject, err)
+	}
+
+	if apiChanges.Breaking() {
+		commit.Breaking = true
+
+		if !strings.Contains(rest, conventional.BreakingChangeToken) {
+			if rest = strings.TrimRight(rest, "\n"); rest == "" {
+				rest = "\n" + apiChanges.Footer()
+			} else {
+				rest += "\n\n" + apiChanges.Footer()
+			}
+		}
+	}
+
+	if rest == "" {
+		return commit.Header(), nil
+	}
+
+	return commit.Header() + "\n" + rest, nil
+}
+
+// conventionalExamples prefixes the example messages with the type inferred
+// from the example diff, unless the style already wrote them as conventional
+// commits. A leading gitmoji is kept in front of the type.
+func conventionalExamples(examples []style.Example, lang language.Language) []style.Example {
+	converted := make([]style.Example, 0, len(examples))
+
+	for _, example := range examples {
+		prefix, message := gitmoji.Cut(example.Message)
+
+		subject, _, _ := strings.Cut(message, "\n")
+		if _, err := conventional.ParseHeader(subject); err != nil {
+			files, _ := diff.Parse(example.Diff)
+			exampleLang := lang
+			if example.Language == "" {
+				exampleLang, _ = language.Parse(language.English)
+			}
+
+			example.Message = conventional.Infer(files).Type + ": " + lowerSubject(message, exampleLang)
+
+			if prefix != "" {
+				example.Message = prefix + " " + example.Message
+			}
+		}
+
+		converted = append(converted, example)
+	}
+
+	return converted
+}
+
+// lowerSubject lowercases the first letter of the subject s, unless the
+// language capitalizes nouns, where the first word might be a noun.
+func lowerSubject(s string, lang language.Language) string {
+	if lang.CapitalizesNouns {
+		return s
+	}
+
+	return lowerFirst(s)
+}
+
+// lowerFirst lowercases the first letter of s unless the first word looks
+// like an acronym or identifier (e.g. "README" or "OpenAI").
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	first, size := utf8.DecodeRuneInString(s)
+
+	firstWord, _, _ := strings.Cut(s[size:], " ")
+	if strings.ToLower(firstWord) != firstWord {
+		return s
+	}
+
+	return string(unicode.ToLower(first)) + s[size:]
+}
@@ -0,0 +1,204 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/explain"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+// maxExplainDiffTokens is the maximum number of tokens of the diff to
+// explain, longer diffs are truncated.
+const maxExplainDiffTokens = 50000
+
+// explainSchema is the JSON schema of explainAnswer.
+const explainSchema = `{
+  "type": "object",
+  "properties": {
+    "summary": {"type": "string", "description": "What the diff does as a whole, in a few sentences."},
+    "files": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "path": {"type": "string"},
+          "change": {"type": "string", "description": "What changed in the file."},
+          "impact": {"type": "string", "description": "Why the change might matter, e.g. to users or other code."},
+          "risks": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "category": {"type": "string", "enum": ["concurrency", "error-handling", "security", "other"]},
+                "description": {"type": "string"},
+                "line": {"type": "integer", "description": "The line in the new file, 0 for the whole file."}
+              },
+              "required": ["category", "description", "line"],
+              "additionalProperties": false
+            }
+          }
+        },
+        "required": ["path", "change", "impact", "risks"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["summary", "files"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var explainJSONSchema = openai.JSONSchema{
+	Name:   "diff_explanation",
+	Schema: json.RawMessage(explainSchema),
+	Strict: true,
+}
+
+// ExplainResponse is the explanation of a diff.
+type ExplainResponse struct {
+	Explanation explain.Explanation
+
+	// Provider is the name of the provider that explained the diff.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// ExplainDiff explains the diff to a reviewer, file by file, with the risks
+// to look at. The risks found by explain.Callouts are given to the model as
+// hints, and added if the model left out their category. If the provider
+// does not support it, e.g. the Heuristic provider, the explanation is
+// derived from the changed files and the callouts.
+func (o *Client) ExplainDiff(ctx context.Context, gitDiff string, lang string) (ExplainResponse, error) {
+	l, err := language.Parse(lang)
+	if err != nil {
+		return ExplainResponse{}, err
+	}
+
+	files, _ := diff.Parse(gitDiff)
+	if len(files) == 0 {
+		return ExplainResponse{}, UnsureError{"there are no changes to explain"}
+	}
+
+	var hints strings.Builder
+
+	for _, file := range files {
+		for _, risk := range explain.Callouts(file) {
+			fmt.Fprintf(&hints, "- %s:%d %s: %s\n", file.Path(), risk.Line, risk.Category, risk.Description)
+		}
+	}
+
+	hintContent := ""
+	if hints.Len() > 0 {
+		hintContent = "These possible risks were found by simple rules, confirm or dismiss them:\n" + hints.String()
+	}
+
+	var answer explain.Explanation
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages: []openai.Message{
+			{
+				Role: openai.SystemRole,
+				Content: fmt.Sprintf(`You explain a diff to a code reviewer. Summarize what the diff
+does, and for each changed file explain what changed and why it might matter.
+Call out the risks a reviewer should look at, in particular concurrency (races,
+deadlocks, leaked goroutines), error handling (ignored or swallowed errors,
+panics) and security-sensitive code (authentication, secrets, injection,
+cryptography), with the line in the new file when it applies. Do not invent
+risks, a file without risks is fine. Write in %s.
+%s`, l.Name, languageInstructions(l, nil)),
+			},
+			{
+				Role:    openai.UserRole,
+				Content: hintContent + tokens.Truncate(gitDiff, maxExplainDiffTokens),
+			},
+		},
+		Schema:      explainJSONSchema,
+		Temperature: 0.2,
+		MaxTokens:   maxLongAnswerTokens,
+	}, &answer, "the explanation")
+	if errors.Is(err, provider.ErrUnsupported) {
+		response := heuristicExplanation(files)
+		response.Cost = result.Cost
+
+		return response, nil
+	}
+
+	if err != nil {
+		return ExplainResponse{}, err
+	}
+
+	explained := map[string]explain.File{}
+	for _, file := range answer.Files {
+		explained[file.Path] = file
+	}
+
+	response := ExplainResponse{
+		Explanation: explain.Explanation{Summary: strings.TrimSpace(answer.Summary), Files: []explain.File{}},
+		Provider:    result.Provider,
+		Cost:        result.Cost,
+	}
+
+	for _, file := range files {
+		explanation := explained[file.Path()]
+		explanation.Path = file.Path()
+		explanation.Change = strings.TrimSpace(explanation.Change)
+		explanation.Impact = strings.TrimSpace(explanation.Impact)
+
+		risks := []explain.Risk{}
+		categories := map[explain.Category]bool{}
+
+		for _, risk := range explanation.Risks {
+			risk.Category = explain.ParseCategory(string(risk.Category))
+			if risk.Line < 0 {
+				risk.Line = 0
+			}
+
+			categories[risk.Category] = true
+			risks = append(risks, risk)
+		}
+
+		for _, risk := range explain.Callouts(file) {
+			if !categories[risk.Category] {
+				risks = append(risks, risk)
+			}
+		}
+
+		explanation.Risks = risks
+		response.Explanation.Files = append(response.Explanation.Files, explanation)
+	}
+
+	return response, nil
+}
+
+func heuristicExplanation(files []diff.File) ExplainResponse {
+	response := ExplainResponse{
+		Explanation: explain.Explanation{Summary: heuristicSubject(files), Files: []explain.File{}},
+		Provider:    Heuristic{}.Name(),
+	}
+
+	for _, file := range files {
+		change := heuristicSubject([]diff.File{file})
+
+		if names := functions([]diff.File{file}); len(names) > 0 {
+			change += ": " + strings.Join(names, ", ")
+		}
+
+		response.Explanation.Files = append(response.Explanation.Files, explain.File{
+			Path:   file.Path(),
+			Change: change,
+			Risks:  explain.Callouts(file),
+		})
+	}
+
+	return response
+}
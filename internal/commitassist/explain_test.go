@@ -0,0 +1,71 @@
+package commitassist_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/explain"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+const workerDiff = `diff --git a/worker.go b/worker.go
+index 1111111..2222222 100644
+--- a/worker.go
++++ b/worker.go
+@@ -5,2 +5,4 @@ func Run() {
+ 	start()
++	go func() { work() }()
++	_ = stop()
+ }
+`
+
+func TestExplainDiff(t *testing.T) {
+	content := `{"summary":"Runs the work in the background.","files":[
+		{"path":"worker.go","change":"Starts the work in a goroutine.","impact":"Run returns early.",
+		 "risks":[{"category":"concurrency","description":"the goroutine is never stopped","line":6}]},
+		{"path":"unknown.go","change":"ignored","impact":"","risks":[]}
+	]}`
+
+	response, err := newClient(t, content).ExplainDiff(context.Background(), workerDiff, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := explain.Explanation{
+		Summary: "Runs the work in the background.",
+		Files: []explain.File{
+			{
+				Path:   "worker.go",
+				Change: "Starts the work in a goroutine.",
+				Impact: "Run returns early.",
+				Risks: []explain.Risk{
+					{Category: explain.Concurrency, Description: "the goroutine is never stopped", Line: 6},
+					{Category: explain.ErrorHandling, Description: "ignores, swallows or panics on an error", Line: 7},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(response.Explanation, expected) {
+		t.Errorf("got %+v, want %+v", response.Explanation, expected)
+	}
+}
+
+func TestExplainDiffHeuristic(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := commitassist.New(commitassist.Heuristic{}, styles).ExplainDiff(context.Background(), workerDiff, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := response.Explanation.Files
+	if len(files) != 1 || files[0].Change != "Update worker.go: Run" || len(files[0].Risks) != 2 {
+		t.Errorf("got %+v", files)
+	}
+}
@@ -0,0 +1,55 @@
+package commitassist
+
+import (
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+// gitmojiHint returns the prompt fragment listing the gitmojis and the one
+// matching the inferred type.
+func gitmojiHint(cfg gitmoji.Config, inference conventional.Inference, breaking bool) string {
+	suggested := gitmoji.ForType(inference.Type, breaking)
+
+	return gitmoji.Prompt(cfg.Form) +
+		"Based on the changed files the gitmoji is likely " + suggested.Format(cfg.Form) +
+		" (" + suggested.Description + "). Prefer it unless the diff clearly suggests otherwise."
+}
+
+// correctGitmojiMessage prefixes message (without a gitmoji) with the gitmoji
+// the model used, written in the configured form. A missing gitmoji or one
+// that is not in the list is replaced by the gitmoji of the commit type, and
+// detected API changes always use ":boom:".
+func correctGitmojiMessage(prefix, message string, cfg *MessageConfig, inference conventional.Inference) string {
+	breaking := cfg.APIChanges.Breaking()
+	commitType := inference.Type
+
+	if cfg.ConventionalCommitCompliant {
+		if commit, err := conventional.Parse(message); err == nil {
+			commitType = commit.Type
+			breaking = breaking || commit.BreakingChange() != ""
+		}
+	}
+
+	g, err := gitmoji.Validate(prefix)
+	if err != nil || breaking {
+		g = gitmoji.ForType(commitType, breaking)
+	}
+
+	return gitmoji.Prefix(message, g, cfg.Gitmoji.Form)
+}
+
+// gitmojiExamples writes the gitmojis of the examples in the configured form.
+func gitmojiExamples(examples []style.Example, form gitmoji.Form) []style.Example {
+	converted := make([]style.Example, 0, len(examples))
+
+	for _, example := range examples {
+		if g, err := gitmoji.Validate(example.Message); err == nil {
+			example.Message = gitmoji.Prefix(example.Message, g, form)
+		}
+
+		converted = append(converted, example)
+	}
+
+	return converted
+}
@@ -0,0 +1,281 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+const (
+	// maxHeuristicFiles is the maximum number of files listed in the body
+	// of a heuristic message.
+	maxHeuristicFiles = 20
+
+	// maxHeuristicFunctions is the maximum number of functions listed per
+	// file.
+	maxHeuristicFunctions = 5
+)
+
+// Heuristic is a provider deriving the message from the diff, without a
+// model. The subject is based on the paths of the changed files, e.g. "Update
+// README.md", "Add openai client tests" or "Rename a.go to b.go", and the body
+// lists the changed files and functions. It is deterministic, and answers
+// only the requests of GetCommitMessage.
+type Heuristic struct{}
+
+// Name returns "heuristic".
+func (Heuristic) Name() string {
+	return "heuristic"
+}
+
+// Complete answers with the message derived from the diff, the last message
+// of the request.
+func (Heuristic) Complete(_ context.Context, request provider.Request) (provider.Response, error) {
+	if request.Schema.Name != messageJSONSchema.Name || len(request.Messages) == 0 {
+		return provider.Response{}, fmt.Errorf("%w: %q", provider.ErrUnsupported, request.Schema.Name)
+	}
+
+	last := request.Messages[len(request.Messages)-1]
+	if last.Role != openai.UserRole {
+		return provider.Response{}, fmt.Errorf("%w: the last message is not the diff", provider.ErrUnsupported)
+	}
+
+	files, _ := diff.Parse(last.Content)
+
+	// A StructuredMessage can always be encoded.
+	content, _ := json.Marshal(heuristicMessage(files))
+
+	return provider.Response{Content: string(content)}, nil
+}
+
+// FallbackMessage returns the message of the Heuristic provider, e.g. when
+// the model is not confident enough. It is corrected and gets trailers like a
+// suggestion from GetCommitMessage, but is always in English. The confidence
+// of the message is unknown.
+func FallbackMessage(gitDiff string, cfg *MessageConfig) (GetTypeResponse, error) {
+	if cfg == nil {
+		cfg = &MessageConfig{
+			Style: DescriptiveAndNeutral,
+		}
+	}
+
+	files, _ := diff.Parse(gitDiff)
+	lang, _ := language.Parse(language.English)
+	structured := heuristicMessage(files)
+
+	response := GetTypeResponse{
+		Message:    structured.Text(cfg.ConventionalCommitCompliant),
+		Structured: structured,
+		Confidence: confidence.Unknown,
+		Signals:    confidence.UnknownSignals(),
+		Provider:   Heuristic{}.Name(),
+	}
+
+	return finishMessage(response, cfg, cfg.Style, cfg.ConventionalRules.Infer(files), lang)
+}
+
+func heuristicMessage(files []diff.File) StructuredMessage {
+	inference := conventional.Infer(files)
+
+	return StructuredMessage{
+		Subject:    heuristicSubject(files),
+		Body:       heuristicBody(files),
+		Type:       inference.Type,
+		Scope:      inference.Scope,
+		Confidence: confidence.Unknown,
+		Reasoning:  "Derived from the changed files without a model.",
+	}
+}
+
+func heuristicSubject(files []diff.File) string {
+	switch len(files) {
+	case 0:
+		return "Update files"
+	case 1:
+		file := files[0]
+
+		switch {
+		case file.IsRename && path.Dir(file.OldPath) == path.Dir(file.NewPath):
+			return fmt.Sprintf("Rename %s to %s", path.Base(file.OldPath), path.Base(file.NewPath))
+		case file.IsRename:
+			return fmt.Sprintf("Move %s to %s", file.OldPath, file.NewPath)
+		default:
+			return verb(files) + " " + label(file.Path())
+		}
+	}
+
+	dir := commonDir(files)
+
+	if all(files, func(f diff.File) bool { return isTest(f.Path()) && path.Dir(f.Path()) == dir }) {
+		return verb(files) + " " + testLabel(dir, "")
+	}
+
+	subject := fmt.Sprintf("%s %d files", verb(files), len(files))
+
+	if dir != "." {
+		subject += " in " + dir
+	}
+
+	return subject
+}
+
+func heuristicBody(files []diff.File) string {
+	if len(files) < 2 && len(functions(files)) == 0 {
+		return ""
+	}
+
+	lines := []string{}
+
+	for i, file := range files {
+		if i == maxHeuristicFiles {
+			lines = append(lines, fmt.Sprintf("- and %d more", len(files)-maxHeuristicFiles))
+			break
+		}
+
+		line := "- " + file.Path()
+
+		switch {
+		case file.IsNew:
+			line += " (new)"
+		case file.IsDeleted:
+			line += " (deleted)"
+		case file.IsRename:
+			line += " (renamed from " + file.OldPath + ")"
+		}
+
+		if names := functions([]diff.File{file}); len(names) > 0 {
+			line += ": " + strings.Join(names, ", ")
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// verb returns "Add" if all files are new, "Remove" if all are deleted and
+// "Update" otherwise.
+func verb(files []diff.File) string {
+	switch {
+	case all(files, func(f diff.File) bool { return f.IsNew }):
+		return "Add"
+	case all(files, func(f diff.File) bool { return f.IsDeleted }):
+		return "Remove"
+	default:
+		return "Update"
+	}
+}
+
+// label returns how a file is named in the subject, e.g. "README.md" or
+// "openai client tests" for internal/openai/client_test.go.
+func label(p string) string {
+	if !isTest(p) {
+		return path.Base(p)
+	}
+
+	name := path.Base(p)
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	for _, affix := range []string{"_test", ".test", ".spec", "_spec"} {
+		name = strings.TrimSuffix(name, affix)
+	}
+
+	name = strings.TrimPrefix(name, "test_")
+
+	return testLabel(path.Dir(p), name)
+}
+
+func testLabel(dir, name string) string {
+	words := []string{}
+
+	if base := path.Base(dir); base != "." && base != "/" && base != "tests" && base != "test" {
+		words = append(words, base)
+	}
+
+	if name != "" && (len(words) == 0 || words[0] != name) {
+		words = append(words, name)
+	}
+
+	return strings.Join(append(words, "tests"), " ")
+}
+
+func isTest(p string) bool {
+	name := path.Base(p)
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	return strings.HasSuffix(name, "_test") || strings.HasSuffix(name, ".test") ||
+		strings.HasSuffix(name, ".spec") || strings.HasSuffix(name, "_spec") ||
+		strings.HasPrefix(name, "test_")
+}
+
+//nolint:gochecknoglobals
+var functionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?(\w+)`),
+	regexp.MustCompile(`^\s*(?:async\s+)?(?:def|function|class)\s+(\w+)`),
+}
+
+// functions returns the names of the functions changed in the files, from
+// the hunk headers and the added and removed lines.
+func functions(files []diff.File) []string {
+	names := []string{}
+	seen := map[string]bool{}
+
+	add := func(s string) {
+		for _, pattern := range functionPatterns {
+			if match := pattern.FindStringSubmatch(s); match != nil && !seen[match[1]] && len(names) < maxHeuristicFunctions {
+				seen[match[1]] = true
+				names = append(names, match[1])
+
+				return
+			}
+		}
+	}
+
+	for _, file := range files {
+		for _, hunk := range file.Hunks {
+			for _, line := range hunk.Lines {
+				if line.Kind != diff.Context {
+					add(line.Content)
+				}
+			}
+
+			add(hunk.Section)
+		}
+	}
+
+	return names
+}
+
+func all(files []diff.File, f func(diff.File) bool) bool {
+	for _, file := range files {
+		if !f(file) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// commonDir returns the deepest directory containing all files, "." if
+// there is none.
+func commonDir(files []diff.File) string {
+	dir := path.Dir(files[0].Path())
+
+	for _, file := range files[1:] {
+		for dir != "." && !strings.HasPrefix(file.Path(), dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+
+	return dir
+}
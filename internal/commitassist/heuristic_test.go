@@ -0,0 +1,202 @@
+package commitassist_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+func TestHeuristic(t *testing.T) {
+	testCases := []struct {
+		diff     string
+		expected string
+	}{
+		{
+			diff: `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-# Commit
++# Commit Message
+`,
+			expected: "Update README.md",
+		},
+		{
+			diff: `diff --git a/internal/openai/client_test.go b/internal/openai/client_test.go
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/internal/openai/client_test.go
+@@ -0,0 +1,3 @@
++package openai_test
++
++func TestClient(t *testing.T) {}
+`,
+			expected: "Add openai client tests\n\n- internal/openai/client_test.go (new): TestClient",
+		},
+		{
+			diff: `diff --git a/internal/git/log.go b/internal/git/history.go
+similarity index 100%
+rename from internal/git/log.go
+rename to internal/git/history.go
+`,
+			expected: "Rename log.go to history.go",
+		},
+		{
+			diff: `diff --git a/internal/git/git.go b/internal/git/git.go
+index 1111111..2222222 100644
+--- a/internal/git/git.go
++++ b/internal/git/git.go
+@@ -10,3 +10,3 @@ func (r *Repo) Log(ctx context.Context) error {
+-	return nil
++	return r.run(ctx)
+ }
+diff --git a/internal/git/show.go b/internal/git/show.go
+deleted file mode 100644
+index 1111111..0000000
+--- a/internal/git/show.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package git
+`,
+			expected: "Update 2 files in internal/git\n\n- internal/git/git.go: Log\n- internal/git/show.go (deleted)",
+		},
+	}
+
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := commitassist.New(commitassist.Heuristic{}, styles)
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			response, err := client.GetCommitMessage(context.Background(), tc.diff, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Message != tc.expected {
+				t.Errorf("got %q, want %q", response.Message, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHeuristicConfidenceIsUnknown(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &commitassist.MessageConfig{Style: commitassist.DescriptiveAndNeutral, SelfCheck: true}
+
+	response, err := commitassist.New(commitassist.Heuristic{}, styles).GetCommitMessage(context.Background(), stagedDiff, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Signals != confidence.UnknownSignals() || response.Confidence != confidence.Unknown {
+		t.Errorf("got confidence %v (%+v), want unknown", response.Confidence, response.Signals)
+	}
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Name() string {
+	return "failing"
+}
+
+func (failingProvider) Complete(_ context.Context, _ provider.Request) (provider.Response, error) {
+	return provider.Response{Cost: 0.01}, errors.New("service unavailable")
+}
+
+func TestHeuristicInChain(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := provider.NewChain(provider.ChainConfig{}, failingProvider{}, commitassist.Heuristic{})
+
+	response, err := commitassist.New(chain, styles).GetCommitMessage(context.Background(), stagedDiff, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Provider != "heuristic" {
+		t.Errorf("got provider %q, want %q", response.Provider, "heuristic")
+	}
+
+	if response.Cost != 1 {
+		t.Errorf("got cost %v cent, want the cost of the failed request", response.Cost)
+	}
+}
+
+func TestFallbackInChainKeepsCost(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := commitassist.New(provider.NewChain(provider.ChainConfig{}, failingProvider{}, commitassist.Heuristic{}), styles)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		complete func() (string, float64, error)
+	}{
+		{
+			name: "explain",
+			complete: func() (string, float64, error) {
+				response, err := client.ExplainDiff(ctx, workerDiff, "")
+				return response.Provider, response.Cost, err
+			},
+		},
+		{
+			name: "review",
+			complete: func() (string, float64, error) {
+				response, err := client.ReviewDiff(ctx, debugDiff, "")
+				return response.Provider, response.Cost, err
+			},
+		},
+		{
+			name: "split",
+			complete: func() (string, float64, error) {
+				response, err := client.SuggestSplit(ctx, unfocusedDiff, nil)
+				return response.Provider, response.Cost, err
+			},
+		},
+		{
+			name: "rebase",
+			complete: func() (string, float64, error) {
+				response, err := client.AnalyzeCommits(ctx, []commitassist.CommitDiff{{Message: "wip", Diff: workerDiff}}, "")
+				return response.Provider, response.Cost, err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			name, cost, err := tc.complete()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if name != "heuristic" || cost != 1 {
+				t.Errorf("got %q with cost %v cent, want the heuristic with the cost of the failed request", name, cost)
+			}
+		})
+	}
+}
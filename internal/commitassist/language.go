@@ -0,0 +1,30 @@
+package commitassist
+
+import (
+	"fmt"
+
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+// languageInstructions returns the prompt fragment asking for a message in
+// the language, keeping the conventional commit keywords and trailers in
+// English. Nothing is added for English.
+func languageInstructions(lang language.Language, examples []style.Example) string {
+	if lang.IsEnglish() {
+		return ""
+	}
+
+	instructions := fmt.Sprintf("Write the commit subject and body in %s (%s). "+
+		"Keep conventional commit types and scopes, the BREAKING CHANGE footer and trailer keys such as Signed-off-by in English.",
+		lang.Name, lang.Tag)
+
+	for _, example := range examples {
+		if example.Language != lang.Base {
+			instructions += fmt.Sprintf(" Some examples are in English, but your message must be in %s.", lang.Name)
+			break
+		}
+	}
+
+	return instructions
+}
@@ -0,0 +1,211 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/pullrequest"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+// maxPullRequestDiffTokens is the maximum number of tokens of the diff of a
+// pull request, longer diffs are truncated.
+const maxPullRequestDiffTokens = 50000
+
+// pullRequestSchema is the JSON schema of pullRequestAnswer.
+const pullRequestSchema = `{
+  "type": "object",
+  "properties": {
+    "title": {
+      "type": "string",
+      "description": "The title of the pull request."
+    },
+    "summary": {
+      "type": "string",
+      "description": "What the pull request does and why, in a few sentences."
+    },
+    "changes": {
+      "type": "array",
+      "items": {"type": "string"},
+      "description": "The notable changes, one per item."
+    },
+    "testing": {
+      "type": "string",
+      "description": "How the changes were or can be tested."
+    },
+    "risk": {
+      "type": "string",
+      "description": "What could go wrong, e.g. when deploying the changes."
+    },
+    "sections": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "heading": {"type": "string"},
+          "content": {"type": "string"}
+        },
+        "required": ["heading", "content"],
+        "additionalProperties": false
+      },
+      "description": "The filled in sections of the template, empty without a template."
+    }
+  },
+  "required": ["title", "summary", "changes", "testing", "risk", "sections"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var pullRequestJSONSchema = openai.JSONSchema{
+	Name:   "pull_request",
+	Schema: json.RawMessage(pullRequestSchema),
+	Strict: true,
+}
+
+type pullRequestAnswer struct {
+	Title    string                `json:"title"`
+	Summary  string                `json:"summary"`
+	Changes  []string              `json:"changes"`
+	Testing  string                `json:"testing"`
+	Risk     string                `json:"risk"`
+	Sections []pullrequest.Section `json:"sections"`
+}
+
+// PullRequestConfig configures GetPullRequest.
+type PullRequestConfig struct {
+	// Messages are the messages of the commits of the pull request, most
+	// recent first.
+	Messages []string
+
+	// Template is the pull request template of the repository, see package
+	// pullrequest. Its sections are filled in instead of the default ones.
+	Template string
+
+	// Language is the BCP 47 tag of the language of the pull request, empty
+	// is English.
+	Language string
+}
+
+// PullRequestResponse is a suggested pull request.
+type PullRequestResponse struct {
+	Title string
+
+	// Body is the Markdown description.
+	Body string
+
+	// Provider is the name of the provider that suggested the pull request.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// GetPullRequest returns a title and description of a pull request based on
+// the combined diff and the commit messages.
+func (o *Client) GetPullRequest(ctx context.Context, gitDiff string, cfg *PullRequestConfig) (PullRequestResponse, error) {
+	if cfg == nil {
+		cfg = &PullRequestConfig{}
+	}
+
+	lang, err := language.Parse(cfg.Language)
+	if err != nil {
+		return PullRequestResponse{}, err
+	}
+
+	template := pullrequest.ParseTemplate(cfg.Template)
+
+	templateContent := ""
+	if len(template.Sections) > 0 {
+		templateContent = fmt.Sprintf(`The repository has this pull request template:
+
+%s
+
+Fill in the sections of the template, using the headings %q. Leave the content
+of a section empty if it cannot be answered from the changes, e.g. a checklist.`, cfg.Template, template.Headings())
+	}
+
+	languageContent := ""
+	if !lang.IsEnglish() {
+		languageContent = fmt.Sprintf("Write the pull request in %s (%s).", lang.Name, lang.Tag)
+	}
+
+	messages := []openai.Message{
+		{
+			Role: openai.SystemRole,
+			Content: fmt.Sprintf(`You are an insightful assistant that writes pull
+request descriptions. Given the commit messages and the combined diff of a
+branch, write a title and a description that help a reviewer understand what
+the pull request does, why, and what to look out for.
+
+The title should be brief (72 characters or less) and use the %s.
+
+The description consists of a summary, the notable changes, how the changes
+were or can be tested, and the risk of the changes. Use Markdown, and do not
+invent anything the commits and the diff do not show.
+%s
+%s`, lang.Mood, templateContent, languageContent),
+		},
+		{
+			Role:    openai.UserRole,
+			Content: pullRequestPrompt(cfg.Messages, gitDiff),
+		},
+	}
+
+	var answer pullRequestAnswer
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages:    messages,
+		Schema:      pullRequestJSONSchema,
+		Temperature: 0.2,
+		MaxTokens:   maxLongAnswerTokens,
+	}, &answer, "the pull request")
+	if err != nil {
+		return PullRequestResponse{}, err
+	}
+
+	answer.Title = strings.TrimSpace(answer.Title)
+	if answer.Title == "" || strings.Contains(answer.Title, "\n") {
+		return PullRequestResponse{}, UnexpectedStateError{fmt.Sprintf("invalid title %q", answer.Title)}
+	}
+
+	body := pullrequest.Description{
+		Title:   answer.Title,
+		Summary: answer.Summary,
+		Changes: answer.Changes,
+		Testing: answer.Testing,
+		Risk:    answer.Risk,
+	}.Markdown()
+
+	if len(template.Sections) > 0 {
+		body = template.Fill(answer.Sections)
+	}
+
+	return PullRequestResponse{
+		Title:    answer.Title,
+		Body:     body,
+		Provider: result.Provider,
+		Cost:     result.Cost,
+	}, nil
+}
+
+// pullRequestPrompt returns the commit messages, oldest first, and the diff,
+// truncated to maxPullRequestDiffTokens.
+func pullRequestPrompt(commitMessages []string, gitDiff string) string {
+	var b strings.Builder
+
+	b.WriteString("Commit messages:\n")
+
+	for i := len(commitMessages) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "\n---\n%s\n", strings.TrimSpace(commitMessages[i]))
+	}
+
+	b.WriteString("\nDiff:\n")
+	b.WriteString(tokens.Truncate(gitDiff, maxPullRequestDiffTokens))
+
+	return b.String()
+}
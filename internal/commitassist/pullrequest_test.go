@@ -0,0 +1,68 @@
+package commitassist_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+func TestGetPullRequest(t *testing.T) {
+	content := `{"title":"Add user search","summary":"Users can be searched by name.","changes":["Add the search package"],"testing":"Unit tests.","risk":"Low.","sections":[{"heading":"Description","content":"Users can be searched by name."}]}`
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "without template",
+			expected: "## Summary\n\nUsers can be searched by name.\n\n## Changes\n\n- Add the search package\n\n## Testing\n\nUnit tests.\n\n## Risk\n\nLow.",
+		},
+		{
+			name:     "with template",
+			template: "## Description\n\n<!-- What and why? -->\n\n## Checklist\n\n- [ ] Tests",
+			expected: "## Description\n\nUsers can be searched by name.\n\n## Checklist\n\n- [ ] Tests",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &commitassist.PullRequestConfig{
+				Messages: []string{"Add search package"},
+				Template: tc.template,
+			}
+
+			response, err := newClient(t, content).GetPullRequest(context.Background(), stagedDiff, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Title != "Add user search" || response.Provider != "fake" {
+				t.Errorf("got title %q from %q", response.Title, response.Provider)
+			}
+
+			if response.Body != tc.expected {
+				t.Errorf("got body %q, want %q", response.Body, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGetPullRequestUnsupported(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = commitassist.New(commitassist.Heuristic{}, styles).GetPullRequest(context.Background(), stagedDiff, nil)
+
+	var unexpected commitassist.UnexpectedStateError
+	if err == nil || errors.As(err, &unexpected) {
+		t.Errorf("got %v, want the error of the provider", err)
+	}
+}
@@ -0,0 +1,223 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+// maxAnalyzeDiffTokens is the maximum number of tokens of the diff of each
+// commit in the prompt of AnalyzeCommits, longer diffs are truncated.
+const maxAnalyzeDiffTokens = 2000
+
+// analyzeSchema is the JSON schema of analyzeAnswer.
+const analyzeSchema = `{
+  "type": "object",
+  "properties": {
+    "commits": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "summary": {"type": "string", "description": "What the diff changes, in one sentence."},
+          "mismatch": {"type": "string", "description": "How the message does not match the diff, empty if it does."},
+          "group": {"type": "string", "enum": ["none", "fixup", "squash"], "description": "fixup if the commit only corrects an earlier commit, squash if it continues it."},
+          "target": {"type": "integer", "description": "The id of the earlier commit of the group, -1 if none."}
+        },
+        "required": ["id", "summary", "mismatch", "group", "target"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["commits"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var analyzeJSONSchema = openai.JSONSchema{
+	Name:   "commit_analysis",
+	Schema: json.RawMessage(analyzeSchema),
+	Strict: true,
+}
+
+type analyzeAnswer struct {
+	Commits []struct {
+		ID       int    `json:"id"`
+		Summary  string `json:"summary"`
+		Mismatch string `json:"mismatch"`
+		Group    string `json:"group"`
+		Target   int    `json:"target"`
+	} `json:"commits"`
+}
+
+// Grouping is how a commit is suggested to be combined with an earlier one.
+type Grouping string
+
+const (
+	// NoGrouping keeps the commit.
+	NoGrouping Grouping = "none"
+
+	// FixupGrouping folds the commit into the target, keeping the message of
+	// the target, e.g. for a commit correcting a typo of the target.
+	FixupGrouping Grouping = "fixup"
+
+	// SquashGrouping combines the commit with the target, combining the
+	// messages, e.g. for a commit continuing the work of the target.
+	SquashGrouping Grouping = "squash"
+)
+
+// CommitDiff is a commit to analyze.
+type CommitDiff struct {
+	Message string
+	Diff    string
+}
+
+// CommitAnalysis is what the model found about a commit.
+type CommitAnalysis struct {
+	// Summary describes what the diff changes.
+	Summary string
+
+	// Mismatch explains how the message does not match the diff, empty if
+	// it does.
+	Mismatch string
+
+	// Grouping is NoGrouping, or how to combine the commit with Target.
+	Grouping Grouping
+
+	// Target is the index of an earlier commit, -1 if Grouping is
+	// NoGrouping. It is never grouped itself.
+	Target int
+}
+
+// AnalyzeResponse are the analyses of the commits.
+type AnalyzeResponse struct {
+	// Commits are in the order of the request. A commit the model did not
+	// answer for has no summary.
+	Commits []CommitAnalysis
+
+	// Provider is the name of the provider that analyzed the commits.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// AnalyzeCommits summarizes what each commit, oldest first, changes, flags
+// messages that do not match the diff, and suggests commits to combine with
+// an earlier one, e.g. before an interactive rebase. If the provider does not
+// support it, e.g. the Heuristic provider, the summaries are derived from the
+// changed files and nothing is flagged or grouped.
+func (o *Client) AnalyzeCommits(ctx context.Context, commits []CommitDiff, lang string) (AnalyzeResponse, error) {
+	l, err := language.Parse(lang)
+	if err != nil {
+		return AnalyzeResponse{}, err
+	}
+
+	var prompt strings.Builder
+
+	for i, commit := range commits {
+		fmt.Fprintf(&prompt, "id: %d\ncommit message:\n%s\ndiff:\n%s\n\n", i,
+			strings.TrimSpace(commit.Message), tokens.Truncate(commit.Diff, maxAnalyzeDiffTokens))
+	}
+
+	var answer analyzeAnswer
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages: []openai.Message{
+			{
+				Role: openai.SystemRole,
+				Content: fmt.Sprintf(`You review the commits of a branch before it is cleaned up
+with an interactive rebase. The commits are given oldest first. For each
+commit:
+- summarize what the diff actually changes in one sentence, in the %s
+- explain briefly if the message does not describe the diff, e.g. "wip" or a
+  message about another change, and leave it empty if it does
+- answer "fixup" and the id of an earlier commit if the commit only corrects
+  it, e.g. a typo or a forgotten file, "squash" if it continues the same
+  change, and "none" with target -1 otherwise. Only group commits that
+  clearly belong together.
+%s`, l.Mood, languageInstructions(l, nil)),
+			},
+			{
+				Role:    openai.UserRole,
+				Content: prompt.String(),
+			},
+		},
+		Schema:      analyzeJSONSchema,
+		Temperature: 0,
+	}, &answer, "the analysis")
+	if errors.Is(err, provider.ErrUnsupported) {
+		response := heuristicAnalysis(commits)
+		response.Cost = result.Cost
+
+		return response, nil
+	}
+
+	if err != nil {
+		return AnalyzeResponse{}, err
+	}
+
+	analyses := make([]CommitAnalysis, len(commits))
+	for i := range analyses {
+		analyses[i] = CommitAnalysis{Grouping: NoGrouping, Target: -1}
+	}
+
+	for _, commit := range answer.Commits {
+		if commit.ID < 0 || commit.ID >= len(commits) {
+			continue
+		}
+
+		analysis := CommitAnalysis{
+			Summary:  strings.TrimSpace(commit.Summary),
+			Mismatch: strings.TrimSpace(commit.Mismatch),
+			Grouping: Grouping(commit.Group),
+			Target:   commit.Target,
+		}
+
+		if (analysis.Grouping != FixupGrouping && analysis.Grouping != SquashGrouping) ||
+			analysis.Target < 0 || analysis.Target >= commit.ID {
+			analysis.Grouping, analysis.Target = NoGrouping, -1
+		}
+
+		analyses[commit.ID] = analysis
+	}
+
+	// A commit grouped with a grouped commit is grouped with the target of
+	// that one instead. Targets are earlier, so they are resolved first.
+	for i := range analyses {
+		if target := analyses[i].Target; target >= 0 && analyses[target].Target >= 0 {
+			analyses[i].Target = analyses[target].Target
+		}
+	}
+
+	return AnalyzeResponse{
+		Commits:  analyses,
+		Provider: result.Provider,
+		Cost:     result.Cost,
+	}, nil
+}
+
+func heuristicAnalysis(commits []CommitDiff) AnalyzeResponse {
+	response := AnalyzeResponse{Provider: Heuristic{}.Name()}
+
+	for _, commit := range commits {
+		files, _ := diff.Parse(commit.Diff)
+
+		response.Commits = append(response.Commits, CommitAnalysis{
+			Summary:  heuristicSubject(files),
+			Grouping: NoGrouping,
+			Target:   -1,
+		})
+	}
+
+	return response
+}
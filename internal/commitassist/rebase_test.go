@@ -0,0 +1,60 @@
+package commitassist_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+func TestAnalyzeCommits(t *testing.T) {
+	content := `{"commits":[
+		{"id":0,"summary":"Adds user search.","mismatch":"","group":"none","target":-1},
+		{"id":1,"summary":"Fixes a typo in the search.","mismatch":"wip does not describe the change","group":"fixup","target":0},
+		{"id":2,"summary":"Renames a variable.","mismatch":"","group":"squash","target":1},
+		{"id":3,"summary":"Updates the README.","mismatch":"","group":"squash","target":3}
+	]}`
+
+	commits := []commitassist.CommitDiff{
+		{Message: "Add user search", Diff: stagedDiff},
+		{Message: "wip", Diff: stagedDiff},
+		{Message: "Rename variable", Diff: stagedDiff},
+		{Message: "Update README", Diff: stagedDiff},
+	}
+
+	response, err := newClient(t, content).AnalyzeCommits(context.Background(), commits, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []commitassist.CommitAnalysis{
+		{Summary: "Adds user search.", Grouping: commitassist.NoGrouping, Target: -1},
+		{Summary: "Fixes a typo in the search.", Mismatch: "wip does not describe the change", Grouping: commitassist.FixupGrouping, Target: 0},
+		{Summary: "Renames a variable.", Grouping: commitassist.SquashGrouping, Target: 0},
+		{Summary: "Updates the README.", Grouping: commitassist.NoGrouping, Target: -1},
+	}
+
+	if !reflect.DeepEqual(response.Commits, expected) {
+		t.Errorf("got %+v, want %+v", response.Commits, expected)
+	}
+}
+
+func TestAnalyzeCommitsHeuristic(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits := []commitassist.CommitDiff{{Message: "wip", Diff: stagedDiff}}
+
+	response, err := commitassist.New(commitassist.Heuristic{}, styles).AnalyzeCommits(context.Background(), commits, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Commits) != 1 || response.Commits[0].Summary != "Add search.go" {
+		t.Errorf("got %+v", response.Commits)
+	}
+}
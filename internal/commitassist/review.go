@@ -0,0 +1,214 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/review"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+// maxReviewDiffTokens is the maximum number of tokens of the diff to review,
+// longer diffs are truncated.
+const maxReviewDiffTokens = 50000
+
+// reviewSchema is the JSON schema of reviewAnswer.
+const reviewSchema = `{
+  "type": "object",
+  "properties": {
+    "findings": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "path": {"type": "string"},
+          "line": {"type": "integer", "description": "The line number in the new file, 0 for the whole file."},
+          "severity": {"type": "string", "enum": ["info", "warning", "error"]},
+          "category": {"type": "string", "enum": ["debug", "todo", "error-handling", "secret", "tests", "other"]},
+          "message": {"type": "string", "description": "The mistake, in one sentence."}
+        },
+        "required": ["path", "line", "severity", "category", "message"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["findings"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var reviewJSONSchema = openai.JSONSchema{
+	Name:   "diff_review",
+	Schema: json.RawMessage(reviewSchema),
+	Strict: true,
+}
+
+type reviewAnswer struct {
+	Findings []review.Finding `json:"findings"`
+}
+
+// ReviewResponse are the findings of a review.
+type ReviewResponse struct {
+	// Findings are in the order of the files of the diff and their lines.
+	Findings []review.Finding
+
+	// Provider is the name of the provider that reviewed the diff.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// ReviewDiff asks the model for likely mistakes in the diff, e.g. debug
+// prints, TODOs, swallowed errors, leaked secrets or mismatched test changes.
+// The findings of review.Check are given to the model as hints and are always
+// included. If the provider does not support it, e.g. the Heuristic provider,
+// only they are returned.
+func (o *Client) ReviewDiff(ctx context.Context, gitDiff string, lang string) (ReviewResponse, error) {
+	l, err := language.Parse(lang)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	files, _ := diff.Parse(gitDiff)
+	if len(files) == 0 {
+		return ReviewResponse{}, UnsureError{"there are no changes to review"}
+	}
+
+	checked := review.Check(files)
+
+	var hints strings.Builder
+
+	for _, finding := range checked {
+		fmt.Fprintf(&hints, "- %s\n", finding)
+	}
+
+	hintContent := ""
+	if hints.Len() > 0 {
+		hintContent = "These findings of simple rules are already reported, do not repeat them:\n" + hints.String() + "\n"
+	}
+
+	var answer reviewAnswer
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages: []openai.Message{
+			{
+				Role: openai.SystemRole,
+				Content: fmt.Sprintf(`You review staged changes right before they are committed, and
+report only likely mistakes: debug prints left in, TODOs, ignored or swallowed
+errors, leaked secrets or credentials, tests that do not match the code
+changes, and obvious bugs. Do not comment on style or design, and report
+nothing if there are no mistakes. The diff is given with the line number in the
+new file before each line. Use "error" for mistakes that must not be
+committed, e.g. a secret, "warning" for likely mistakes and "info" for things
+to double-check. Write the messages in %s.
+%s`, l.Name, languageInstructions(l, nil)),
+			},
+			{
+				Role:    openai.UserRole,
+				Content: hintContent + tokens.Truncate(numberedDiff(files), maxReviewDiffTokens),
+			},
+		},
+		Schema:      reviewJSONSchema,
+		Temperature: 0,
+	}, &answer, "the review")
+	if errors.Is(err, provider.ErrUnsupported) {
+		return ReviewResponse{Findings: sortFindings(checked, files), Provider: Heuristic{}.Name(), Cost: result.Cost}, nil
+	}
+
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	paths := map[string]bool{}
+	for _, file := range files {
+		paths[file.Path()] = true
+	}
+
+	findings := checked
+
+	for _, finding := range answer.Findings {
+		if !paths[finding.Path] || containsFinding(findings, finding) {
+			continue
+		}
+
+		if finding.Severity, err = review.ParseSeverity(string(finding.Severity)); err != nil {
+			finding.Severity = review.Warning
+		}
+
+		if finding.Line < 0 {
+			finding.Line = 0
+		}
+
+		finding.Message = strings.TrimSpace(finding.Message)
+		findings = append(findings, finding)
+	}
+
+	return ReviewResponse{
+		Findings: sortFindings(findings, files),
+		Provider: result.Provider,
+		Cost:     result.Cost,
+	}, nil
+}
+
+// sortFindings sorts the findings in the order of the files and their lines.
+func sortFindings(findings []review.Finding, files []diff.File) []review.Finding {
+	order := map[string]int{}
+	for i, file := range files {
+		order[file.Path()] = i
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if order[findings[i].Path] != order[findings[j].Path] {
+			return order[findings[i].Path] < order[findings[j].Path]
+		}
+
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings
+}
+
+// numberedDiff returns the hunks of the files with the line number in the new
+// file before each line.
+func numberedDiff(files []diff.File) string {
+	var b strings.Builder
+
+	prefixes := map[diff.LineKind]string{diff.Context: " ", diff.Added: "+", diff.Removed: "-"}
+
+	for _, file := range files {
+		fmt.Fprintf(&b, "File: %s\n", file.Path())
+
+		for _, hunk := range file.Hunks {
+			numbers := hunk.LineNumbers()
+
+			for i, line := range hunk.Lines {
+				fmt.Fprintf(&b, "%5d %s%s\n", numbers[i], prefixes[line.Kind], line.Content)
+			}
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// containsFinding reports if a finding of the same category is already at the
+// line.
+func containsFinding(findings []review.Finding, finding review.Finding) bool {
+	for _, f := range findings {
+		if f.Path == finding.Path && f.Line == finding.Line && f.Category == finding.Category {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package commitassist_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/review"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+const debugDiff = `diff --git a/search.go b/search.go
+index 1111111..2222222 100644
+--- a/search.go
++++ b/search.go
+@@ -3,2 +3,4 @@ func Users() {
+ 	users := load()
++	_ = save(users)
++	println(users)
+ }
+`
+
+func TestReviewDiff(t *testing.T) {
+	content := `{"findings":[
+		{"path":"search.go","line":4,"severity":"error","category":"error-handling","message":"repeated"},
+		{"path":"search.go","line":3,"severity":"fatal","category":"other","message":"users may be nil"},
+		{"path":"unknown.go","line":1,"severity":"error","category":"other","message":"ignored"}
+	]}`
+
+	response, err := newClient(t, content).ReviewDiff(context.Background(), debugDiff, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []review.Finding{
+		{Path: "search.go", Line: 3, Severity: review.Warning, Category: review.Other, Message: "users may be nil"},
+		{Path: "search.go", Line: 4, Severity: review.Warning, Category: review.ErrorHandling, Message: "ignores or swallows an error"},
+		{Path: "search.go", Line: 5, Severity: review.Warning, Category: review.Debug, Message: "looks like a debug print"},
+	}
+
+	if !reflect.DeepEqual(response.Findings, expected) {
+		t.Errorf("got %+v, want %+v", response.Findings, expected)
+	}
+}
+
+func TestReviewDiffHeuristic(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := commitassist.New(commitassist.Heuristic{}, styles).ReviewDiff(context.Background(), debugDiff, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Findings) != 2 || response.Provider != "heuristic" {
+		t.Errorf("got %+v from %q", response.Findings, response.Provider)
+	}
+}
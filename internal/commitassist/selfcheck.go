@@ -0,0 +1,77 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+// selfCheckSchema is the JSON schema of selfCheckAnswer.
+const selfCheckSchema = `{
+  "type": "object",
+  "properties": {
+    "confidence": {
+      "type": "number",
+      "description": "How confident you are that the commit message accurately describes the diff, from 0 to 1."
+    },
+    "reason": {
+      "type": "string",
+      "description": "What the message gets wrong or leaves out, empty if nothing."
+    }
+  },
+  "required": ["confidence", "reason"],
+  "additionalProperties": false
+}`
+
+type selfCheckAnswer struct {
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// selfCheck asks the model how well the message describes the diff. It
+// returns the confidence and the cost of the request in cent. The confidence
+// is unknown if the provider does not support the self-check.
+func (o *Client) selfCheck(ctx context.Context, gitDiff, message string) (float64, float64, error) {
+	messages := []openai.Message{
+		{
+			Role: openai.SystemRole,
+			Content: `You review commit messages. Given a git diff and a commit message,
+judge whether the message accurately describes the changes in the diff: it
+must not claim anything the diff does not do, and must not leave out
+significant changes.`,
+		},
+		{
+			Role:    openai.UserRole,
+			Content: "Diff:\n" + gitDiff + "\n\nCommit message:\n" + message,
+		},
+	}
+
+	schema := openai.JSONSchema{Name: "self_check", Schema: json.RawMessage(selfCheckSchema), Strict: true}
+
+	var answer selfCheckAnswer
+
+	result, err := o.complete(ctx, provider.Request{Messages: messages, Schema: schema}, &answer, "the self-check")
+	if errors.Is(err, provider.ErrUnsupported) {
+		return confidence.Unknown, result.Cost, nil
+	}
+
+	var unexpected UnexpectedStateError
+	if errors.As(err, &unexpected) {
+		return 0, 0, unexpected
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not do the self-check request: %w", err)
+	}
+
+	if answer.Confidence < 0 || answer.Confidence > 1 {
+		return 0, 0, UnexpectedStateError{fmt.Sprintf("self-check confidence must be between 0 and 1, got %v", answer.Confidence)}
+	}
+
+	return answer.Confidence, result.Cost, nil
+}
@@ -0,0 +1,129 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/semver"
+)
+
+// bumpSchema is the JSON schema of bumpAnswer.
+const bumpSchema = `{
+  "type": "object",
+  "properties": {
+    "commits": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "bump": {"type": "string", "enum": ["none", "patch", "minor", "major"]},
+          "reason": {"type": "string", "description": "Why, in a few words."}
+        },
+        "required": ["id", "bump", "reason"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["commits"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var bumpJSONSchema = openai.JSONSchema{
+	Name:   "version_bump",
+	Schema: json.RawMessage(bumpSchema),
+	Strict: true,
+}
+
+type bumpAnswer struct {
+	Commits []struct {
+		ID     int    `json:"id"`
+		Bump   string `json:"bump"`
+		Reason string `json:"reason"`
+	} `json:"commits"`
+}
+
+// Classification is the semantic version bump of a commit.
+type Classification struct {
+	Level  semver.Level
+	Reason string
+}
+
+// ClassifyResponse are the classifications of the commits by the model.
+type ClassifyResponse struct {
+	// Classifications are in the order of the messages. A message the model
+	// did not answer for is Patch.
+	Classifications []Classification
+
+	// Provider is the name of the provider that classified the commits.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// ClassifyCommits asks the model which part of the semantic version each
+// commit bumps, e.g. for messages that are not conventional commits.
+func (o *Client) ClassifyCommits(ctx context.Context, messages []string) (ClassifyResponse, error) {
+	var commits strings.Builder
+
+	for i, message := range messages {
+		fmt.Fprintf(&commits, "id: %d\ncommit message:\n%s\n\n", i, strings.TrimSpace(message))
+	}
+
+	var answer bumpAnswer
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages: []openai.Message{
+			{
+				Role: openai.SystemRole,
+				Content: `You classify commits by how they change the next release according to
+semantic versioning. Answer for each commit:
+- major: it breaks backwards compatibility for the users, e.g. removes or
+  changes a public API, flag or configuration
+- minor: it adds functionality in a backwards compatible way
+- patch: it fixes a bug or changes the behavior in a backwards compatible way
+- none: it does not affect the users, e.g. documentation, tests or CI
+When unsure between two levels, answer the higher one.`,
+			},
+			{
+				Role:    openai.UserRole,
+				Content: commits.String(),
+			},
+		},
+		Schema:      bumpJSONSchema,
+		Temperature: 0,
+	}, &answer, "the classification")
+	if err != nil {
+		return ClassifyResponse{}, err
+	}
+
+	classifications := make([]Classification, len(messages))
+	for i := range classifications {
+		classifications[i] = Classification{Level: semver.Patch, Reason: "not classified by the model"}
+	}
+
+	for _, commit := range answer.Commits {
+		if commit.ID < 0 || commit.ID >= len(messages) {
+			continue
+		}
+
+		level, err := semver.ParseLevel(commit.Bump)
+		if err != nil {
+			return ClassifyResponse{}, UnexpectedStateError{err.Error()}
+		}
+
+		classifications[commit.ID] = Classification{Level: level, Reason: strings.TrimSpace(commit.Reason)}
+	}
+
+	return ClassifyResponse{
+		Classifications: classifications,
+		Provider:        result.Provider,
+		Cost:            result.Cost,
+	}, nil
+}
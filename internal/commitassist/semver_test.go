@@ -0,0 +1,26 @@
+package commitassist_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/semver"
+)
+
+func TestClassifyCommits(t *testing.T) {
+	content := `{"commits":[{"id":1,"bump":"minor","reason":"adds search"},{"id":5,"bump":"major","reason":"ignored"}]}`
+
+	response, err := newClient(t, content).ClassifyCommits(context.Background(), []string{"Tweak logging", "Add search"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Classifications) != 2 {
+		t.Fatalf("got %d classifications, want 2", len(response.Classifications))
+	}
+
+	if response.Classifications[0].Level != semver.Patch || response.Classifications[1].Level != semver.Minor ||
+		response.Classifications[1].Reason != "adds search" {
+		t.Errorf("got %+v", response.Classifications)
+	}
+}
@@ -0,0 +1,334 @@
+package commitassist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/apidiff"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+// maxSplitUnitTokens is the maximum number of tokens of a hunk in the prompt
+// of SuggestSplit, longer hunks are truncated.
+const maxSplitUnitTokens = 1500
+
+// splitSchema is the JSON schema of splitAnswer.
+const splitSchema = `{
+  "type": "object",
+  "properties": {
+    "commits": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "subject": {"type": "string", "description": "The commit subject, without the conventional commit type and scope."},
+          "body": {"type": "string", "description": "The commit body, empty if not needed."},
+          "type": {"type": "string", "description": "The conventional commit type, e.g. feat or fix."},
+          "scope": {"type": "string", "description": "The conventional commit scope, empty if none."},
+          "hunks": {"type": "array", "items": {"type": "integer"}, "description": "The ids of the hunks of the commit."},
+          "reason": {"type": "string", "description": "The intent of the commit, in a few words."}
+        },
+        "required": ["subject", "body", "type", "scope", "hunks", "reason"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["commits"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var splitJSONSchema = openai.JSONSchema{
+	Name:   "commit_split",
+	Schema: json.RawMessage(splitSchema),
+	Strict: true,
+}
+
+type splitAnswer struct {
+	Commits []struct {
+		StructuredMessage
+
+		Hunks  []int  `json:"hunks"`
+		Reason string `json:"reason"`
+	} `json:"commits"`
+}
+
+// SplitCommit is a commit of a suggested split.
+type SplitCommit struct {
+	Message string
+
+	// Units are the indexes of the units of the commit in SplitResponse, in
+	// the order of the diff.
+	Units []int
+
+	// Reason is the intent of the commit.
+	Reason string
+}
+
+// SplitResponse is a suggested sequence of commits of a diff.
+type SplitResponse struct {
+	// Units are the hunks of the diff, see diff.SplitUnits.
+	Units []diff.Unit
+
+	// Commits are in the order they should be made. Every unit belongs to
+	// exactly one commit.
+	Commits []SplitCommit
+
+	// Provider is the name of the provider that suggested the split.
+	Provider string
+
+	// Cost is the cost of the request in cent.
+	Cost float64
+}
+
+// SuggestSplit clusters the hunks of the diff by intent, e.g. a bug fix, a
+// refactoring and a dependency update, and suggests a commit with a message
+// for each cluster. The conventional commit type inferred from each hunk is
+// given to the model as a hint. If the provider does not support it, e.g.
+// the Heuristic provider, the hunks are grouped by the inferred type.
+//
+// The Style and examples of the configuration are not used.
+func (o *Client) SuggestSplit(ctx context.Context, gitDiff string, cfg *MessageConfig) (SplitResponse, error) {
+	if cfg == nil {
+		cfg = &MessageConfig{}
+	}
+
+	lang, err := language.Parse(cfg.Language)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+
+	units := diff.SplitUnits(gitDiff)
+	if len(units) == 0 {
+		return SplitResponse{}, UnsureError{"there are no changes to split"}
+	}
+
+	conventionalCommitContent := ""
+	if cfg.ConventionalCommitCompliant {
+		conventionalCommitContent = "Use the conventional commit standard.\n" + conventionalConstraints(cfg.ConventionalRules)
+	}
+
+	messages := []openai.Message{
+		{
+			Role: openai.SystemRole,
+			Content: fmt.Sprintf(`You are an insightful assistant that helps to
+make focused commits. The staged changes are given as numbered hunks. Group
+the hunks by intent, e.g. a bug fix, a refactoring or a dependency update,
+into a sequence of commits that each do one thing, and write a commit message
+for each. A hunk must belong to exactly one commit. Order the commits so that
+each builds on the previous ones, e.g. a refactoring before the feature using
+it. Do not split changes that belong together, one commit is fine if the
+changes have one intent.
+
+The commit subject should be brief (50 characters or less) and use the %s.
+The type inferred from the files of each hunk is given as a hint.
+%s
+%s`, lang.Mood, conventionalCommitContent, languageInstructions(lang, nil)),
+		},
+		{
+			Role:    openai.UserRole,
+			Content: splitPrompt(units, cfg),
+		},
+	}
+
+	var answer splitAnswer
+
+	result, err := o.complete(ctx, provider.Request{
+		Messages:    messages,
+		Schema:      splitJSONSchema,
+		Temperature: 0.2,
+		MaxTokens:   maxLongAnswerTokens,
+	}, &answer, "the split")
+	if errors.Is(err, provider.ErrUnsupported) {
+		response, err := heuristicSplit(units, cfg, lang)
+		response.Cost = result.Cost
+
+		return response, err
+	}
+
+	if err != nil {
+		return SplitResponse{}, err
+	}
+
+	response := SplitResponse{
+		Units:    units,
+		Provider: result.Provider,
+		Cost:     result.Cost,
+	}
+
+	assigned := make([]bool, len(units))
+
+	for _, commit := range answer.Commits {
+		split := SplitCommit{Reason: strings.TrimSpace(commit.Reason)}
+
+		for _, id := range commit.Hunks {
+			if id < 0 || id >= len(units) || assigned[id] {
+				continue
+			}
+
+			assigned[id] = true
+			split.Units = append(split.Units, id)
+		}
+
+		if len(split.Units) == 0 || strings.TrimSpace(commit.Subject) == "" {
+			continue
+		}
+
+		sort.Ints(split.Units)
+
+		split.Message, err = splitMessage(commit.StructuredMessage, units, split.Units, cfg, lang)
+		if err != nil {
+			return SplitResponse{}, err
+		}
+
+		response.Commits = append(response.Commits, split)
+	}
+
+	if len(response.Commits) == 0 {
+		return SplitResponse{}, UnexpectedStateError{"the split has no commits"}
+	}
+
+	// Hunks the model left out are added to the last commit.
+	last := &response.Commits[len(response.Commits)-1]
+
+	for id, ok := range assigned {
+		if !ok {
+			last.Units = append(last.Units, id)
+		}
+	}
+
+	sort.Ints(last.Units)
+
+	return response, nil
+}
+
+// splitPrompt lists the hunks with their ids, paths and inferred types.
+func splitPrompt(units []diff.Unit, cfg *MessageConfig) string {
+	var b strings.Builder
+
+	for i, unit := range units {
+		file := unit.File()
+		inference := cfg.ConventionalRules.Infer([]diff.File{file})
+
+		fmt.Fprintf(&b, "Hunk %d: %s (inferred type: %s)\n", i, file.Path(), inference.Type)
+
+		patch := unit.Hunk
+		if patch == "" {
+			patch = unit.Header
+		}
+
+		b.WriteString(tokens.Truncate(patch, maxSplitUnitTokens))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// splitMessage renders the message of a commit, corrected to follow the
+// conventional commit rules of the configuration.
+func splitMessage(structured StructuredMessage, units []diff.Unit, ids []int, cfg *MessageConfig, lang language.Language) (string, error) {
+	structured.Subject = strings.TrimSpace(structured.Subject)
+	structured.Body = strings.TrimSpace(structured.Body)
+
+	message := structured.Text(cfg.ConventionalCommitCompliant)
+
+	if !cfg.ConventionalCommitCompliant {
+		return message, nil
+	}
+
+	files := make([]diff.File, 0, len(ids))
+	for _, id := range ids {
+		files = append(files, units[id].File())
+	}
+
+	message, err := correctConventionalMessage(message, cfg.ConventionalRules, cfg.ConventionalRules.Infer(files), apidiff.Report{}, lang)
+	if err != nil {
+		return "", InvalidMessageError{err.Error()}
+	}
+
+	return message, nil
+}
+
+// heuristicSplit groups the units by the conventional commit type inferred
+// from their files, in the order the types first appear, with messages of the
+// Heuristic provider. The hunks of a file are never split.
+func heuristicSplit(units []diff.Unit, cfg *MessageConfig, lang language.Language) (SplitResponse, error) {
+	response := SplitResponse{
+		Units:    units,
+		Provider: Heuristic{}.Name(),
+	}
+
+	files := make([]diff.File, 0, len(units))
+	for _, unit := range units {
+		files = append(files, unit.File())
+	}
+
+	types := map[string]string{}
+	for _, file := range mergeFiles(files) {
+		types[file.Path()] = cfg.ConventionalRules.Infer([]diff.File{file}).Type
+	}
+
+	groups := map[string]int{}
+
+	for i, file := range files {
+		commitType := types[file.Path()]
+
+		g, ok := groups[commitType]
+		if !ok {
+			g = len(response.Commits)
+			groups[commitType] = g
+
+			response.Commits = append(response.Commits, SplitCommit{Reason: "changes inferred to be " + commitType})
+		}
+
+		response.Commits[g].Units = append(response.Commits[g].Units, i)
+	}
+
+	for i := range response.Commits {
+		commit := &response.Commits[i]
+
+		files := make([]diff.File, 0, len(commit.Units))
+		for _, id := range commit.Units {
+			files = append(files, units[id].File())
+		}
+
+		structured := heuristicMessage(mergeFiles(files))
+		structured.Type = cfg.ConventionalRules.Infer(files).Type
+
+		var err error
+
+		commit.Message, err = splitMessage(structured, units, commit.Units, cfg, lang)
+		if err != nil {
+			return SplitResponse{}, err
+		}
+	}
+
+	return response, nil
+}
+
+// mergeFiles merges the hunks of the same file, e.g. of several units.
+func mergeFiles(files []diff.File) []diff.File {
+	merged := []diff.File{}
+	index := map[string]int{}
+
+	for _, file := range files {
+		if i, ok := index[file.Path()]; ok {
+			merged[i].Hunks = append(merged[i].Hunks, file.Hunks...)
+			continue
+		}
+
+		index[file.Path()] = len(merged)
+		file.Hunks = append([]diff.Hunk{}, file.Hunks...)
+		merged = append(merged, file)
+	}
+
+	return merged
+}
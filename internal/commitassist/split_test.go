@@ -0,0 +1,109 @@
+package commitassist_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+const unfocusedDiff = `diff --git a/go.mod b/go.mod
+index 1111111..2222222 100644
+--- a/go.mod
++++ b/go.mod
+@@ -3 +3 @@
+-require github.com/urfave/cli v1.22.0
++require github.com/urfave/cli v1.22.5
+diff --git a/internal/search/search.go b/internal/search/search.go
+index 1111111..2222222 100644
+--- a/internal/search/search.go
++++ b/internal/search/search.go
+@@ -3,3 +3,3 @@
+-func Users() {}
++func Users(name string) {}
+@@ -20,3 +20,3 @@
+-	return nil
++	return err
+`
+
+func TestSuggestSplit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []commitassist.SplitCommit
+	}{
+		{
+			name: "two commits",
+			content: `{"commits":[
+				{"subject":"Return search errors","body":"","type":"fix","scope":"","hunks":[2],"reason":"bug fix"},
+				{"subject":"Search users by name","body":"","type":"feat","scope":"","hunks":[1,0],"reason":"feature"}
+			]}`,
+			expected: []commitassist.SplitCommit{
+				{Message: "Return search errors", Units: []int{2}, Reason: "bug fix"},
+				{Message: "Search users by name", Units: []int{0, 1}, Reason: "feature"},
+			},
+		},
+		{
+			name: "unassigned and duplicate hunks",
+			content: `{"commits":[
+				{"subject":"Update cli","body":"","type":"chore","scope":"","hunks":[0,7],"reason":"dependency"},
+				{"subject":"Search users by name","body":"","type":"feat","scope":"","hunks":[0,1],"reason":"feature"}
+			]}`,
+			expected: []commitassist.SplitCommit{
+				{Message: "Update cli", Units: []int{0}, Reason: "dependency"},
+				{Message: "Search users by name", Units: []int{1, 2}, Reason: "feature"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := newClient(t, tc.content).SuggestSplit(context.Background(), unfocusedDiff, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(response.Units) != 3 {
+				t.Fatalf("got %d units, want 3", len(response.Units))
+			}
+
+			if !reflect.DeepEqual(response.Commits, tc.expected) {
+				t.Errorf("got %+v, want %+v", response.Commits, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSuggestSplitHeuristic(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &commitassist.MessageConfig{ConventionalCommitCompliant: true}
+
+	response, err := commitassist.New(commitassist.Heuristic{}, styles).SuggestSplit(context.Background(), unfocusedDiff, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Provider != "heuristic" {
+		t.Errorf("got provider %q, want %q", response.Provider, "heuristic")
+	}
+
+	if len(response.Commits) != 2 {
+		t.Fatalf("got %+v, want a commit per inferred type", response.Commits)
+	}
+
+	if !reflect.DeepEqual(response.Commits[1].Units, []int{1, 2}) {
+		t.Errorf("got units %v, want [1 2]", response.Commits[1].Units)
+	}
+
+	if response.Commits[0].Message != "build: update go.mod" {
+		t.Errorf("got message %q, want %q", response.Commits[0].Message, "build: update go.mod")
+	}
+}
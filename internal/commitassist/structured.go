@@ -0,0 +1,216 @@
+package commitassist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+// StructuredMessage is the commit message as returned by the model, see
+// messageSchema.
+type StructuredMessage struct {
+	// Subject is the first line of the message. In the response of the
+	// model it is without the conventional commit type and scope, in
+	// GetTypeResponse it is the whole first line.
+	Subject string `json:"subject"`
+
+	// Body is the rest of the message, including any trailers.
+	Body string `json:"body"`
+
+	// Type and Scope are the conventional commit type and scope. They are
+	// set by the model also when the message is not a conventional commit.
+	Type  string `json:"type"`
+	Scope string `json:"scope"`
+
+	// Breaking is true if the change breaks backwards compatibility.
+	Breaking bool `json:"breaking"`
+
+	// Confidence is how confident the model is that the message describes
+	// the change, from 0 to 1.
+	Confidence float64 `json:"confidence"`
+
+	// Reasoning is the explanation of the model for the message.
+	Reasoning string `json:"reasoning"`
+}
+
+// messageSchema is the JSON schema of StructuredMessage, in the strict form
+// required by structured outputs.
+const messageSchema = `{
+  "type": "object",
+  "properties": {
+    "subject": {
+      "type": "string",
+      "description": "The commit subject, without the conventional commit type and scope."
+    },
+    "body": {
+      "type": "string",
+      "description": "The commit body, empty if not needed."
+    },
+    "type": {
+      "type": "string",
+      "description": "The conventional commit type of the change, e.g. feat or fix."
+    },
+    "scope": {
+      "type": "string",
+      "description": "The conventional commit scope of the change, empty if none."
+    },
+    "breaking": {
+      "type": "boolean",
+      "description": "True if the change breaks backwards compatibility."
+    },
+    "confidence": {
+      "type": "number",
+      "description": "How confident you are that the message describes the change, from 0 to 1. Use 0 if you are unsure what the change does."
+    },
+    "reasoning": {
+      "type": "string",
+      "description": "A short explanation of the message, or why you are unsure."
+    }
+  },
+  "required": ["subject", "body", "type", "scope", "breaking", "confidence", "reasoning"],
+  "additionalProperties": false
+}`
+
+//nolint:gochecknoglobals
+var messageJSONSchema = openai.JSONSchema{
+	Name:   "commit_message",
+	Schema: json.RawMessage(messageSchema),
+	Strict: true,
+}
+
+// parseStructuredMessage decodes and validates the response of the model. An
+// unknown confidence is only valid if allowed, for the answers of Heuristic.
+func parseStructuredMessage(content string, conventionalCommit, unknownConfidence bool) (StructuredMessage, error) {
+	var structured StructuredMessage
+
+	if err := json.Unmarshal([]byte(content), &structured); err != nil {
+		return StructuredMessage{}, fmt.Errorf("could not decode the structured message: %w", err)
+	}
+
+	structured.Subject = strings.TrimSpace(structured.Subject)
+	structured.Body = strings.TrimSpace(structured.Body)
+	structured.Type = strings.TrimSpace(structured.Type)
+	structured.Scope = strings.TrimSpace(structured.Scope)
+
+	known := !unknownConfidence || structured.Confidence != confidence.Unknown
+
+	switch {
+	case known && (structured.Confidence < 0 || structured.Confidence > 1):
+		return StructuredMessage{}, fmt.Errorf("confidence must be between 0 and 1, got %v", structured.Confidence)
+	case structured.Confidence == 0:
+		// The subject is not needed when the model is unsure.
+		return structured, nil
+	case structured.Subject == "" || strings.Contains(structured.Subject, "\n"):
+		return StructuredMessage{}, fmt.Errorf("invalid subject %q", structured.Subject)
+	case conventionalCommit && structured.Type == "":
+		return StructuredMessage{}, errors.New("missing conventional commit type")
+	}
+
+	return structured, nil
+}
+
+// Text renders the message. With conventionalCommit the subject is prefixed
+// with the type and scope, after any gitmoji.
+func (m StructuredMessage) Text(conventionalCommit bool) string {
+	subject := m.Subject
+
+	if conventionalCommit {
+		prefix, description := gitmoji.Cut(subject)
+
+		subject = conventional.Commit{
+			Type:        m.Type,
+			Scope:       m.Scope,
+			Breaking:    m.Breaking,
+			Description: description,
+		}.Header()
+
+		if prefix != "" {
+			subject = prefix + " " + subject
+		}
+	}
+
+	if m.Body == "" {
+		return subject
+	}
+
+	return subject + "\n\n" + m.Body
+}
+
+// structure returns the fields of a message, the reverse of Text, e.g. to use
+// an example message as the answer of the model. Type and scope are only
+// set for conventional commits.
+func structure(message string) StructuredMessage {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+
+	structured := StructuredMessage{
+		Subject:    strings.TrimSpace(subject),
+		Body:       strings.TrimSpace(body),
+		Confidence: 1,
+	}
+
+	prefix, header := gitmoji.Cut(structured.Subject)
+
+	commit, err := conventional.Parse(header + "\n" + body)
+	if err != nil {
+		return structured
+	}
+
+	structured.Type = commit.Type
+	structured.Scope = commit.Scope
+	structured.Breaking = commit.BreakingChange() != ""
+	structured.Subject = commit.Description
+
+	if prefix != "" {
+		structured.Subject = prefix + " " + commit.Description
+	}
+
+	return structured
+}
+
+// describe updates the fields with the final message, after it has been
+// corrected and trailers added. Subject becomes the whole first line.
+func (m StructuredMessage) describe(message string, conventionalCommit bool) StructuredMessage {
+	subject, body, _ := strings.Cut(message, "\n")
+
+	m.Subject = subject
+	m.Body = strings.TrimSpace(body)
+
+	if !conventionalCommit {
+		return m
+	}
+
+	_, header := gitmoji.Cut(subject)
+
+	if commit, err := conventional.Parse(header + "\n" + body); err == nil {
+		m.Type = commit.Type
+		m.Scope = commit.Scope
+		m.Breaking = commit.BreakingChange() != ""
+	}
+
+	return m
+}
+
+// exampleAnswer returns the example message as the JSON the model should
+// answer with. The type of a message that is not a conventional commit is
+// inferred from the diff.
+func exampleAnswer(example style.Example) string {
+	structured := structure(example.Message)
+
+	if structured.Type == "" {
+		files, _ := diff.Parse(example.Diff)
+		structured.Type = conventional.Infer(files).Type
+	}
+
+	// A StructuredMessage can always be encoded.
+	answer, _ := json.Marshal(structured)
+
+	return string(answer)
+}
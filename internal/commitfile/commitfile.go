@@ -0,0 +1,144 @@
+/*
+Package commitfile reads and rewrites the commit message file of git, e.g.
+$COMMIT_MSG_FILE in the prepare-commit-msg hook.
+
+Lines starting with the comment char, see core.commentChar, are comments. With
+"git commit --verbose" the diff follows a scissors line, below which git
+ignores everything. The comments and the diff are kept when the message is
+rewritten.
+*/
+package commitfile
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+// DefaultCommentChar is the comment char of git if core.commentChar is not
+// set.
+const DefaultCommentChar = "#"
+
+// scissors follows the comment char on the line git ignores everything
+// below, e.g. "# ------------------------ >8 ------------------------".
+const scissors = " ------------------------ >8 ------------------------"
+
+const diffStart = "diff --git "
+
+// File is the content of a commit message file.
+type File struct {
+	// Message is the message already in the file (e.g. added by "git commit
+	// --signoff" or a template), without comments.
+	Message string
+
+	// Content is everything in the file except comments. With "git commit
+	// --verbose" it includes the diff.
+	Content string
+
+	// CommentChar starts the comments, DefaultCommentChar if empty.
+	CommentChar string
+
+	// tail is the comment lines and everything from the scissors line or
+	// the diff on, kept when the file is rewritten.
+	tail []string
+}
+
+// Read reads and parses the file, see Parse.
+func Read(filename, commentChar string) (File, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return File{}, fmt.Errorf("read file %q: %w", filename, err)
+	}
+
+	return Parse(string(content), commentChar), nil
+}
+
+// Parse parses the content of a commit message file. Lines starting with the
+// comment char are comments, DefaultCommentChar is used if it is empty. The
+// message ends at the scissors line or at the diff.
+func Parse(content, commentChar string) File {
+	if commentChar == "" {
+		commentChar = DefaultCommentChar
+	}
+
+	var (
+		message, rest strings.Builder
+		lines, tail   []string
+		below         bool
+	)
+
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	for _, line := range lines {
+		if line == commentChar+scissors || strings.HasPrefix(line, diffStart) {
+			below = true
+		}
+
+		isComment := strings.HasPrefix(line, commentChar)
+
+		if below || isComment {
+			tail = append(tail, line)
+		}
+
+		if isComment {
+			continue
+		}
+
+		rest.WriteString(line)
+		rest.WriteString("\n")
+
+		if !below {
+			message.WriteString(line)
+			message.WriteString("\n")
+		}
+	}
+
+	return File{
+		Message:     message.String(),
+		Content:     rest.String(),
+		CommentChar: commentChar,
+		tail:        tail,
+	}
+}
+
+// Format returns the file with the message replaced by message, keeping the
+// body of the message already in the file (but not its trailers, which are
+// expected to be in message), the comments and the diff. suggested trailers
+// are added as comments, for the user to uncomment.
+func (f File) Format(message string, suggested []trailer.Trailer) string {
+	existingBody, _ := trailer.Split(f.Message)
+
+	if existingBody = strings.TrimSpace(existingBody); existingBody != "" {
+		body, trailers := trailer.Split(message)
+		message = trailer.Append(body+"\n\n"+existingBody, trailers...)
+	}
+
+	commentChar := f.CommentChar
+	if commentChar == "" {
+		commentChar = DefaultCommentChar
+	}
+
+	lines := []string{message, ""}
+
+	for _, t := range suggested {
+		lines = append(lines, commentChar+" "+t.String())
+	}
+
+	lines = append(lines, f.tail...)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Write writes the file with the message replaced, see Format.
+func (f File) Write(filename, message string, suggested []trailer.Trailer) error {
+	//nolint:gosec
+	if err := os.WriteFile(filename, []byte(f.Format(message, suggested)), 0o644); err != nil {
+		return fmt.Errorf("write file %q: %w", filename, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package commitfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitfile"
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+const verboseDiff = `diff --git a/search.go b/search.go
+index 1111111..2222222 100644
+--- a/search.go
++++ b/search.go
+@@ -1,2 +1,3 @@
+ package search
++// Users finds users.
+`
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		commentChar string
+		message     string
+		rest        string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:    "comments",
+			content: "\n# Please enter the commit message for your changes.\n#\n# On branch main\n",
+			message: "\n",
+			rest:    "\n",
+		},
+		{
+			name:    "signoff",
+			content: "\n\nSigned-off-by: Jane Doe <jane@example.com>\n# Please enter the commit message.\n",
+			message: "\n\nSigned-off-by: Jane Doe <jane@example.com>\n",
+			rest:    "\n\nSigned-off-by: Jane Doe <jane@example.com>\n",
+		},
+		{
+			name: "verbose",
+			content: "\n# Please enter the commit message.\n" +
+				"# ------------------------ >8 ------------------------\n" +
+				"# Do not modify or remove the line above.\n" +
+				"# Everything below it will be ignored.\n" + verboseDiff,
+			message: "\n",
+			rest:    "\n" + verboseDiff,
+		},
+		{
+			name: "text below the scissors",
+			content: "Add search\n" +
+				"# ------------------------ >8 ------------------------\n" +
+				"ignored by git\n" + verboseDiff,
+			message: "Add search\n",
+			rest:    "Add search\nignored by git\n" + verboseDiff,
+		},
+		{
+			name:        "custom comment char",
+			content:     "#12 Add search\n; Please enter the commit message.\n",
+			commentChar: ";",
+			message:     "#12 Add search\n",
+			rest:        "#12 Add search\n",
+		},
+		{
+			name: "custom comment char verbose",
+			content: "\n; Please enter the commit message.\n" +
+				"; ------------------------ >8 ------------------------\n" +
+				"; Do not modify or remove the line above.\n" + verboseDiff,
+			commentChar: ";",
+			message:     "\n",
+			rest:        "\n" + verboseDiff,
+		},
+		{
+			name:        "scissors of another comment char",
+			content:     "Add search\n# ------------------------ >8 ------------------------\n",
+			commentChar: ";",
+			message:     "Add search\n# ------------------------ >8 ------------------------\n",
+			rest:        "Add search\n# ------------------------ >8 ------------------------\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			file := commitfile.Parse(tc.content, tc.commentChar)
+
+			if file.Message != tc.message {
+				t.Errorf("got message %q, want %q", file.Message, tc.message)
+			}
+
+			if file.Content != tc.rest {
+				t.Errorf("got content %q, want %q", file.Content, tc.rest)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	suggested := []trailer.Trailer{{Key: "Co-authored-by", Value: "John Doe <john@example.com>"}}
+
+	testCases := []struct {
+		name        string
+		content     string
+		commentChar string
+		message     string
+		expected    string
+	}{
+		{
+			name:     "comments",
+			content:  "\n# Please enter the commit message.\n",
+			message:  "Add search",
+			expected: "Add search\n\n# Co-authored-by: John Doe <john@example.com>\n# Please enter the commit message.\n",
+		},
+		{
+			name:    "existing body",
+			content: "\n\nFixes the lookup.\n\nSigned-off-by: Jane Doe <jane@example.com>\n",
+			message: "Add search\n\nSigned-off-by: Jane Doe <jane@example.com>",
+			expected: "Add search\n\nFixes the lookup.\n\nSigned-off-by: Jane Doe <jane@example.com>\n\n" +
+				"# Co-authored-by: John Doe <john@example.com>\n",
+		},
+		{
+			name: "verbose",
+			content: "\n# Please enter the commit message.\n" +
+				"# ------------------------ >8 ------------------------\n" + verboseDiff,
+			message: "Add search",
+			expected: "Add search\n\n# Co-authored-by: John Doe <john@example.com>\n# Please enter the commit message.\n" +
+				"# ------------------------ >8 ------------------------\n" + verboseDiff,
+		},
+		{
+			name:        "custom comment char",
+			content:     "\n; Please enter the commit message.\n",
+			commentChar: ";",
+			message:     "Add search",
+			expected:    "Add search\n\n; Co-authored-by: John Doe <john@example.com>\n; Please enter the commit message.\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			got := commitfile.Parse(tc.content, tc.commentChar).Format(tc.message, suggested)
+
+			if got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+
+	content := "\n# Please enter the commit message.\n# ------------------------ >8 ------------------------\n" + verboseDiff
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := commitfile.Read(filename, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.Write(filename, "Add search", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "Add search\n" + content; string(got) != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+
+	if _, err := commitfile.Read(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("got no error for a missing file")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Package confidence combines the signals of how confident the model is in a
+suggested message, and decides what to do when it is not confident enough.
+
+The signals are the confidence the model reports in the structured answer,
+the probability of the tokens of the answer (logprobs) when the provider
+returns them, and an optional self-check where the model is asked if the
+message matches the diff.
+*/
+package confidence
+
+import (
+	"fmt"
+	"math"
+)
+
+// Action is what to do with a suggestion below the threshold.
+type Action string
+
+const (
+	// Abstain gives no suggestion. It is the default.
+	Abstain Action = "abstain"
+
+	// Prompt asks the user if the suggestion should be used anyway.
+	Prompt Action = "prompt"
+
+	// Fallback uses a simple message derived from the changed files
+	// instead.
+	Fallback Action = "fallback"
+)
+
+// Unknown is the value of a signal that is not available.
+const Unknown = -1.0
+
+// Policy decides when and how to abstain.
+type Policy struct {
+	// Threshold is the lowest confidence, from 0 to 1, of a suggestion that
+	// is used. Zero disables the policy.
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// Action is what to do below the threshold, Abstain if empty.
+	Action Action `json:"action,omitempty"`
+
+	// SelfCheck asks the model if the suggested message matches the diff,
+	// at the cost of a second request.
+	SelfCheck bool `json:"selfCheck,omitempty"`
+}
+
+// Validate returns an error if the threshold or the action is invalid.
+func (p Policy) Validate() error {
+	if p.Threshold < 0 || p.Threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1, got %v", p.Threshold)
+	}
+
+	switch p.Action {
+	case "", Abstain, Prompt, Fallback:
+		return nil
+	default:
+		return fmt.Errorf("invalid action %q, must be %q, %q or %q", p.Action, Abstain, Prompt, Fallback)
+	}
+}
+
+// Below returns true if the confidence is below the threshold. An Unknown
+// confidence, e.g. of a message derived without a model, is below any
+// threshold.
+func (p Policy) Below(confidence float64) bool {
+	return p.Threshold > 0 && confidence < p.Threshold
+}
+
+// LowAction returns the action, Abstain if not set.
+func (p Policy) LowAction() Action {
+	if p.Action == "" {
+		return Abstain
+	}
+
+	return p.Action
+}
+
+// Signals are the signals of the confidence in a suggestion, from 0 to 1 or
+// Unknown.
+type Signals struct {
+	// Model is the confidence reported by the model.
+	Model float64 `json:"model"`
+
+	// Tokens is the probability of the tokens of the answer.
+	Tokens float64 `json:"tokens"`
+
+	// SelfCheck is the confidence of the model that the message matches the
+	// diff, when asked afterwards.
+	SelfCheck float64 `json:"selfCheck"`
+}
+
+// UnknownSignals returns signals where all are Unknown.
+func UnknownSignals() Signals {
+	return Signals{Model: Unknown, Tokens: Unknown, SelfCheck: Unknown}
+}
+
+// Combined returns the lowest of the known signals, i.e. a suggestion is only
+// as confident as its weakest signal. Unknown is returned if no signal is
+// known.
+func (s Signals) Combined() float64 {
+	combined := Unknown
+
+	for _, signal := range []float64{s.Model, s.Tokens, s.SelfCheck} {
+		if signal < 0 {
+			continue
+		}
+
+		if combined == Unknown || signal < combined {
+			combined = signal
+		}
+	}
+
+	return combined
+}
+
+// FromLogprobs returns the geometric mean of the probabilities of the tokens,
+// given their natural logarithms. Unknown is returned for no tokens.
+func FromLogprobs(logprobs []float64) float64 {
+	if len(logprobs) == 0 {
+		return Unknown
+	}
+
+	sum := 0.0
+	for _, logprob := range logprobs {
+		sum += logprob
+	}
+
+	return math.Exp(sum / float64(len(logprobs)))
+}
@@ -0,0 +1,71 @@
+package confidence_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/confidence"
+)
+
+func TestCombined(t *testing.T) {
+	testCases := []struct {
+		signals  confidence.Signals
+		expected float64
+	}{
+		{signals: confidence.UnknownSignals(), expected: confidence.Unknown},
+		{signals: confidence.Signals{Model: 0.9, Tokens: confidence.Unknown, SelfCheck: confidence.Unknown}, expected: 0.9},
+		{signals: confidence.Signals{Model: 0.9, Tokens: 0.7, SelfCheck: confidence.Unknown}, expected: 0.7},
+		{signals: confidence.Signals{Model: 0.9, Tokens: 0.95, SelfCheck: 0.2}, expected: 0.2},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			if got := tc.signals.Combined(); got != tc.expected {
+				t.Errorf("got %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFromLogprobs(t *testing.T) {
+	if got := confidence.FromLogprobs(nil); got != confidence.Unknown {
+		t.Errorf("got %v, want unknown", got)
+	}
+
+	got := confidence.FromLogprobs([]float64{math.Log(0.5), math.Log(0.5), 0})
+	if expected := math.Pow(0.25, 1.0/3); math.Abs(got-expected) > 1e-9 {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	testCases := []struct {
+		policy     confidence.Policy
+		confidence float64
+		below      bool
+		valid      bool
+	}{
+		{policy: confidence.Policy{}, confidence: 0.1, below: false, valid: true},
+		{policy: confidence.Policy{Threshold: 0.5}, confidence: 0.4, below: true, valid: true},
+		{policy: confidence.Policy{Threshold: 0.5}, confidence: 0.5, below: false, valid: true},
+		{policy: confidence.Policy{Threshold: 0.5}, confidence: confidence.Unknown, below: true, valid: true},
+		{policy: confidence.Policy{Threshold: 1.5}, valid: false},
+		{policy: confidence.Policy{Threshold: 0.5, Action: "ignore"}, valid: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			if err := tc.policy.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("got %v, want valid %v", err, tc.valid)
+			}
+
+			if got := tc.policy.Below(tc.confidence); tc.valid && got != tc.below {
+				t.Errorf("got below %v, want %v", got, tc.below)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+/*
+Package config loads the repository configuration of commit-msg.
+
+The configuration is read from .commit-msg.json in the root of the
+repository. For conventional commits the allowed types and scopes are also
+read from a commitlint configuration (.commitlintrc.json or a .commitlintrc
+containing JSON), unless they are set in .commit-msg.json.
+
+Example .commit-msg.json:
+
+	{
+	  "conventionalCommit": true,
+	  "conventional": {
+	    "types": ["feat", "fix", "perf", "revert", "deps"],
+	    "scopes": [
+	      {"name": "api", "paths": ["services/api/**"]},
+	      {"name": "web", "paths": ["web/**"]}
+	    ],
+	    "scopeDelimiter": ","
+	  },
+	  "issues": {
+	    "placement": "trailer",
+	    "trailerToken": "Refs",
+	    "patterns": [
+	      {"name": "jira", "regexp": "(PROJ-[0-9]+)"}
+	    ]
+	  },
+	  "trailers": {
+	    "signOff": true,
+	    "coAuthors": ["Jane Doe <jane@example.com>"],
+	    "suggestCoAuthors": true,
+	    "custom": [{"key": "Reviewed-by", "value": "John Doe <john@example.com>"}]
+	  },
+	  "repoStyle": {
+	    "author": "jane@example.com",
+	    "paths": ["services/api"],
+	    "examples": 3,
+	    "tokenBudget": 2000,
+	    "pin": ["4f1d2c3"],
+	    "exclude": ["a1b2c3d"]
+	  },
+	  "stylesDir": ".commit-msg/styles",
+	  "gitmoji": {
+	    "form": "code"
+	  },
+	  "language": "sv",
+	  "confidence": {
+	    "threshold": 0.6,
+	    "action": "fallback",
+	    "selfCheck": true
+	  },
+	  "protectedBranches": ["main", "release/*"],
+	  "review": {
+	    "threshold": "warning",
+	    "action": "block"
+	  }
+	}
+*/
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/philiplinell/commit-msg/internal/confidence"
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/fewshot"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+	"github.com/philiplinell/commit-msg/internal/issue"
+	"github.com/philiplinell/commit-msg/internal/language"
+	"github.com/philiplinell/commit-msg/internal/review"
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+const (
+	// FileName is the name of the configuration file.
+	FileName = ".commit-msg.json"
+
+	// DefaultStylesDir is the directory of the user-defined styles, see
+	// package style.
+	DefaultStylesDir = ".commit-msg/styles"
+)
+
+//nolint:gochecknoglobals
+var (
+	// DefaultProtectedBranches are the protected branches if none are
+	// configured.
+	DefaultProtectedBranches = []string{"main", "master"}
+)
+
+//nolint:gochecknoglobals
+var commitlintFileNames = []string{".commitlintrc.json", ".commitlintrc"}
+
+// Config is the repository configuration.
+type Config struct {
+	// ConventionalCommit enables conventional commits, like the
+	// --conventional-commit flag.
+	ConventionalCommit bool `json:"conventionalCommit"`
+
+	// Conventional is the vocabulary allowed in conventional commits.
+	Conventional conventional.Rules `json:"conventional"`
+
+	// Issues configures how issue references are extracted from the branch
+	// name and added to the message.
+	Issues issue.Config `json:"issues"`
+
+	// Trailers configures the trailers added to the message.
+	Trailers trailer.Config `json:"trailers"`
+
+	// RepoStyle configures how previous commits are selected as examples
+	// for the RepoStyle style.
+	RepoStyle fewshot.Config `json:"repoStyle"`
+
+	// StylesDir is the directory of the user-defined styles, relative to the
+	// repository. DefaultStylesDir is used if empty.
+	StylesDir string `json:"stylesDir"`
+
+	// Gitmoji configures the gitmojis of the Gitmoji style.
+	Gitmoji gitmoji.Config `json:"gitmoji"`
+
+	// Language is the BCP 47 tag of the language of the messages, like the
+	// --language flag. Empty is English.
+	Language string `json:"language"`
+
+	// Confidence decides what to do with suggestions the model is not
+	// confident about.
+	Confidence confidence.Policy `json:"confidence"`
+
+	// ProtectedBranches are the names or patterns, see path.Match, of the
+	// branches whose commits are not rewritten by reword.
+	// DefaultProtectedBranches are used if empty.
+	ProtectedBranches []string `json:"protectedBranches"`
+
+	// Review decides if the findings of the review command block the
+	// commit.
+	Review review.Policy `json:"review"`
+}
+
+// IsProtected reports if the branch, e.g. "release/1.0", is protected.
+func (c Config) IsProtected(branch string) bool {
+	patterns := c.ProtectedBranches
+	if len(patterns) == 0 {
+		patterns = DefaultProtectedBranches
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load reads the configuration from the directory dir. A missing
+// configuration file is not an error, the zero Config is returned instead.
+func Load(dir string) (Config, error) {
+	cfg := Config{}
+
+	if err := readJSON(filepath.Join(dir, FileName), &cfg); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, err
+	}
+
+	if err := cfg.Issues.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid issues configuration: %w", err)
+	}
+
+	if err := cfg.Gitmoji.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid gitmoji configuration: %w", err)
+	}
+
+	if _, err := language.Parse(cfg.Language); err != nil {
+		return Config{}, fmt.Errorf("invalid language: %w", err)
+	}
+
+	if err := cfg.Confidence.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid confidence configuration: %w", err)
+	}
+
+	if err := cfg.Review.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid review configuration: %w", err)
+	}
+
+	for _, pattern := range cfg.ProtectedBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Config{}, fmt.Errorf("invalid protected branch %q: %w", pattern, err)
+		}
+	}
+
+	if len(cfg.Conventional.Types) > 0 && len(cfg.Conventional.Scopes) > 0 {
+		return cfg, nil
+	}
+
+	for _, name := range commitlintFileNames {
+		rules, err := readCommitlint(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, errNotJSON) {
+			continue
+		}
+
+		if err != nil {
+			return Config{}, err
+		}
+
+		if len(cfg.Conventional.Types) == 0 {
+			cfg.Conventional.Types = rules.Types
+		}
+
+		if len(cfg.Conventional.Scopes) == 0 {
+			cfg.Conventional.Scopes = rules.Scopes
+		}
+
+		break
+	}
+
+	return cfg, nil
+}
+
+var errNotJSON = errors.New("not a JSON file")
+
+// commitlintConfig is the subset of the commitlint configuration that is
+// used. Rules are on the form [level, applicable, value], e.g.
+// "type-enum": [2, "always", ["feat", "fix"]].
+type commitlintConfig struct {
+	Rules map[string][]json.RawMessage `json:"rules"`
+}
+
+func readCommitlint(filename string) (conventional.Rules, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return conventional.Rules{}, fmt.Errorf("read %q: %w", filename, err)
+	}
+
+	// .commitlintrc might as well be YAML, which is not supported.
+	if !json.Valid(content) {
+		return conventional.Rules{}, errNotJSON
+	}
+
+	var commitlint commitlintConfig
+	if err := json.Unmarshal(content, &commitlint); err != nil {
+		return conventional.Rules{}, fmt.Errorf("decode %q: %w", filename, err)
+	}
+
+	rules := conventional.Rules{}
+
+	types, err := commitlintEnum(commitlint.Rules["type-enum"])
+	if err != nil {
+		return conventional.Rules{}, fmt.Errorf("decode type-enum in %q: %w", filename, err)
+	}
+
+	rules.Types = types
+
+	scopes, err := commitlintEnum(commitlint.Rules["scope-enum"])
+	if err != nil {
+		return conventional.Rules{}, fmt.Errorf("decode scope-enum in %q: %w", filename, err)
+	}
+
+	for _, scope := range scopes {
+		rules.Scopes = append(rules.Scopes, conventional.Scope{Name: scope})
+	}
+
+	return rules, nil
+}
+
+// commitlintEnum returns the values of an enum rule. Disabled rules (level 0)
+// and rules that are not "always" applicable are ignored.
+func commitlintEnum(rule []json.RawMessage) ([]string, error) {
+	if len(rule) != 3 {
+		return nil, nil
+	}
+
+	var (
+		level      int
+		applicable string
+		values     []string
+	)
+
+	if err := json.Unmarshal(rule[0], &level); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rule[1], &applicable); err != nil {
+		return nil, err
+	}
+
+	if level == 0 || applicable != "always" {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(rule[2], &values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func readJSON(filename string, v any) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", filename, err)
+	}
+
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("decode %q: %w", filename, err)
+	}
+
+	return nil
+}
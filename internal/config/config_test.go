@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/config"
+)
+
+func TestLoadMissingFileReturnsZeroConfig(t *testing.T) {
+	cfg, err := config.Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cfg, config.Config{}) {
+		t.Errorf("got %+v, want zero config", cfg)
+	}
+}
+
+func TestLoadCommitlint(t *testing.T) {
+	cfg, err := config.Load("testdata/commitlint")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cfg.Conventional.Types, []string{"feat", "fix", "perf", "revert", "deps"}) {
+		t.Errorf("got types %v", cfg.Conventional.Types)
+	}
+
+	if !reflect.DeepEqual(cfg.Conventional.ScopeNames(), []string{"api", "web", "infra"}) {
+		t.Errorf("got scopes %v", cfg.Conventional.ScopeNames())
+	}
+}
+
+func TestLoadPrefersConfigFileOverCommitlint(t *testing.T) {
+	cfg, err := config.Load("testdata/override")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.ConventionalCommit {
+		t.Error("expected conventional commits to be enabled")
+	}
+
+	if !reflect.DeepEqual(cfg.Conventional.ScopeNames(), []string{"api"}) {
+		t.Errorf("got scopes %v", cfg.Conventional.ScopeNames())
+	}
+
+	if len(cfg.Conventional.Types) != 5 {
+		t.Errorf("got types %v, want types from commitlint", cfg.Conventional.Types)
+	}
+}
+
+func TestIsProtected(t *testing.T) {
+	testCases := []struct {
+		cfg      config.Config
+		branch   string
+		expected bool
+	}{
+		{cfg: config.Config{}, branch: "main", expected: true},
+		{cfg: config.Config{}, branch: "feature/login", expected: false},
+		{cfg: config.Config{ProtectedBranches: []string{"release/*"}}, branch: "release/1.0", expected: true},
+		{cfg: config.Config{ProtectedBranches: []string{"release/*"}}, branch: "main", expected: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.branch, func(t *testing.T) {
+			if got := tc.cfg.IsProtected(tc.branch); got != tc.expected {
+				t.Errorf("got %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Package conventional parses and validates commit messages following the
+Conventional Commits specification (https://www.conventionalcommits.org).
+
+A conventional commit message looks like:
+
+	<type>[(<scope>)][!]: <description>
+
+	[body]
+
+	[footer(s)]
+*/
+package conventional
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	Build    = "build"
+	Chore    = "chore"
+	CI       = "ci"
+	Docs     = "docs"
+	Feat     = "feat"
+	Fix      = "fix"
+	Perf     = "perf"
+	Refactor = "refactor"
+	Revert   = "revert"
+	Style    = "style"
+	Test     = "test"
+)
+
+// DefaultTypes returns the commit types recommended by the conventional
+// commit specification (based on the Angular convention).
+func DefaultTypes() []string {
+	return []string{Build, Chore, CI, Docs, Feat, Fix, Perf, Refactor, Revert, Style, Test}
+}
+
+// BreakingChangeToken is the footer token used to describe a breaking change.
+const BreakingChangeToken = "BREAKING CHANGE"
+
+// ErrInvalidHeader is returned when the first line of a message is not a
+// conventional commit header.
+var ErrInvalidHeader = errors.New("invalid conventional commit header")
+
+// Footer is a single "token: value" or "token #value" footer.
+type Footer struct {
+	Token string
+	Value string
+
+	// Separator is either ": " or " #". An empty separator is formatted as
+	// ": ".
+	Separator string
+}
+
+// String returns the footer formatted as "token: value" or "token #value".
+func (f Footer) String() string {
+	if f.Separator == "" {
+		return f.Token + ": " + f.Value
+	}
+
+	return f.Token + f.Separator + f.Value
+}
+
+// Commit is a parsed conventional commit message.
+type Commit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+	Body        string
+	Footers     []Footer
+}
+
+// Header returns the first line of the commit message.
+func (c Commit) Header() string {
+	sb := strings.Builder{}
+	sb.WriteString(c.Type)
+
+	if c.Scope != "" {
+		sb.WriteString("(" + c.Scope + ")")
+	}
+
+	if c.Breaking {
+		sb.WriteString("!")
+	}
+
+	sb.WriteString(": ")
+	sb.WriteString(c.Description)
+
+	return sb.String()
+}
+
+// String returns the full commit message.
+func (c Commit) String() string {
+	parts := []string{c.Header()}
+
+	if c.Body != "" {
+		parts = append(parts, c.Body)
+	}
+
+	if len(c.Footers) > 0 {
+		footers := make([]string, 0, len(c.Footers))
+		for _, footer := range c.Footers {
+			footers = append(footers, footer.String())
+		}
+
+		parts = append(parts, strings.Join(footers, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// BreakingChange returns the description of the breaking change, taken from
+// the "BREAKING CHANGE" footer or, if there is none, the description.
+func (c Commit) BreakingChange() string {
+	for _, footer := range c.Footers {
+		if isBreakingToken(footer.Token) {
+			return footer.Value
+		}
+	}
+
+	if c.Breaking {
+		return c.Description
+	}
+
+	return ""
+}
+
+//nolint:gochecknoglobals
+var (
+	headerRegexp = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()\r\n]*)\))?(!)?: (\S.*)$`)
+	footerRegexp = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][\w-]*)(: | #)(.*)$`)
+)
+
+// ParseHeader parses the first line of a conventional commit.
+func ParseHeader(header string) (Commit, error) {
+	matches := headerRegexp.FindStringSubmatch(strings.TrimSpace(header))
+	if matches == nil {
+		return Commit{}, fmt.Errorf("%w: %q", ErrInvalidHeader, header)
+	}
+
+	return Commit{
+		Type:        strings.ToLower(matches[1]),
+		Scope:       strings.TrimSpace(matches[2]),
+		Breaking:    matches[3] == "!",
+		Description: strings.TrimSpace(matches[4]),
+	}, nil
+}
+
+// Parse parses a full conventional commit message. Lines starting with "#"
+// are treated as git comments and ignored.
+func Parse(message string) (Commit, error) {
+	lines := []string{}
+
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+
+	if len(lines) == 0 {
+		return Commit{}, fmt.Errorf("%w: empty message", ErrInvalidHeader)
+	}
+
+	commit, err := ParseHeader(lines[0])
+	if err != nil {
+		return Commit{}, err
+	}
+
+	body, footers := splitFooters(lines[1:])
+
+	commit.Body = strings.TrimSpace(strings.Join(body, "\n"))
+	commit.Footers = footers
+
+	for _, footer := range footers {
+		if isBreakingToken(footer.Token) {
+			commit.Breaking = true
+		}
+	}
+
+	return commit, nil
+}
+
+// Validate returns an error if the message is not a valid conventional
+// commit. If allowedTypes is not empty the type must be one of them.
+func Validate(message string, allowedTypes []string) error {
+	commit, err := Parse(message)
+	if err != nil {
+		return err
+	}
+
+	if len(allowedTypes) > 0 && !contains(allowedTypes, commit.Type) {
+		return fmt.Errorf("type %q is not one of %s", commit.Type, strings.Join(allowedTypes, ", "))
+	}
+
+	return nil
+}
+
+// splitFooters splits the lines after the header into body and footers. The
+// footers are the last paragraph, if every line in it is a footer or the
+// continuation of one.
+func splitFooters(lines []string) (body []string, footers []Footer) {
+	lastParagraph := len(lines)
+	for lastParagraph > 0 && lines[lastParagraph-1] == "" {
+		lastParagraph--
+	}
+
+	lines = lines[:lastParagraph]
+
+	start := len(lines)
+	for start > 0 && lines[start-1] != "" {
+		start--
+	}
+
+	paragraph := lines[start:]
+	if len(paragraph) == 0 || !footerRegexp.MatchString(paragraph[0]) {
+		return lines, nil
+	}
+
+	for _, line := range paragraph {
+		if matches := footerRegexp.FindStringSubmatch(line); matches != nil {
+			footers = append(footers, Footer{Token: matches[1], Separator: matches[2], Value: matches[3]})
+			continue
+		}
+
+		// Footer values may span several lines.
+		footers[len(footers)-1].Value += "\n" + line
+	}
+
+	return lines[:start], footers
+}
+
+func isBreakingToken(token string) bool {
+	return token == BreakingChangeToken || token == "BREAKING-CHANGE"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
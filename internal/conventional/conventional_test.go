@@ -0,0 +1,208 @@
+package conventional_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+func TestParse(t *testing.T) {
+	message := `feat(api)!: send an email to the customer
+
+Some body text
+spanning lines.
+
+Reviewed-by: Z
+Refs #133
+BREAKING CHANGE: the customer
+  must have an email`
+
+	commit, err := conventional.Parse(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if commit.Type != "feat" || commit.Scope != "api" || !commit.Breaking {
+		t.Errorf("got type %q scope %q breaking %t", commit.Type, commit.Scope, commit.Breaking)
+	}
+
+	if commit.Description != "send an email to the customer" {
+		t.Errorf("got description %q", commit.Description)
+	}
+
+	if commit.Body != "Some body text\nspanning lines." {
+		t.Errorf("got body %q", commit.Body)
+	}
+
+	if len(commit.Footers) != 3 {
+		t.Fatalf("got %d footers, want 3", len(commit.Footers))
+	}
+
+	if commit.BreakingChange() != "the customer\n  must have an email" {
+		t.Errorf("got breaking change %q", commit.BreakingChange())
+	}
+
+	if commit.String() != message {
+		t.Errorf("got %q, want %q", commit.String(), message)
+	}
+}
+
+func TestParseInvalidHeaderReturnsErr(t *testing.T) {
+	testCases := []struct {
+		message string
+	}{
+		{message: ""},
+		{message: "Add README.md"},
+		{message: "feat:missing space"},
+		{message: "feat(: unbalanced"},
+		{message: "feat: "},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			_, err := conventional.Parse(tc.message)
+			if !errors.Is(err, conventional.ErrInvalidHeader) {
+				t.Errorf("got %v, want ErrInvalidHeader", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := conventional.Validate("docs: add README", conventional.DefaultTypes()); err != nil {
+		t.Error(err)
+	}
+
+	if err := conventional.Validate("feature: add README", conventional.DefaultTypes()); err == nil {
+		t.Error("expected error for unknown type")
+	}
+}
+
+func TestInfer(t *testing.T) {
+	testCases := []struct {
+		files        []diff.File
+		expectedType string
+		scope        string
+	}{
+		{
+			files:        []diff.File{{NewPath: "README.md", OldPath: "README.md"}},
+			expectedType: conventional.Docs,
+		},
+		{
+			files:        []diff.File{{NewPath: "internal/openai/client_test.go"}, {NewPath: "internal/openai/testdata/x.json"}},
+			expectedType: conventional.Test,
+			scope:        "openai",
+		},
+		{
+			files:        []diff.File{{NewPath: "internal/changelog/testdata/notes.md"}, {NewPath: "internal/changelog/testdata/log.txt"}},
+			expectedType: conventional.Test,
+			scope:        "changelog",
+		},
+		{
+			files:        []diff.File{{NewPath: ".github/workflows/go.yml"}},
+			expectedType: conventional.CI,
+			scope:        "workflows",
+		},
+		{
+			files:        []diff.File{{NewPath: "go.mod"}, {NewPath: "go.sum"}},
+			expectedType: conventional.Build,
+		},
+		{
+			files:        []diff.File{{OldPath: "a.go", NewPath: "b.go", IsRename: true}},
+			expectedType: conventional.Refactor,
+		},
+		{
+			files: []diff.File{{
+				NewPath: "internal/commitassist/assist.go",
+				Hunks:   []diff.Hunk{{Lines: []diff.Line{{Kind: diff.Added, Content: "func New() {}"}}}},
+			}},
+			expectedType: conventional.Feat,
+			scope:        "commitassist",
+		},
+		{
+			files: []diff.File{{
+				NewPath: "cmd/cli/main.go",
+				Hunks: []diff.Hunk{{Lines: []diff.Line{
+					{Kind: diff.Removed, Content: "if x > len(a) {"},
+					{Kind: diff.Added, Content: "if x >= len(a) { // off-by-one"},
+				}}},
+			}},
+			expectedType: conventional.Fix,
+			scope:        "cli",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expectedType, func(t *testing.T) {
+			got := conventional.Infer(tc.files)
+
+			if got.Type != tc.expectedType {
+				t.Errorf("got type %q, want %q", got.Type, tc.expectedType)
+			}
+
+			if got.Scope != tc.scope {
+				t.Errorf("got scope %q, want %q", got.Scope, tc.scope)
+			}
+		})
+	}
+}
+
+func TestRulesRepair(t *testing.T) {
+	rules := conventional.Rules{
+		Types: []string{"feat", "fix", "deps"},
+		Scopes: []conventional.Scope{
+			{Name: "api", Paths: []string{"services/api/**"}},
+			{Name: "web", Paths: []string{"web/**"}},
+			{Name: "infra"},
+		},
+	}
+
+	files := []diff.File{{NewPath: "services/api/main.go"}, {NewPath: "web/index.ts"}, {NewPath: "deploy/infra/main.tf"}}
+
+	if got := rules.JoinScopes(rules.MatchScopes(files)); got != "api,web,infra" {
+		t.Errorf("got scopes %q, want %q", got, "api,web,infra")
+	}
+
+	testCases := []struct {
+		commit   conventional.Commit
+		expected string
+	}{
+		{
+			commit:   conventional.Commit{Type: "feat", Scope: "api, web", Description: "x"},
+			expected: "feat(api,web): x",
+		},
+		{
+			commit:   conventional.Commit{Type: "chore", Scope: "database", Description: "x"},
+			expected: "fix(api): x",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			got, err := rules.Repair(tc.commit, conventional.Inference{Type: "fix", Scope: "api"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Header() != tc.expected {
+				t.Errorf("got %q, want %q", got.Header(), tc.expected)
+			}
+
+			if err := rules.Check(got); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if _, err := rules.Repair(conventional.Commit{Type: "docs"}, conventional.Inference{Type: "docs"}); err == nil {
+		t.Error("expected error when neither type is allowed")
+	}
+}
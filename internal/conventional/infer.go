@@ -0,0 +1,207 @@
+package conventional
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+// Inference is the type and scope guessed from a diff.
+type Inference struct {
+	Type  string
+	Scope string
+
+	// Certain is true when the type was derived from the kind of files
+	// changed (e.g. only documentation), rather than from the content of the
+	// change. A certain type is used to correct the type suggested by the
+	// model.
+	Certain bool
+
+	// Reason is a short explanation of why the type was chosen.
+	Reason string
+}
+
+//nolint:gochecknoglobals
+var (
+	docExtensions = map[string]bool{".md": true, ".rst": true, ".txt": true, ".adoc": true}
+	docFiles      = map[string]bool{"LICENSE": true, "AUTHORS": true, "CODEOWNERS": true}
+	buildFiles    = map[string]bool{
+		"Makefile": true, "go.mod": true, "go.sum": true, "Dockerfile": true,
+		"package.json": true, "package-lock.json": true, "yarn.lock": true,
+		"Cargo.toml": true, "Cargo.lock": true, "requirements.txt": true,
+		"pyproject.toml": true, "build.gradle": true, "pom.xml": true,
+		".golangci.yml": true, ".goreleaser.yml": true,
+	}
+	ciFiles       = map[string]bool{".gitlab-ci.yml": true, ".travis.yml": true, "Jenkinsfile": true, "azure-pipelines.yml": true}
+	ciDirectories = []string{".github/workflows/", ".circleci/", ".buildkite/"}
+
+	// scopeSkipDirs are directories too generic to be used as a scope.
+	scopeSkipDirs = map[string]bool{"internal": true, "pkg": true, "cmd": true, "src": true, "lib": true, "testdata": true}
+
+	fixKeywordRegexp     = regexp.MustCompile(`(?i)\b(fix(es|ed)?|bug|panic|nil pointer|off[- ]by[- ]one|race|regression|crash)\b`)
+	goExportedDeclRegexp = regexp.MustCompile(`^func (\([^)]*\) )?[A-Z]|^type [A-Z]|^(const|var) [A-Z]`)
+)
+
+// Infer guesses the conventional commit type and scope from the changed
+// files.
+func Infer(files []diff.File) Inference {
+	if len(files) == 0 {
+		return Inference{Type: Chore, Reason: "no files changed"}
+	}
+
+	inference := inferType(files)
+	inference.Scope = InferScope(files)
+
+	return inference
+}
+
+//nolint:cyclop
+func inferType(files []diff.File) Inference {
+	switch {
+	// Test files are checked first, fixtures in testdata are often
+	// Markdown or text files.
+	case all(files, isTestFile):
+		return Inference{Type: Test, Certain: true, Reason: "only test files changed"}
+	case all(files, isDocFile):
+		return Inference{Type: Docs, Certain: true, Reason: "only documentation files changed"}
+	case all(files, isCIFile):
+		return Inference{Type: CI, Certain: true, Reason: "only CI configuration changed"}
+	case all(files, isBuildFile):
+		return Inference{Type: Build, Certain: true, Reason: "only build files changed"}
+	}
+
+	var (
+		added, removed int
+		newExported    bool
+		onlyRenames    = true
+		mentionsFix    bool
+		addsFiles      bool
+	)
+
+	for _, file := range files {
+		a, r := file.Stat()
+		added += a
+		removed += r
+
+		if !file.IsRename || a+r > 0 {
+			onlyRenames = false
+		}
+
+		if file.IsNew && !isTestFile(file) && !isDocFile(file) {
+			addsFiles = true
+		}
+
+		for _, line := range file.AddedLines() {
+			if goExportedDeclRegexp.MatchString(line) {
+				newExported = true
+			}
+
+			if fixKeywordRegexp.MatchString(line) {
+				mentionsFix = true
+			}
+		}
+	}
+
+	switch {
+	case onlyRenames:
+		return Inference{Type: Refactor, Reason: "files were only moved or renamed"}
+	case addsFiles || newExported:
+		return Inference{Type: Feat, Reason: "new files or exported declarations were added"}
+	case mentionsFix:
+		return Inference{Type: Fix, Reason: "the added lines mention a bug or fix"}
+	case added > 0 && removed > 0 && absDiff(added, removed)*4 <= added+removed:
+		return Inference{Type: Refactor, Reason: "about as many lines were removed as added"}
+	case added == 0 && removed > 0:
+		return Inference{Type: Refactor, Reason: "code was only removed"}
+	default:
+		return Inference{Type: Feat, Reason: "code was added"}
+	}
+}
+
+// InferScope returns the name of the deepest directory shared by all changed
+// files, skipping generic directory names such as "internal" or "cmd". An
+// empty string is returned if there is no common directory.
+func InferScope(files []diff.File) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	common := strings.Split(path.Dir(files[0].Path()), "/")
+
+	for _, file := range files[1:] {
+		dirs := strings.Split(path.Dir(file.Path()), "/")
+
+		n := 0
+		for n < len(common) && n < len(dirs) && common[n] == dirs[n] {
+			n++
+		}
+
+		common = common[:n]
+	}
+
+	for i := len(common) - 1; i >= 0; i-- {
+		if common[i] != "." && common[i] != "" && !scopeSkipDirs[common[i]] {
+			return common[i]
+		}
+	}
+
+	return ""
+}
+
+func isDocFile(file diff.File) bool {
+	p := file.Path()
+
+	return docExtensions[strings.ToLower(path.Ext(p))] && !buildFiles[path.Base(p)] ||
+		docFiles[path.Base(p)] ||
+		strings.HasPrefix(p, "docs/") || strings.Contains(p, "/docs/")
+}
+
+func isTestFile(file diff.File) bool {
+	p := file.Path()
+	base := path.Base(p)
+
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") ||
+		strings.Contains("/"+p, "/testdata/")
+}
+
+func isCIFile(file diff.File) bool {
+	p := file.Path()
+
+	if ciFiles[path.Base(p)] {
+		return true
+	}
+
+	for _, dir := range ciDirectories {
+		if strings.HasPrefix(p, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isBuildFile(file diff.File) bool {
+	return buildFiles[path.Base(file.Path())]
+}
+
+func all(files []diff.File, fn func(diff.File) bool) bool {
+	for _, file := range files {
+		if !fn(file) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func absDiff(a, b int) int {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
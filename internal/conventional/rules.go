@@ -0,0 +1,198 @@
+package conventional
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/glob"
+)
+
+// DefaultScopeDelimiter is used to join several scopes, e.g. "api,web".
+const DefaultScopeDelimiter = ","
+
+// Scope is a scope allowed by the repository.
+type Scope struct {
+	Name string `json:"name"`
+
+	// Paths are glob patterns (see package glob) of the files belonging to
+	// the scope. If empty, a file belongs to the scope if one of its
+	// directories has the same name as the scope.
+	Paths []string `json:"paths,omitempty"`
+}
+
+// Rules is the vocabulary a repository allows in its conventional commits.
+// The zero value allows the default types and any scope.
+type Rules struct {
+	// Types are the allowed commit types. DefaultTypes is used if empty.
+	Types []string `json:"types,omitempty"`
+
+	// Scopes are the allowed scopes. Any scope is allowed if empty.
+	Scopes []Scope `json:"scopes,omitempty"`
+
+	// ScopeDelimiter joins several scopes. DefaultScopeDelimiter is used if
+	// empty.
+	ScopeDelimiter string `json:"scopeDelimiter,omitempty"`
+}
+
+// AllowedTypes returns the types allowed by the rules.
+func (r Rules) AllowedTypes() []string {
+	if len(r.Types) == 0 {
+		return DefaultTypes()
+	}
+
+	return r.Types
+}
+
+// ScopeNames returns the names of the allowed scopes, or nil if any scope is
+// allowed.
+func (r Rules) ScopeNames() []string {
+	if len(r.Scopes) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(r.Scopes))
+	for _, scope := range r.Scopes {
+		names = append(names, scope.Name)
+	}
+
+	return names
+}
+
+// Delimiter returns the delimiter used to join several scopes.
+func (r Rules) Delimiter() string {
+	if r.ScopeDelimiter == "" {
+		return DefaultScopeDelimiter
+	}
+
+	return r.ScopeDelimiter
+}
+
+// MatchScopes returns the configured scopes touched by the files, in the order
+// the scopes are configured.
+func (r Rules) MatchScopes(files []diff.File) []string {
+	scopes := []string{}
+
+	for _, scope := range r.Scopes {
+		for _, file := range files {
+			if scope.matches(file.Path()) {
+				scopes = append(scopes, scope.Name)
+				break
+			}
+		}
+	}
+
+	return scopes
+}
+
+// JoinScopes joins scopes with the delimiter.
+func (r Rules) JoinScopes(scopes []string) string {
+	return strings.Join(scopes, r.Delimiter())
+}
+
+// SplitScope splits a scope that might consist of several scopes.
+func (r Rules) SplitScope(scope string) []string {
+	scopes := []string{}
+
+	for _, s := range strings.Split(scope, r.Delimiter()) {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
+
+// Infer is like the package level Infer, but constrains the result to the
+// vocabulary of the rules.
+func (r Rules) Infer(files []diff.File) Inference {
+	inference := Infer(files)
+
+	if len(r.Scopes) > 0 {
+		inference.Scope = r.JoinScopes(r.MatchScopes(files))
+	}
+
+	if !contains(r.AllowedTypes(), inference.Type) {
+		inference.Certain = false
+	}
+
+	return inference
+}
+
+// Check returns an error if the commit uses a type or scope not allowed by
+// the rules.
+func (r Rules) Check(commit Commit) error {
+	if !contains(r.AllowedTypes(), commit.Type) {
+		return fmt.Errorf("type %q is not one of %s", commit.Type, strings.Join(r.AllowedTypes(), ", "))
+	}
+
+	names := r.ScopeNames()
+	if names == nil {
+		return nil
+	}
+
+	for _, scope := range r.SplitScope(commit.Scope) {
+		if !contains(names, scope) {
+			return fmt.Errorf("scope %q is not one of %s", scope, strings.Join(names, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Repair replaces the type and scope of the commit with the inferred ones when
+// they are not allowed by the rules. An inferred type that is certain always
+// replaces the type. An error is returned if the commit cannot be repaired.
+func (r Rules) Repair(commit Commit, inference Inference) (Commit, error) {
+	allowedTypes := r.AllowedTypes()
+	inferredTypeAllowed := contains(allowedTypes, inference.Type)
+
+	if inference.Certain && inferredTypeAllowed {
+		commit.Type = inference.Type
+	}
+
+	if !contains(allowedTypes, commit.Type) {
+		if !inferredTypeAllowed {
+			return Commit{}, fmt.Errorf("type %q is not one of %s", commit.Type, strings.Join(allowedTypes, ", "))
+		}
+
+		commit.Type = inference.Type
+	}
+
+	scopes := r.SplitScope(commit.Scope)
+
+	if names := r.ScopeNames(); names != nil {
+		allowed := []string{}
+
+		for _, scope := range scopes {
+			if contains(names, scope) && !contains(allowed, scope) {
+				allowed = append(allowed, scope)
+			}
+		}
+
+		scopes = allowed
+	}
+
+	if len(scopes) == 0 {
+		commit.Scope = inference.Scope
+	} else {
+		commit.Scope = r.JoinScopes(scopes)
+	}
+
+	return commit, nil
+}
+
+func (s Scope) matches(p string) bool {
+	if len(s.Paths) > 0 {
+		return glob.MatchAny(s.Paths, p)
+	}
+
+	dirs := strings.Split(p, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if dir == s.Name {
+			return true
+		}
+	}
+
+	return false
+}
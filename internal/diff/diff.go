@@ -0,0 +1,362 @@
+/*
+Package diff parses the unified diff format produced by git (e.g. "git diff
+--staged" or the verbose section of a commit message file).
+
+Only the parts needed to reason about a change are kept: the paths, whether
+the file was added, deleted, renamed or is binary, and the hunks with their
+lines.
+*/
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// LineKind describes if a line in a hunk was added, removed or is context.
+type LineKind int
+
+const (
+	// Context is a line that is unchanged.
+	Context LineKind = iota
+	// Added is a line that was added.
+	Added
+	// Removed is a line that was removed.
+	Removed
+)
+
+// Line is a single line in a hunk.
+type Line struct {
+	Kind LineKind
+
+	// Content is the line without the leading '+', '-' or ' '.
+	Content string
+}
+
+// Hunk is a contiguous block of changes in a file.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+
+	// Section is the text after the closing "@@", which git fills with the
+	// enclosing function or section when it can find one.
+	Section string
+
+	Lines []Line
+}
+
+// File is the change made to a single file.
+type File struct {
+	// OldPath is the path before the change, empty if the file is new.
+	OldPath string
+
+	// NewPath is the path after the change, empty if the file was deleted.
+	NewPath string
+
+	IsNew     bool
+	IsDeleted bool
+	IsRename  bool
+	IsBinary  bool
+
+	Hunks []Hunk
+}
+
+// Path returns the path of the file after the change, or the path before the
+// change if the file was deleted.
+func (f File) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+
+	return f.OldPath
+}
+
+// Ext returns the file extension of Path, including the leading dot.
+func (f File) Ext() string {
+	return path.Ext(f.Path())
+}
+
+// AddedLines returns the content of all added lines in the file.
+func (f File) AddedLines() []string {
+	return f.lines(Added)
+}
+
+// RemovedLines returns the content of all removed lines in the file.
+func (f File) RemovedLines() []string {
+	return f.lines(Removed)
+}
+
+func (f File) lines(kind LineKind) []string {
+	lines := []string{}
+
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == kind {
+				lines = append(lines, line.Content)
+			}
+		}
+	}
+
+	return lines
+}
+
+// LineNumbers returns the line number of each line of the hunk in the new
+// file. A removed line has the number of the line following it.
+func (h Hunk) LineNumbers() []int {
+	numbers := make([]int, 0, len(h.Lines))
+	next := h.NewStart
+
+	// An empty new range starts at the line before it.
+	if h.NewLines == 0 {
+		next++
+	}
+
+	for _, line := range h.Lines {
+		numbers = append(numbers, next)
+
+		if line.Kind != Removed {
+			next++
+		}
+	}
+
+	return numbers
+}
+
+// Stat returns the number of added and removed lines in the file.
+func (f File) Stat() (added, removed int) {
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case Added:
+				added++
+			case Removed:
+				removed++
+			case Context:
+			}
+		}
+	}
+
+	return added, removed
+}
+
+const (
+	devNull = "/dev/null"
+
+	diffGitPrefix   = "diff --git "
+	oldFilePrefix   = "--- "
+	newFilePrefix   = "+++ "
+	hunkPrefix      = "@@ "
+	renameFrom      = "rename from "
+	renameTo        = "rename to "
+	newFileMode     = "new file mode"
+	deletedFileMode = "deleted file mode"
+	binaryFiles     = "Binary files "
+	gitBinaryPatch  = "GIT binary patch"
+	noNewlineMarker = `\ No newline at end of file`
+)
+
+// Parse parses a unified git diff. Text before the first "diff --git" line is
+// ignored, which makes it possible to pass the content of a verbose commit
+// message file directly.
+//
+//nolint:cyclop,funlen
+func Parse(gitDiff string) ([]File, error) {
+	files := []File{}
+
+	var (
+		current *File
+		hunk    *Hunk
+	)
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+
+		if hunk != nil {
+			current.Hunks = append(current.Hunks, *hunk)
+			hunk = nil
+		}
+
+		files = append(files, *current)
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(gitDiff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, diffGitPrefix) {
+			flush()
+
+			oldPath, newPath := parseDiffGitLine(line)
+			current = &File{OldPath: oldPath, NewPath: newPath}
+
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if hunk != nil {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				hunk.Lines = append(hunk.Lines, Line{Kind: Added, Content: line[1:]})
+				continue
+			case strings.HasPrefix(line, "-"):
+				hunk.Lines = append(hunk.Lines, Line{Kind: Removed, Content: line[1:]})
+				continue
+			case strings.HasPrefix(line, " "):
+				hunk.Lines = append(hunk.Lines, Line{Kind: Context, Content: line[1:]})
+				continue
+			case line == "":
+				hunk.Lines = append(hunk.Lines, Line{Kind: Context})
+				continue
+			case line == noNewlineMarker:
+				continue
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(line, hunkPrefix):
+			if hunk != nil {
+				current.Hunks = append(current.Hunks, *hunk)
+			}
+
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("file %q: %w", current.Path(), err)
+			}
+
+			hunk = &h
+		case strings.HasPrefix(line, newFileMode):
+			current.IsNew = true
+		case strings.HasPrefix(line, deletedFileMode):
+			current.IsDeleted = true
+		case strings.HasPrefix(line, renameFrom):
+			current.IsRename = true
+			current.OldPath = strings.TrimPrefix(line, renameFrom)
+		case strings.HasPrefix(line, renameTo):
+			current.IsRename = true
+			current.NewPath = strings.TrimPrefix(line, renameTo)
+		case strings.HasPrefix(line, binaryFiles), line == gitBinaryPatch:
+			current.IsBinary = true
+		case strings.HasPrefix(line, oldFilePrefix):
+			if p := trimPathPrefix(strings.TrimPrefix(line, oldFilePrefix), "a/"); p == devNull {
+				current.IsNew = true
+			} else {
+				current.OldPath = p
+			}
+		case strings.HasPrefix(line, newFilePrefix):
+			if p := trimPathPrefix(strings.TrimPrefix(line, newFilePrefix), "b/"); p == devNull {
+				current.IsDeleted = true
+			} else {
+				current.NewPath = p
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan diff: %w", err)
+	}
+
+	flush()
+
+	for i := range files {
+		if files[i].IsNew {
+			files[i].OldPath = ""
+		}
+
+		if files[i].IsDeleted {
+			files[i].NewPath = ""
+		}
+	}
+
+	return files, nil
+}
+
+// parseDiffGitLine parses "diff --git a/x b/x". Paths containing " b/" are
+// ambiguous in this line, they are corrected by the "---" and "+++" lines.
+func parseDiffGitLine(line string) (oldPath, newPath string) {
+	rest := strings.TrimPrefix(line, diffGitPrefix)
+
+	idx := strings.Index(rest, " b/")
+	if idx < 0 {
+		return rest, rest
+	}
+
+	return strings.TrimPrefix(rest[:idx], "a/"), rest[idx+len(" b/"):]
+}
+
+func trimPathPrefix(p, prefix string) string {
+	// git appends a tab to paths containing spaces.
+	p = strings.TrimSuffix(p, "\t")
+
+	if p == devNull {
+		return p
+	}
+
+	return strings.TrimPrefix(p, prefix)
+}
+
+// parseHunkHeader parses "@@ -l,s +l,s @@ section".
+func parseHunkHeader(line string) (Hunk, error) {
+	rest := strings.TrimPrefix(line, hunkPrefix)
+
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q", line)
+	}
+
+	ranges := strings.Fields(rest[:end])
+	if len(ranges) != 2 {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q", line)
+	}
+
+	oldStart, oldLines, err := parseRange(strings.TrimPrefix(ranges[0], "-"))
+	if err != nil {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+
+	newStart, newLines, err := parseRange(strings.TrimPrefix(ranges[1], "+"))
+	if err != nil {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+
+	return Hunk{
+		OldStart: oldStart,
+		OldLines: oldLines,
+		NewStart: newStart,
+		NewLines: newLines,
+		Section:  strings.TrimSpace(rest[end+len(" @@"):]),
+	}, nil
+}
+
+// parseRange parses "l,s" or "l" where s defaults to 1.
+func parseRange(r string) (start, lines int, err error) {
+	startStr, linesStr, found := strings.Cut(r, ",")
+
+	start, err = strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not parse start: %w", err)
+	}
+
+	if !found {
+		return start, 1, nil
+	}
+
+	lines, err = strconv.Atoi(linesStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not parse number of lines: %w", err)
+	}
+
+	return start, lines, nil
+}
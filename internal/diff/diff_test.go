@@ -0,0 +1,99 @@
+package diff_test
+
+import (
+	"embed"
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+func TestParse(t *testing.T) {
+	content, err := testdata.ReadFile("testdata/staged.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := diff.Parse("# Please enter the commit message\n" + string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path      string
+		isNew     bool
+		isDeleted bool
+		isRename  bool
+		isBinary  bool
+		added     int
+		removed   int
+	}{
+		{path: "README.md", added: 2, removed: 1},
+		{path: "internal/openai/client.go", isNew: true, added: 3},
+		{path: "old.txt", isDeleted: true, removed: 1},
+		{path: "b.go", isRename: true},
+		{path: "logo.png", isNew: true, isBinary: true},
+	}
+
+	if len(files) != len(testCases) {
+		t.Fatalf("got %d files, want %d", len(files), len(testCases))
+	}
+
+	for i, tc := range testCases {
+		file := files[i]
+
+		if file.Path() != tc.path {
+			t.Errorf("file %d: got path %q, want %q", i, file.Path(), tc.path)
+		}
+
+		if file.IsNew != tc.isNew || file.IsDeleted != tc.isDeleted || file.IsRename != tc.isRename || file.IsBinary != tc.isBinary {
+			t.Errorf("file %q: got new=%t deleted=%t rename=%t binary=%t", file.Path(), file.IsNew, file.IsDeleted, file.IsRename, file.IsBinary)
+		}
+
+		added, removed := file.Stat()
+		if added != tc.added || removed != tc.removed {
+			t.Errorf("file %q: got +%d -%d, want +%d -%d", file.Path(), added, removed, tc.added, tc.removed)
+		}
+	}
+
+	if files[0].Hunks[0].Section != "# Commit Message" {
+		t.Errorf("got section %q", files[0].Hunks[0].Section)
+	}
+
+	if files[3].OldPath != "a.go" {
+		t.Errorf("got old path %q, want %q", files[3].OldPath, "a.go")
+	}
+}
+
+func TestParseInvalidHunkHeaderReturnsErr(t *testing.T) {
+	_, err := diff.Parse("diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -a,1 +1,1 @@\n")
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestLineNumbers(t *testing.T) {
+	files, err := diff.Parse(`diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -10,3 +10,3 @@ func A() {
+ 	a := 1
+-	b := 2
++	b := 3
+ 	return a + b
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := files[0].Hunks[0].LineNumbers()
+	expected := []int{10, 11, 11, 12}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Unit is a part of a diff that can be staged on its own with "git apply
+// --cached": a hunk with the header of its file, or a whole file without
+// hunks, e.g. a binary file or a rename.
+type Unit struct {
+	// Header are the lines of the file from "diff --git" up to the first
+	// hunk.
+	Header string
+
+	// Hunk is the hunk, starting with the "@@" line. It is empty for a file
+	// without hunks, whose changes are all in the Header.
+	Hunk string
+}
+
+// Patch returns the unit as a diff that can be applied.
+func (u Unit) Patch() string {
+	return u.Header + u.Hunk
+}
+
+// File returns the parsed unit, a file with at most one hunk.
+func (u Unit) File() File {
+	files, err := Parse(u.Patch())
+	if err != nil || len(files) == 0 {
+		oldPath, newPath := parseDiffGitLine(strings.SplitN(u.Header, "\n", 2)[0])
+		return File{OldPath: oldPath, NewPath: newPath}
+	}
+
+	return files[0]
+}
+
+// SplitUnits splits a git diff into units, in the order of the diff. Text
+// before the first "diff --git" line is ignored.
+func SplitUnits(gitDiff string) []Unit {
+	units := []Unit{}
+
+	var (
+		header  []string
+		hunk    []string
+		inFile  bool
+		hasHunk bool
+	)
+
+	flushHunk := func() {
+		if hunk == nil {
+			return
+		}
+
+		for len(hunk) > 1 && hunk[len(hunk)-1] == "" {
+			hunk = hunk[:len(hunk)-1]
+		}
+
+		units = append(units, Unit{Header: joinLines(header), Hunk: joinLines(hunk)})
+		hunk = nil
+	}
+
+	flushFile := func() {
+		flushHunk()
+
+		if inFile && !hasHunk {
+			for len(header) > 1 && header[len(header)-1] == "" {
+				header = header[:len(header)-1]
+			}
+
+			units = append(units, Unit{Header: joinLines(header)})
+		}
+
+		header = nil
+		hasHunk = false
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(gitDiff, "\r\n", "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, diffGitPrefix):
+			flushFile()
+
+			inFile = true
+			header = []string{line}
+		case !inFile:
+			continue
+		case strings.HasPrefix(line, hunkPrefix):
+			flushHunk()
+
+			hasHunk = true
+			hunk = []string{line}
+		case hunk != nil:
+			hunk = append(hunk, line)
+		default:
+			header = append(header, line)
+		}
+	}
+
+	flushFile()
+
+	return units
+}
+
+// JoinUnits returns the units as one diff, where the hunks of the same file
+// share its header. The files are in the order they first appear in units,
+// and the hunks of a file must be in the order of the diff they were split
+// from.
+func JoinUnits(units []Unit) string {
+	headers := []string{}
+	hunks := map[string][]string{}
+
+	for _, unit := range units {
+		if _, ok := hunks[unit.Header]; !ok {
+			headers = append(headers, unit.Header)
+		}
+
+		hunks[unit.Header] = append(hunks[unit.Header], unit.Hunk)
+	}
+
+	var b strings.Builder
+
+	for _, header := range headers {
+		b.WriteString(header)
+
+		for _, hunk := range hunks[header] {
+			b.WriteString(hunk)
+		}
+	}
+
+	return b.String()
+}
+
+func joinLines(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
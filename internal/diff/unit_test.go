@@ -0,0 +1,71 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+const unitsDiff = `# Please enter the commit message for your changes.
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@ package main
+ import "fmt"
++import "os"
+ 
+ func main() {
+@@ -10,2 +11,3 @@ func main() {
+ 	fmt.Println("hello")
++	os.Exit(0)
+ }
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..3333333
+Binary files /dev/null and b/logo.png differ
+diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+`
+
+func TestSplitUnits(t *testing.T) {
+	units := diff.SplitUnits(unitsDiff)
+
+	if len(units) != 4 {
+		t.Fatalf("got %d units, want 4", len(units))
+	}
+
+	paths := []string{"main.go", "main.go", "logo.png", "new.go"}
+	for i, unit := range units {
+		if got := unit.File().Path(); got != paths[i] {
+			t.Errorf("got path %q of unit %d, want %q", got, i, paths[i])
+		}
+	}
+
+	if !strings.HasPrefix(units[1].Hunk, "@@ -10,2 +11,3 @@") || !strings.HasPrefix(units[1].Header, "diff --git a/main.go") {
+		t.Errorf("unexpected unit %+v", units[1])
+	}
+
+	if units[2].Hunk != "" || !units[2].File().IsBinary {
+		t.Errorf("unexpected binary unit %+v", units[2])
+	}
+
+	if !units[3].File().IsRename {
+		t.Errorf("unexpected rename unit %+v", units[3])
+	}
+}
+
+func TestJoinUnits(t *testing.T) {
+	units := diff.SplitUnits(unitsDiff)
+
+	joined := diff.JoinUnits([]diff.Unit{units[0], units[2], units[1], units[3]})
+	expected := unitsDiff[strings.Index(unitsDiff, "diff --git"):]
+
+	// The hunks of main.go share the header, and the files keep the order.
+	if joined != expected {
+		t.Errorf("got\n%s\nwant\n%s", joined, expected)
+	}
+}
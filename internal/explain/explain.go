@@ -0,0 +1,179 @@
+/*
+Package explain describes a diff for code review: what changed in each file,
+why it might matter, and the risks a reviewer should look at, e.g. in
+concurrent code, error handling or security-sensitive paths.
+
+Callouts finds such risks in a diff without a model. They are given to the
+model as hints, and are the risks of the explanation when no model is used.
+*/
+package explain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+// Category is the kind of a risk.
+type Category string
+
+const (
+	Concurrency   Category = "concurrency"
+	ErrorHandling Category = "error-handling"
+	Security      Category = "security"
+	Other         Category = "other"
+)
+
+// Categories are the categories of risks, in the order they are rendered.
+//
+//nolint:gochecknoglobals
+var Categories = []Category{Concurrency, ErrorHandling, Security, Other}
+
+// ParseCategory returns the category, Other if it is unknown.
+func ParseCategory(s string) Category {
+	for _, category := range Categories {
+		if string(category) == s {
+			return category
+		}
+	}
+
+	return Other
+}
+
+// Risk is something a reviewer should look at.
+type Risk struct {
+	Category    Category `json:"category"`
+	Description string   `json:"description"`
+
+	// Line is the line in the new file, zero if the risk is about the whole
+	// file.
+	Line int `json:"line,omitempty"`
+}
+
+// File is the explanation of the changes to a file.
+type File struct {
+	Path string `json:"path"`
+
+	// Change describes what changed.
+	Change string `json:"change"`
+
+	// Impact describes why the change might matter.
+	Impact string `json:"impact"`
+
+	Risks []Risk `json:"risks"`
+}
+
+// Explanation is the explanation of a diff.
+type Explanation struct {
+	// Summary describes the diff as a whole.
+	Summary string `json:"summary"`
+
+	// Files are in the order of the diff.
+	Files []File `json:"files"`
+}
+
+// Markdown renders the explanation with a section per file.
+func (e Explanation) Markdown() string {
+	var b strings.Builder
+
+	if summary := strings.TrimSpace(e.Summary); summary != "" {
+		fmt.Fprintf(&b, "## Summary\n\n%s\n", summary)
+	}
+
+	for _, file := range e.Files {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(&b, "## `%s`\n", file.Path)
+
+		if change := strings.TrimSpace(file.Change); change != "" {
+			fmt.Fprintf(&b, "\n%s\n", change)
+		}
+
+		if impact := strings.TrimSpace(file.Impact); impact != "" {
+			fmt.Fprintf(&b, "\n**Why it matters:** %s\n", impact)
+		}
+
+		if len(file.Risks) > 0 {
+			b.WriteString("\n**Risks:**\n\n")
+
+			for _, risk := range file.Risks {
+				location := ""
+				if risk.Line > 0 {
+					location = fmt.Sprintf(" (line %d)", risk.Line)
+				}
+
+				fmt.Fprintf(&b, "- %s%s: %s\n", risk.Category, location, strings.TrimSpace(risk.Description))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+//nolint:gochecknoglobals
+var (
+	// securityPathRegexp matches paths that are often security-sensitive.
+	securityPathRegexp = regexp.MustCompile(`(?i)(auth|login|passw|secret|token|credential|crypto|session|permission|acl|oauth|jwt|cert|\.env$|\.pem$|sudoers|policy)`)
+
+	lineCallouts = []struct {
+		category    Category
+		kind        diff.LineKind
+		regexp      *regexp.Regexp
+		description string
+	}{
+		{
+			category:    Concurrency,
+			kind:        diff.Added,
+			regexp:      regexp.MustCompile(`\bgo\s+(func\b|[\w.]+\()|\bsync\.|\batomic\.|\bchan\b|<-|\bMutex\b|\bWaitGroup\b|\bsynchronized\b|\bThread\(|\basync\s|\bawait\s`),
+			description: "adds concurrent code, check for races and leaked goroutines or threads",
+		},
+		{
+			category:    ErrorHandling,
+			kind:        diff.Added,
+			regexp:      regexp.MustCompile(`^\s*_\s*(,\s*_\s*)?=|\bpanic\(|\brecover\(\)|except\s*:|catch\s*\([^)]*\)\s*\{\s*\}`),
+			description: "ignores, swallows or panics on an error",
+		},
+		{
+			category:    ErrorHandling,
+			kind:        diff.Removed,
+			regexp:      regexp.MustCompile(`\berr\s*!=\s*nil\b|\breturn\s+.*\berr\b|\bthrow\b|\braise\b`),
+			description: "removes error handling",
+		},
+		{
+			category:    Security,
+			kind:        diff.Added,
+			regexp:      regexp.MustCompile(`(?i)\b(exec\.Command|os/exec|eval\(|innerHTML|InsecureSkipVerify|md5|sha1\b|math/rand|Sprintf\("(SELECT|INSERT|UPDATE|DELETE)\b)`),
+			description: "uses an API that is often misused, e.g. for injection or weak cryptography",
+		},
+	}
+)
+
+// Callouts returns the risks found in the file without a model, at most one
+// of each kind, at the first line where it is found.
+func Callouts(file diff.File) []Risk {
+	risks := []Risk{}
+
+	if securityPathRegexp.MatchString(file.Path()) {
+		risks = append(risks, Risk{Category: Security, Description: "changes a security-sensitive path"})
+	}
+
+	for _, callout := range lineCallouts {
+	hunks:
+		for _, hunk := range file.Hunks {
+			numbers := hunk.LineNumbers()
+
+			for i, line := range hunk.Lines {
+				if line.Kind == callout.kind && callout.regexp.MatchString(line.Content) {
+					risks = append(risks, Risk{Category: callout.category, Description: callout.description, Line: numbers[i]})
+					break hunks
+				}
+			}
+		}
+	}
+
+	return risks
+}
@@ -0,0 +1,104 @@
+package explain_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/explain"
+)
+
+func TestCallouts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		diff     string
+		expected []explain.Risk
+	}{
+		{
+			name: "concurrency and swallowed error",
+			diff: `diff --git a/worker.go b/worker.go
+index 1111111..2222222 100644
+--- a/worker.go
++++ b/worker.go
+@@ -5,2 +5,4 @@ func Run() {
+ 	start()
++	go func() { work() }()
++	_ = stop()
+ }
+`,
+			expected: []explain.Risk{
+				{Category: explain.Concurrency, Description: "adds concurrent code, check for races and leaked goroutines or threads", Line: 6},
+				{Category: explain.ErrorHandling, Description: "ignores, swallows or panics on an error", Line: 7},
+			},
+		},
+		{
+			name: "removed error handling in a security-sensitive path",
+			diff: `diff --git a/internal/auth/login.go b/internal/auth/login.go
+index 1111111..2222222 100644
+--- a/internal/auth/login.go
++++ b/internal/auth/login.go
+@@ -10,4 +10,2 @@ func Login() error {
+ 	err := check()
+-	if err != nil {
+-		return err
+-	}
+ 	return nil
+`,
+			expected: []explain.Risk{
+				{Category: explain.Security, Description: "changes a security-sensitive path"},
+				{Category: explain.ErrorHandling, Description: "removes error handling", Line: 11},
+			},
+		},
+		{
+			name: "documentation",
+			diff: `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-# Commit
++# Commit Message
+`,
+			expected: []explain.Risk{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := diff.Parse(tc.diff)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := explain.Callouts(files[0]); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %+v, want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	explanation := explain.Explanation{
+		Summary: "Runs the work in the background.",
+		Files: []explain.File{
+			{
+				Path:   "worker.go",
+				Change: "Starts the work in a goroutine.",
+				Impact: "Run returns before the work is done.",
+				Risks:  []explain.Risk{{Category: explain.Concurrency, Description: "the goroutine is never stopped", Line: 6}},
+			},
+			{Path: "README.md", Change: "Renames the title."},
+		},
+	}
+
+	expected := "## Summary\n\nRuns the work in the background.\n\n" +
+		"## `worker.go`\n\nStarts the work in a goroutine.\n\n**Why it matters:** Run returns before the work is done.\n\n" +
+		"**Risks:**\n\n- concurrency (line 6): the goroutine is never stopped\n\n" +
+		"## `README.md`\n\nRenames the title.\n"
+
+	if got := explanation.Markdown(); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
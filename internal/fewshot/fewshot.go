@@ -0,0 +1,212 @@
+/*
+Package fewshot selects commits from the history of the repository to be used
+as examples in the prompt, so that the suggested messages follow the style of
+the repository.
+
+Recent commits are ranked by how many files they have in common with the
+staged change, and the best ones are used as long as they fit in the token
+budget. Pinned commits are always used first and excluded commits never.
+*/
+package fewshot
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+const (
+	// DefaultCandidates is the number of recent commits considered.
+	DefaultCandidates = 100
+
+	// DefaultExamples is the maximum number of examples.
+	DefaultExamples = 3
+
+	// DefaultTokenBudget is the maximum number of tokens used by the
+	// examples.
+	DefaultTokenBudget = 2000
+)
+
+// Config decides how examples are selected.
+type Config struct {
+	// Author only considers commits by a matching author, e.g.
+	// "jane@example.com".
+	Author string `json:"author,omitempty"`
+
+	// Paths only considers commits changing one of the paths.
+	Paths []string `json:"paths,omitempty"`
+
+	// Candidates is the number of recent commits considered.
+	// DefaultCandidates is used if zero.
+	Candidates int `json:"candidates,omitempty"`
+
+	// Examples is the maximum number of examples. DefaultExamples is used
+	// if zero.
+	Examples int `json:"examples,omitempty"`
+
+	// TokenBudget is the maximum number of tokens used by the examples.
+	// DefaultTokenBudget is used if zero.
+	TokenBudget int `json:"tokenBudget,omitempty"`
+
+	// Pin are commits (hashes or other revisions) that are always used as
+	// examples, in order.
+	Pin []string `json:"pin,omitempty"`
+
+	// Exclude are commit hashes (or prefixes of them) never used as
+	// examples.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Example is a commit to use as example.
+type Example struct {
+	Hash    string
+	Diff    string
+	Message string
+}
+
+// History gives access to the commits of the repository, see package git.
+type History interface {
+	Log(ctx context.Context, opts git.LogOptions) ([]git.Commit, error)
+	Show(ctx context.Context, rev string) (string, error)
+}
+
+// Select returns the examples for a change of the staged files.
+func Select(ctx context.Context, history History, staged []string, cfg Config) ([]Example, error) {
+	maxExamples := valueOrDefault(cfg.Examples, DefaultExamples)
+	budget := valueOrDefault(cfg.TokenBudget, DefaultTokenBudget)
+
+	pinned := []git.Commit{}
+
+	for _, rev := range cfg.Pin {
+		commits, err := history.Log(ctx, git.LogOptions{Revision: rev, MaxCount: 1})
+		if err != nil {
+			return nil, fmt.Errorf("could not get pinned commit %q: %w", rev, err)
+		}
+
+		pinned = append(pinned, commits...)
+	}
+
+	candidates, err := history.Log(ctx, git.LogOptions{
+		MaxCount: valueOrDefault(cfg.Candidates, DefaultCandidates),
+		Author:   cfg.Author,
+		Paths:    cfg.Paths,
+		NoMerges: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get recent commits: %w", err)
+	}
+
+	ranked := append(pinned, Rank(candidates, staged, cfg.Exclude)...)
+
+	examples := []Example{}
+	seen := map[string]bool{}
+	perExample := budget / maxExamples
+
+	for _, commit := range ranked {
+		if len(examples) == maxExamples || budget <= 0 {
+			break
+		}
+
+		if seen[commit.Hash] || commit.Message == "" {
+			continue
+		}
+
+		seen[commit.Hash] = true
+
+		diff, err := history.Show(ctx, commit.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("could not get diff of %q: %w", commit.Hash, err)
+		}
+
+		diff = tokens.Truncate(diff, perExample-tokens.Estimate(commit.Message))
+
+		cost := tokens.Estimate(diff) + tokens.Estimate(commit.Message)
+		if cost > budget || strings.TrimSpace(diff) == "" {
+			continue
+		}
+
+		budget -= cost
+		examples = append(examples, Example{Hash: commit.Hash, Diff: diff, Message: commit.Message})
+	}
+
+	return examples, nil
+}
+
+// Rank sorts the commits by how relevant they are to a change of the staged
+// files, most relevant first. Commits with the same relevance keep their
+// order. Commits matching one of the excluded hash prefixes are removed.
+func Rank(commits []git.Commit, staged []string, exclude []string) []git.Commit {
+	ranked := []git.Commit{}
+
+	for _, commit := range commits {
+		if !isExcluded(commit.Hash, exclude) {
+			ranked = append(ranked, commit)
+		}
+	}
+
+	scores := make(map[string]float64, len(ranked))
+	for _, commit := range ranked {
+		scores[commit.Hash] = overlap(commit.Files, staged)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].Hash] > scores[ranked[j].Hash]
+	})
+
+	return ranked
+}
+
+// overlap returns the similarity between two sets of paths. A shared file
+// counts as one, a file in a shared directory as a half. The sum is divided
+// by the number of distinct paths.
+func overlap(files, staged []string) float64 {
+	if len(files) == 0 || len(staged) == 0 {
+		return 0
+	}
+
+	stagedFiles := map[string]bool{}
+	stagedDirs := map[string]bool{}
+
+	for _, file := range staged {
+		stagedFiles[file] = true
+		stagedDirs[path.Dir(file)] = true
+	}
+
+	score := 0.0
+	shared := 0
+
+	for _, file := range files {
+		switch {
+		case stagedFiles[file]:
+			score++
+			shared++
+		case stagedDirs[path.Dir(file)]:
+			score += 0.5
+		}
+	}
+
+	return score / float64(len(files)+len(staged)-shared)
+}
+
+func isExcluded(hash string, exclude []string) bool {
+	for _, prefix := range exclude {
+		if prefix != "" && strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func valueOrDefault(value, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
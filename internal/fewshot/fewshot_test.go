@@ -0,0 +1,101 @@
+package fewshot_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/fewshot"
+	"github.com/philiplinell/commit-msg/internal/git"
+)
+
+type fakeHistory struct {
+	commits []git.Commit
+}
+
+func (f fakeHistory) Log(_ context.Context, opts git.LogOptions) ([]git.Commit, error) {
+	if opts.Revision == "" {
+		return f.commits, nil
+	}
+
+	for _, commit := range f.commits {
+		if strings.HasPrefix(commit.Hash, opts.Revision) {
+			return []git.Commit{commit}, nil
+		}
+	}
+
+	return []git.Commit{}, nil
+}
+
+func (f fakeHistory) Show(_ context.Context, rev string) (string, error) {
+	return "diff --git a/" + rev + " b/" + rev + "\n" + strings.Repeat("+line\n", 50), nil
+}
+
+func TestRank(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "aaa", Files: []string{"README.md"}},
+		{Hash: "bbb", Files: []string{"internal/openai/client.go", "internal/openai/client_test.go"}},
+		{Hash: "ccc", Files: []string{"internal/openai/other.go"}},
+		{Hash: "ddd", Files: []string{"internal/openai/client.go"}},
+	}
+
+	ranked := fewshot.Rank(commits, []string{"internal/openai/client.go"}, []string{"dd"})
+
+	got := []string{}
+	for _, commit := range ranked {
+		got = append(got, commit.Hash)
+	}
+
+	expected := []string{"bbb", "ccc", "aaa"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	history := fakeHistory{commits: []git.Commit{
+		{Hash: "aaa", Message: "Update README", Files: []string{"README.md"}},
+		{Hash: "bbb", Message: "Add client", Files: []string{"client.go"}},
+		{Hash: "ccc", Message: "Fix client", Files: []string{"client.go"}},
+		{Hash: "ddd", Message: "", Files: []string{"client.go"}},
+	}}
+
+	cfg := fewshot.Config{Examples: 2, TokenBudget: 1000, Pin: []string{"aaa"}}
+
+	examples, err := fewshot.Select(context.Background(), history, []string{"client.go"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, example := range examples {
+		got = append(got, example.Hash)
+	}
+
+	expected := []string{"aaa", "bbb"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestSelectRespectsTokenBudget(t *testing.T) {
+	history := fakeHistory{commits: []git.Commit{
+		{Hash: "aaa", Message: "Add client", Files: []string{"client.go"}},
+		{Hash: "bbb", Message: "Fix client", Files: []string{"client.go"}},
+	}}
+
+	examples, err := fewshot.Select(context.Background(), history, []string{"client.go"}, fewshot.Config{Examples: 2, TokenBudget: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, example := range examples {
+		total += len(example.Diff) + len(example.Message)
+	}
+
+	if total > 60*4 {
+		t.Errorf("examples use %d characters, want at most %d", total, 60*4)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Package gemini is a client of the Gemini generateContent REST API, see
+https://ai.google.dev/api/generate-content.
+
+The system prompt is the systemInstruction of the request, and the contents
+alternate between the "user" and the "model" roles. The same API is served
+by Vertex AI, with another base URL and a bearer token, see NewClient.
+*/
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultBaseURL is the base URL of the Gemini API.
+const DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Auth is how the client authenticates.
+type Auth string
+
+const (
+	// APIKeyAuth sends the credential in the x-goog-api-key header. It is
+	// the default.
+	APIKeyAuth Auth = "api-key"
+
+	// BearerAuth sends the credential as a bearer token in the Authorization
+	// header, e.g. an OAuth access token for Vertex AI.
+	BearerAuth Auth = "bearer"
+)
+
+// Validate returns an error if the auth scheme is unknown.
+func (a Auth) Validate() error {
+	switch a {
+	case "", APIKeyAuth, BearerAuth:
+		return nil
+	default:
+		return fmt.Errorf("invalid auth %q, must be %q or %q", a, APIKeyAuth, BearerAuth)
+	}
+}
+
+// Client is the Gemini API client.
+type Client struct {
+	httpClient Doer
+	credential string
+	baseURL    string
+	auth       Auth
+}
+
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewClient creates a new Gemini API client. The request of a model is sent
+// to baseURL + "/models/<model>:generateContent", where baseURL is
+// DefaultBaseURL if empty. For Vertex AI the base URL is e.g.
+// "https://europe-west1-aiplatform.googleapis.com/v1/projects/<project>/locations/europe-west1/publishers/google"
+// with BearerAuth. auth is APIKeyAuth if empty.
+func NewClient(httpClient Doer, credential, baseURL string, auth Auth) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	if auth == "" {
+		auth = APIKeyAuth
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		credential: credential,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		auth:       auth,
+	}
+}
+
+type aiModel string
+
+const (
+	// Gemini2_0Flash - A fast and cheap model supporting structured output.
+	Gemini2_0Flash aiModel = "gemini-2.0-flash"
+)
+
+// Cost returns the cost in dollars of a request with the prompt and
+// candidate tokens, see UsageMetadata.
+func (m aiModel) Cost(promptTokens, candidatesTokens int) float64 {
+	if promptTokens < 0 || candidatesTokens < 0 {
+		return 0.0
+	}
+
+	switch m {
+	case Gemini2_0Flash:
+		// $0.10 / 1M input tokens and $0.40 / 1M output tokens.
+		return (float64(promptTokens)*0.1 + float64(candidatesTokens)*0.4) / 1000000
+	default:
+		return 0.0
+	}
+}
+
+// Role is the role of a content, the contents alternate between UserRole and
+// ModelRole.
+type Role string
+
+const (
+	UserRole  Role = "user"
+	ModelRole Role = "model"
+)
+
+// Part is a part of a content.
+type Part struct {
+	Text string `json:"text"`
+}
+
+// Content is a turn of the conversation. The role is empty for the system
+// instruction.
+type Content struct {
+	Role  Role   `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+// TextContent returns a content with a text part.
+func TextContent(role Role, text string) Content {
+	return Content{Role: role, Parts: []Part{{Text: text}}}
+}
+
+// GenerateContentResponse is the answer of the model.
+type GenerateContentResponse struct {
+	Model aiModel
+
+	// Cost is the cost for the request in dollars.
+	Cost float64
+
+	// Text is the text of the first candidate.
+	Text string
+
+	// FinishReason is why the model stopped generating the first
+	// candidate, e.g. "STOP", "MAX_TOKENS" or "SAFETY".
+	FinishReason string
+
+	// BlockReason is set if the prompt was blocked, e.g. "SAFETY". There are
+	// no candidates then.
+	BlockReason string
+
+	// BlockedCategories are the harm categories the prompt or the first
+	// candidate was blocked for, e.g. "HARM_CATEGORY_DANGEROUS_CONTENT".
+	BlockedCategories []string
+}
+
+// GenerateContentWithSchema does a generateContent request where the answer
+// is JSON following the schema.
+//
+// temperature must be a value between 0 and 1 (inclusive), lower is more
+// deterministic.
+func (c *Client) GenerateContentWithSchema(ctx context.Context, system string, contents []Content, model aiModel, temperature float32, schema json.RawMessage) (GenerateContentResponse, error) {
+	requestBody := generateContentRequest{
+		Contents: contents,
+		GenerationConfig: generationConfig{
+			Temperature:        temperature,
+			ResponseMimeType:   "application/json",
+			ResponseJSONSchema: schema,
+		},
+	}
+
+	if system != "" {
+		requestBody.SystemInstruction = &Content{Parts: []Part{{Text: system}}}
+	}
+
+	return c.generateContent(ctx, model, requestBody)
+}
+
+func (c *Client) generateContent(ctx context.Context, model aiModel, requestBody generateContentRequest) (GenerateContentResponse, error) {
+	if requestBody.GenerationConfig.Temperature < 0 || requestBody.GenerationConfig.Temperature > 1 {
+		return GenerateContentResponse{}, fmt.Errorf("temperature must be between 0 and 1 (inclusive), got %f", requestBody.GenerationConfig.Temperature)
+	}
+
+	requestBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return GenerateContentResponse{}, fmt.Errorf("could not marshal body: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, model)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(requestBytes))
+	if err != nil {
+		return GenerateContentResponse{}, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	switch c.auth {
+	case BearerAuth:
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.credential))
+	default:
+		req.Header.Set("x-goog-api-key", c.credential)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return GenerateContentResponse{}, fmt.Errorf("could not do request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResponse rawErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResponse); err == nil && errResponse.Error.Message != "" {
+			return GenerateContentResponse{}, fmt.Errorf("got status code %q, expected %d: %s", resp.Status, http.StatusOK, errResponse.Error.Message)
+		}
+
+		return GenerateContentResponse{}, fmt.Errorf("got status code %q, expected %d", resp.Status, http.StatusOK)
+	}
+
+	var gResponse rawGenerateContentResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&gResponse)
+	if err != nil {
+		return GenerateContentResponse{}, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	response := GenerateContentResponse{
+		Model:       model,
+		Cost:        model.Cost(gResponse.UsageMetadata.PromptTokenCount, gResponse.UsageMetadata.CandidatesTokenCount),
+		BlockReason: gResponse.PromptFeedback.BlockReason,
+	}
+
+	ratings := gResponse.PromptFeedback.SafetyRatings
+
+	if len(gResponse.Candidates) > 0 {
+		candidate := gResponse.Candidates[0]
+
+		for _, part := range candidate.Content.Parts {
+			response.Text += part.Text
+		}
+
+		response.FinishReason = candidate.FinishReason
+		ratings = append(ratings, candidate.SafetyRatings...)
+	}
+
+	for _, rating := range ratings {
+		if rating.Blocked {
+			response.BlockedCategories = append(response.BlockedCategories, rating.Category)
+		}
+	}
+
+	return response, nil
+}
+
+type generateContentRequest struct {
+	SystemInstruction *Content         `json:"systemInstruction,omitempty"`
+	Contents          []Content        `json:"contents"`
+	GenerationConfig  generationConfig `json:"generationConfig"`
+}
+
+type generationConfig struct {
+	Temperature        float32         `json:"temperature"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+type rawSafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
+}
+
+type rawCandidate struct {
+	Content       Content           `json:"content"`
+	FinishReason  string            `json:"finishReason"`
+	SafetyRatings []rawSafetyRating `json:"safetyRatings"`
+}
+
+type rawPromptFeedback struct {
+	BlockReason   string            `json:"blockReason"`
+	SafetyRatings []rawSafetyRating `json:"safetyRatings"`
+}
+
+type rawUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+type rawGenerateContentResponse struct {
+	Candidates     []rawCandidate    `json:"candidates"`
+	PromptFeedback rawPromptFeedback `json:"promptFeedback"`
+	UsageMetadata  rawUsageMetadata  `json:"usageMetadata"`
+	ModelVersion   string            `json:"modelVersion"`
+}
+
+type rawErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
@@ -0,0 +1,158 @@
+package gemini_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/gemini"
+)
+
+const generateContentResponse = `{
+  "candidates": [{
+    "content": {"role": "model", "parts": [{"text": "{\"subject\":"}, {"text": "\"Add search\"}"}]},
+    "finishReason": "STOP"
+  }],
+  "usageMetadata": {"promptTokenCount": 1000, "candidatesTokenCount": 100, "totalTokenCount": 1100},
+  "modelVersion": "gemini-2.0-flash"
+}`
+
+func TestAIModelCost(t *testing.T) {
+	testCases := []struct {
+		promptTokens     int
+		candidatesTokens int
+		expectedCost     float64
+	}{
+		{
+			promptTokens:     1000000,
+			candidatesTokens: 0,
+			expectedCost:     0.1,
+		},
+		{
+			promptTokens:     0,
+			candidatesTokens: 1000000,
+			expectedCost:     0.4,
+		},
+		{
+			promptTokens:     10,
+			candidatesTokens: -1,
+			expectedCost:     0.0,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			got := gemini.Gemini2_0Flash.Cost(tc.promptTokens, tc.candidatesTokens)
+
+			if got != tc.expectedCost {
+				t.Errorf("got %v, want %v", got, tc.expectedCost)
+			}
+		})
+	}
+}
+
+func TestGenerateContentWithSchema(t *testing.T) {
+	testCases := []struct {
+		auth       gemini.Auth
+		header     string
+		credential string
+	}{
+		{
+			auth:       "",
+			header:     "x-goog-api-key",
+			credential: "secret",
+		},
+		{
+			auth:       gemini.BearerAuth,
+			header:     "Authorization",
+			credential: "Bearer secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(string(tc.auth), func(t *testing.T) {
+			var (
+				header      http.Header
+				path        string
+				requestBody struct {
+					SystemInstruction gemini.Content   `json:"systemInstruction"`
+					Contents          []gemini.Content `json:"contents"`
+					GenerationConfig  struct {
+						ResponseMimeType   string          `json:"responseMimeType"`
+						ResponseJSONSchema json.RawMessage `json:"responseJsonSchema"`
+					} `json:"generationConfig"`
+				}
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				path = r.URL.Path
+
+				if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+					t.Error(err)
+				}
+
+				_, _ = w.Write([]byte(generateContentResponse))
+			}))
+			defer server.Close()
+
+			client := gemini.NewClient(server.Client(), "secret", server.URL+"/v1beta", tc.auth)
+			contents := []gemini.Content{gemini.TextContent(gemini.UserRole, "diff")}
+
+			response, err := client.GenerateContentWithSchema(context.Background(), "You write commit messages.", contents, gemini.Gemini2_0Flash, 0.2, json.RawMessage(`{"type":"object"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if path != "/v1beta/models/gemini-2.0-flash:generateContent" || header.Get(tc.header) != tc.credential {
+				t.Errorf("unexpected request to %q with header %v", path, header)
+			}
+
+			if requestBody.SystemInstruction.Parts[0].Text != "You write commit messages." || len(requestBody.Contents) != 1 ||
+				requestBody.GenerationConfig.ResponseMimeType != "application/json" || string(requestBody.GenerationConfig.ResponseJSONSchema) != `{"type":"object"}` {
+				t.Errorf("unexpected request %+v", requestBody)
+			}
+
+			if response.Text != `{"subject":"Add search"}` || response.FinishReason != "STOP" {
+				t.Errorf("got %+v", response)
+			}
+
+			if response.Cost != gemini.Gemini2_0Flash.Cost(1000, 100) {
+				t.Errorf("got cost %v", response.Cost)
+			}
+		})
+	}
+}
+
+func TestGenerateContentBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+  "promptFeedback": {
+    "blockReason": "SAFETY",
+    "safetyRatings": [
+      {"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"},
+      {"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "HIGH", "blocked": true}
+    ]
+  },
+  "usageMetadata": {"promptTokenCount": 10, "totalTokenCount": 10}
+}`))
+	}))
+	defer server.Close()
+
+	client := gemini.NewClient(server.Client(), "", server.URL, "")
+
+	response, err := client.GenerateContentWithSchema(context.Background(), "", nil, gemini.Gemini2_0Flash, 0.2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.BlockReason != "SAFETY" || len(response.BlockedCategories) != 1 || response.BlockedCategories[0] != "HARM_CATEGORY_DANGEROUS_CONTENT" {
+		t.Errorf("got %+v", response)
+	}
+}
@@ -0,0 +1,512 @@
+/*
+Package git runs git commands in a repository.
+
+Only the small set of commands needed by commit-msg is supported. Every
+command is run with the git binary found in PATH.
+*/
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+const (
+	// HEAD is the revision of the current commit.
+	HEAD = "HEAD"
+
+	// Index is used as revision to refer to the staged content.
+	Index = ""
+)
+
+// Repo is a git repository.
+type Repo struct {
+	dir string
+}
+
+// New returns the repository in the directory dir.
+func New(dir string) *Repo {
+	return &Repo{
+		dir: dir,
+	}
+}
+
+// ReadFile returns the content of the file at p in rev. Use Index to read the
+// staged content.
+func (r *Repo) ReadFile(ctx context.Context, rev, p string) ([]byte, error) {
+	out, err := r.run(ctx, "show", rev+":"+p)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ListFiles returns the paths of the files directly in the directory dir in
+// rev. Use Index to list the staged files.
+func (r *Repo) ListFiles(ctx context.Context, rev, dir string) ([]string, error) {
+	dir = path.Clean(dir)
+
+	var args []string
+	if rev == Index {
+		args = []string{"ls-files", "-z", "--"}
+	} else {
+		args = []string{"ls-tree", "-z", "--name-only", "--full-tree", rev, "--"}
+	}
+
+	if dir != "." {
+		args = append(args, dir+"/")
+	}
+
+	out, err := r.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+
+	for _, file := range splitNul(out) {
+		if path.Dir(file) == dir {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// CurrentBranch returns the short name of the checked out branch, e.g.
+// "feature/PROJ-1234-add-login". An error is returned if HEAD is detached.
+func (r *Repo) CurrentBranch(ctx context.Context) (string, error) {
+	out, err := r.run(ctx, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Identity returns the configured user, e.g. "Jane Doe <jane@example.com>".
+func (r *Repo) Identity(ctx context.Context) (string, error) {
+	name, err := r.run(ctx, "config", "user.name")
+	if err != nil {
+		return "", err
+	}
+
+	email, err := r.run(ctx, "config", "user.email")
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s <%s>", strings.TrimSpace(string(name)), strings.TrimSpace(string(email))), nil
+}
+
+// CommentChar returns core.commentChar, the character starting the comments
+// of commit messages and of the todo list of a rebase. It is "#" if not set,
+// and may be "auto" for a character git picks for each message.
+func (r *Repo) CommentChar(ctx context.Context) (string, error) {
+	out, err := r.run(ctx, "config", "--default", "#", "core.commentChar")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RecentMessages returns the messages of the n most recent commits by the
+// author, most recent first. All authors are included if author is empty.
+func (r *Repo) RecentMessages(ctx context.Context, author string, n int) ([]string, error) {
+	args := []string{"log", fmt.Sprintf("-n%d", n), "--format=%B%x00"}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+
+	out, err := r.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []string{}
+
+	for _, message := range strings.Split(string(out), "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Commit is a commit in the history.
+type Commit struct {
+	Hash    string
+	Author  string
+	Message string
+
+	// Files are the paths changed by the commit.
+	Files []string
+}
+
+// LogOptions filters the commits returned by Log.
+type LogOptions struct {
+	// Revision is where the history starts, HEAD if empty. It may also be
+	// a range, e.g. "v1.0.0..HEAD".
+	Revision string
+
+	// MaxCount limits the number of commits, no limit if zero.
+	MaxCount int
+
+	// Author only includes commits by a matching author.
+	Author string
+
+	// Paths only includes commits changing one of the paths.
+	Paths []string
+
+	// NoMerges excludes merge commits.
+	NoMerges bool
+}
+
+const (
+	recordSeparator = "\x1e"
+	fieldSeparator  = "\x1f"
+)
+
+// Log returns the commits in the history, most recent first.
+func (r *Repo) Log(ctx context.Context, opts LogOptions) ([]Commit, error) {
+	args := []string{"log", "--name-only", "--format=" + recordSeparator + "%H" + fieldSeparator + "%an <%ae>" + fieldSeparator + "%B" + fieldSeparator}
+
+	if opts.MaxCount > 0 {
+		args = append(args, fmt.Sprintf("-n%d", opts.MaxCount))
+	}
+
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+
+	if opts.NoMerges {
+		args = append(args, "--no-merges")
+	}
+
+	if opts.Revision != "" {
+		args = append(args, opts.Revision)
+	}
+
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+
+	out, err := r.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []Commit{}
+
+	for _, record := range strings.Split(string(out), recordSeparator) {
+		fields := strings.Split(record, fieldSeparator)
+		if len(fields) != 4 {
+			continue
+		}
+
+		commit := Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Message: strings.TrimSpace(fields[2]),
+			Files:   []string{},
+		}
+
+		for _, file := range strings.Split(fields[3], "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				commit.Files = append(commit.Files, file)
+			}
+		}
+
+		commits = append(commits, commit)
+	}
+
+	return commits, nil
+}
+
+// Show returns the diff introduced by the commit.
+func (r *Repo) Show(ctx context.Context, rev string) (string, error) {
+	out, err := r.run(ctx, "show", "--format=", "--patch", "--no-color", rev)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// Tags returns the tags reachable from HEAD.
+func (r *Repo) Tags(ctx context.Context) ([]string, error) {
+	out, err := r.run(ctx, "tag", "--list", "--merged", HEAD)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+
+	for _, tag := range strings.Split(string(out), "\n") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// Diff returns the changes between the merge base of base and HEAD, and
+// HEAD, i.e. the changes of a branch created from base.
+func (r *Repo) Diff(ctx context.Context, base string) (string, error) {
+	out, err := r.run(ctx, "diff", "--no-color", base+"..."+HEAD)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// DiffRange returns the changes between the commits from and to.
+func (r *Repo) DiffRange(ctx context.Context, from, to string) (string, error) {
+	out, err := r.run(ctx, "diff", "--no-color", from, to, "--")
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// DiffIndex returns the changes between the commit and the staged content.
+// In a repository without commits all staged files are changes against HEAD.
+func (r *Repo) DiffIndex(ctx context.Context, rev string) (string, error) {
+	args := []string{"diff", "--cached", "--no-color", rev, "--"}
+
+	if rev == HEAD {
+		if _, err := r.RevParse(ctx, HEAD); err != nil {
+			// Without a revision git diffs against the empty tree before
+			// the first commit.
+			args = []string{"diff", "--cached", "--no-color", "--"}
+		}
+	}
+
+	out, err := r.run(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// GitPath returns the path of the file in the git directory, e.g.
+// ".git/SQUASH_MSG" for "SQUASH_MSG".
+func (r *Repo) GitPath(ctx context.Context, name string) (string, error) {
+	out, err := r.run(ctx, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Revision is a commit and its parents.
+type Revision struct {
+	Hash    string
+	Parents []string
+}
+
+// RevList returns the commits of the revision, e.g. "main..HEAD", most recent
+// first.
+func (r *Repo) RevList(ctx context.Context, revision string) ([]Revision, error) {
+	out, err := r.run(ctx, "rev-list", "--parents", revision, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []Revision{}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		revisions = append(revisions, Revision{Hash: fields[0], Parents: fields[1:]})
+	}
+
+	return revisions, nil
+}
+
+// RefsContaining returns the local and remote-tracking branches containing
+// the commit, e.g. "refs/heads/main" and "refs/remotes/origin/main".
+func (r *Repo) RefsContaining(ctx context.Context, rev string) ([]string, error) {
+	out, err := r.run(ctx, "for-each-ref", "--contains", rev, "--format=%(refname)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []string{}
+
+	for _, ref := range strings.Split(string(out), "\n") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// Reword creates a copy of the commit with the parent and the message, and
+// returns its hash. The tree and the author are kept, the committer is the
+// current user. No hooks are run.
+func (r *Repo) Reword(ctx context.Context, rev, parent, message string) (string, error) {
+	out, err := r.run(ctx, "show", "--no-patch", "--format=%an"+fieldSeparator+"%ae"+fieldSeparator+"%ad", "--date=raw", rev)
+	if err != nil {
+		return "", err
+	}
+
+	author := strings.Split(strings.TrimSpace(string(out)), fieldSeparator)
+	if len(author) != 3 {
+		return "", fmt.Errorf("could not parse the author of %s: %q", rev, out)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "commit-tree", rev+"^{tree}", "-p", parent, "-F", "-")
+	cmd.Dir = r.dir
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+author[0],
+		"GIT_AUTHOR_EMAIL="+author[1],
+		"GIT_AUTHOR_DATE="+author[2],
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	hash, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git commit-tree %s: %w: %s", rev, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(hash)), nil
+}
+
+// UpdateRef sets the ref to the commit if it is still at old. If old is empty
+// the ref is set whatever it points to, or created if it does not exist. The
+// reason is recorded in the reflog of the ref.
+func (r *Repo) UpdateRef(ctx context.Context, ref, commit, old, reason string) error {
+	args := []string{"update-ref", "--create-reflog", "-m", reason, ref, commit}
+	if old != "" {
+		args = append(args, old)
+	}
+
+	_, err := r.run(ctx, args...)
+
+	return err
+}
+
+// CreateRef creates the ref pointing to the commit. It fails if the ref
+// already exists. The reason is recorded in the reflog of the ref.
+func (r *Repo) CreateRef(ctx context.Context, ref, commit, reason string) error {
+	// An empty old value is the zero object ID, which update-ref requires
+	// the ref to be at.
+	_, err := r.run(ctx, "update-ref", "--create-reflog", "-m", reason, ref, commit, "")
+
+	return err
+}
+
+// StagedDiff returns the staged changes, in a form that can be applied with
+// ApplyCached.
+func (r *Repo) StagedDiff(ctx context.Context) (string, error) {
+	out, err := r.run(ctx, "diff", "--cached", "--binary", "--no-color")
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// RevParse returns the hash of the revision.
+func (r *Repo) RevParse(ctx context.Context, rev string) (string, error) {
+	out, err := r.run(ctx, "rev-parse", "--verify", rev)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ResetIndex unstages all changes, the working tree is not changed.
+func (r *Repo) ResetIndex(ctx context.Context) error {
+	_, err := r.run(ctx, "reset", "--quiet", HEAD)
+
+	return err
+}
+
+// ApplyCached stages the patch, the working tree is not changed.
+func (r *Repo) ApplyCached(ctx context.Context, patch string) error {
+	_, err := r.runInput(ctx, strings.NewReader(patch), "apply", "--cached", "--whitespace=nowarn", "-")
+
+	return err
+}
+
+// Commit commits the staged changes with the message on top of HEAD, and
+// returns the hash of the commit. HEAD is only moved if no other commit was
+// made meanwhile. The reason is recorded in the reflog. The commit is created
+// with plumbing commands, so no hooks are run, e.g. the prepare-commit-msg
+// hook running commit-msg.
+func (r *Repo) Commit(ctx context.Context, message, reason string) (string, error) {
+	parent, err := r.RevParse(ctx, HEAD)
+	if err != nil {
+		return "", err
+	}
+
+	tree, err := r.run(ctx, "write-tree")
+	if err != nil {
+		return "", err
+	}
+
+	out, err := r.runInput(ctx, strings.NewReader(message), "commit-tree", strings.TrimSpace(string(tree)), "-p", parent, "-F", "-")
+	if err != nil {
+		return "", err
+	}
+
+	hash := strings.TrimSpace(string(out))
+
+	if _, err := r.run(ctx, "update-ref", "-m", reason, HEAD, hash, parent); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (r *Repo) run(ctx context.Context, args ...string) ([]byte, error) {
+	return r.runInput(ctx, nil, args...)
+}
+
+func (r *Repo) runInput(ctx context.Context, stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Stdin = stdin
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func splitNul(out []byte) []string {
+	trimmed := strings.TrimSuffix(string(out), "\x00")
+	if trimmed == "" {
+		return []string{}
+	}
+
+	return strings.Split(trimmed, "\x00")
+}
@@ -0,0 +1,292 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/gittest"
+)
+
+func TestRevList(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", map[string]string{"a.txt": "a\n"})
+	second := r.Commit("second", map[string]string{"a.txt": "b\n"})
+	third := r.Commit("third", map[string]string{"a.txt": "c\n"})
+
+	testCases := []struct {
+		revision string
+		expected []git.Revision
+	}{
+		{
+			revision: first + "..HEAD",
+			expected: []git.Revision{{Hash: third, Parents: []string{second}}, {Hash: second, Parents: []string{first}}},
+		},
+		{
+			revision: second,
+			expected: []git.Revision{{Hash: second, Parents: []string{first}}, {Hash: first, Parents: []string{}}},
+		},
+		{revision: "HEAD..HEAD", expected: []git.Revision{}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.revision, func(t *testing.T) {
+			revisions, err := git.New(r.Dir).RevList(context.Background(), tc.revision)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(revisions) != len(tc.expected) {
+				t.Fatalf("got %v, want %v", revisions, tc.expected)
+			}
+
+			for i, rev := range revisions {
+				if rev.Hash != tc.expected[i].Hash || strings.Join(rev.Parents, " ") != strings.Join(tc.expected[i].Parents, " ") {
+					t.Errorf("got %v, want %v", rev, tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRefsContaining(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", nil)
+	r.Git("branch", "feature")
+	second := r.Commit("second", nil)
+	r.Git("update-ref", "refs/remotes/origin/main", first)
+	r.Git("tag", "v1.0.0", first)
+
+	testCases := []struct {
+		rev      string
+		expected string
+	}{
+		{rev: first, expected: "refs/heads/feature refs/heads/main refs/remotes/origin/main"},
+		{rev: second, expected: "refs/heads/main"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			refs, err := git.New(r.Dir).RefsContaining(context.Background(), tc.rev)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(refs, " "); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReword(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", map[string]string{"a.txt": "a\n"})
+
+	t.Setenv("GIT_AUTHOR_NAME", "John Roe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "john@example.com")
+	second := r.Commit("second", map[string]string{"a.txt": "b\n"})
+
+	t.Setenv("GIT_AUTHOR_NAME", "Jane Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "jane@example.com")
+
+	hash, err := git.New(r.Dir).Reword(context.Background(), second, first, "Reworded\n\nWith a body.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash == second {
+		t.Fatal("expected a new commit")
+	}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{format: "%B", expected: "Reworded\n\nWith a body."},
+		{format: "%an <%ae> %ad", expected: r.Git("show", "--no-patch", "--format=%an <%ae> %ad", second)},
+		{format: "%T", expected: r.Git("rev-parse", second+"^{tree}")},
+		{format: "%P", expected: first},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.format, func(t *testing.T) {
+			if got := r.Git("show", "--no-patch", "--format="+tc.format, hash); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+
+	if got := r.Git("rev-parse", "HEAD"); got != second {
+		t.Errorf("expected HEAD to be unchanged, got %s", got)
+	}
+}
+
+func TestUpdateRef(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", nil)
+	second := r.Commit("second", nil)
+
+	ctx := context.Background()
+	repo := git.New(r.Dir)
+
+	if err := repo.UpdateRef(ctx, "refs/heads/main", first, first, "test"); err == nil {
+		t.Error("expected an error when the ref is not at old")
+	}
+
+	if err := repo.UpdateRef(ctx, "refs/heads/main", first, second, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.UpdateRef(ctx, "refs/heads/main", second, "", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.Git("rev-parse", "main"); got != second {
+		t.Errorf("got main at %s, want %s", got, second)
+	}
+}
+
+func TestCreateRef(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", nil)
+	second := r.Commit("second", nil)
+
+	ctx := context.Background()
+	repo := git.New(r.Dir)
+
+	if err := repo.CreateRef(ctx, "refs/backup/main", first, "backup"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.CreateRef(ctx, "refs/backup/main", second, "backup"); err == nil {
+		t.Error("expected an error when the ref exists")
+	}
+
+	if got := r.Git("rev-parse", "refs/backup/main"); got != first {
+		t.Errorf("got the ref at %s, want it unchanged at %s", got, first)
+	}
+}
+
+func TestCommentChar(t *testing.T) {
+	testCases := []struct {
+		config   string
+		expected string
+	}{
+		{expected: "#"},
+		{config: ";", expected: ";"},
+		{config: "auto", expected: "auto"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			r := gittest.New(t)
+			if tc.config != "" {
+				r.Git("config", "core.commentChar", tc.config)
+			}
+
+			commentChar, err := git.New(r.Dir).CommentChar(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if commentChar != tc.expected {
+				t.Errorf("got %q, want %q", commentChar, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommit(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", map[string]string{"a.txt": "a\n"})
+	r.WriteFile("a.txt", "b\n")
+	r.Git("add", "a.txt")
+
+	hash, err := git.New(r.Dir).Commit(context.Background(), "second\n", "commit-msg test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"rev-parse", "HEAD"}, expected: hash},
+		{args: []string{"rev-parse", "HEAD^"}, expected: first},
+		{args: []string{"show", "HEAD:a.txt"}, expected: "b"},
+		{args: []string{"reflog", "--format=%gs", "-n1", "HEAD"}, expected: "commit-msg test"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			if got := r.Git(tc.args...); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommitConcurrent(t *testing.T) {
+	r := gittest.New(t)
+	r.Commit("first", map[string]string{"a.txt": "a\n"})
+	concurrent := r.Commit("concurrent", nil)
+	r.Git("reset", "--quiet", "--soft", "HEAD^")
+
+	realGit, err := exec.LookPath("git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A git in PATH moving HEAD when the tree is written, like a commit made
+	// meanwhile.
+	bin := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = write-tree ]; then " + realGit + " update-ref HEAD " + concurrent + "; fi\nexec " + realGit + " \"$@\"\n"
+
+	if err := os.WriteFile(filepath.Join(bin, "git"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, err := git.New(r.Dir).Commit(context.Background(), "second\n", "test"); err == nil {
+		t.Fatal("expected an error when HEAD moved")
+	}
+
+	if got := r.Git("rev-parse", "HEAD"); got != concurrent {
+		t.Errorf("got HEAD at %s, want the concurrent commit %s", got, concurrent)
+	}
+}
+
+func TestDiffIndexWithoutCommits(t *testing.T) {
+	r := gittest.New(t)
+	r.WriteFile("a.txt", "a\n")
+	r.Git("add", "a.txt")
+
+	gitDiff, err := git.New(r.Dir).DiffIndex(context.Background(), git.HEAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(gitDiff, "new file mode") || !strings.Contains(gitDiff, "+a") {
+		t.Errorf("got %q, want a.txt as a new file", gitDiff)
+	}
+
+	if _, err := git.New(r.Dir).DiffIndex(context.Background(), "main~1"); err == nil {
+		t.Error("got no error for a revision that does not resolve")
+	}
+}
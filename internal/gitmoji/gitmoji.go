@@ -0,0 +1,223 @@
+/*
+Package gitmoji selects and validates gitmojis, the emojis prefixing commit
+messages in the gitmoji convention (https://gitmoji.dev).
+
+The official list of gitmojis is embedded in the binary. A gitmoji is written
+either as unicode ("✨ Add search") or as its code (":sparkles: Add search"),
+and can be combined with conventional commits ("✨ feat(api): add search").
+*/
+package gitmoji
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/philiplinell/commit-msg/internal/conventional"
+)
+
+//go:embed gitmojis.json
+var gitmojisJSON []byte
+
+//nolint:gochecknoglobals
+var gitmojis = mustLoad()
+
+// Gitmoji is an entry of the official list.
+type Gitmoji struct {
+	Emoji       string `json:"emoji"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Name        string `json:"name"`
+
+	// Semver is the part of the version a change with the gitmoji bumps,
+	// "major", "minor", "patch" or empty.
+	Semver string `json:"semver"`
+}
+
+// Form is how a gitmoji is written.
+type Form string
+
+const (
+	// Unicode writes the emoji, e.g. "✨". It is the default.
+	Unicode Form = "unicode"
+
+	// Code writes the code, e.g. ":sparkles:".
+	Code Form = "code"
+)
+
+// Config configures the gitmojis of the Gitmoji style.
+type Config struct {
+	// Form is how the gitmojis are written, Unicode if empty.
+	Form Form `json:"form,omitempty"`
+}
+
+// Validate returns an error if the form is unknown.
+func (c Config) Validate() error {
+	switch c.Form {
+	case "", Unicode, Code:
+		return nil
+	default:
+		return fmt.Errorf("invalid form %q, must be %q or %q", c.Form, Unicode, Code)
+	}
+}
+
+// ErrMissing is returned by Validate when the message has no gitmoji.
+var ErrMissing = errors.New("the message does not start with a gitmoji")
+
+func mustLoad() []Gitmoji {
+	var list struct {
+		Gitmojis []Gitmoji `json:"gitmojis"`
+	}
+
+	if err := json.Unmarshal(gitmojisJSON, &list); err != nil {
+		panic(fmt.Sprintf("could not decode the embedded gitmojis: %s", err))
+	}
+
+	return list.Gitmojis
+}
+
+// All returns the official list of gitmojis.
+func All() []Gitmoji {
+	return append([]Gitmoji(nil), gitmojis...)
+}
+
+// Format returns the gitmoji written in the form.
+func (g Gitmoji) Format(form Form) string {
+	if form == Code {
+		return g.Code
+	}
+
+	return g.Emoji
+}
+
+// Lookup returns the gitmoji written as s, in either form. Emojis are
+// compared without variation selectors, so "⚡" and "⚡️" are the same.
+func Lookup(s string) (Gitmoji, bool) {
+	for _, g := range gitmojis {
+		if s == g.Code || normalize(s) == normalize(g.Emoji) {
+			return g, true
+		}
+	}
+
+	return Gitmoji{}, false
+}
+
+// typeGitmojis are the gitmojis for the conventional commit types.
+//
+//nolint:gochecknoglobals
+var typeGitmojis = map[string]string{
+	conventional.Build:    ":package:",
+	conventional.Chore:    ":wrench:",
+	conventional.CI:       ":construction_worker:",
+	conventional.Docs:     ":memo:",
+	conventional.Feat:     ":sparkles:",
+	conventional.Fix:      ":bug:",
+	conventional.Perf:     ":zap:",
+	conventional.Refactor: ":recycle:",
+	conventional.Revert:   ":rewind:",
+	conventional.Style:    ":art:",
+	conventional.Test:     ":white_check_mark:",
+}
+
+// ForType returns the gitmoji for a change of the conventional commit type,
+// ":boom:" for breaking changes and ":wrench:" for unknown types.
+func ForType(commitType string, breaking bool) Gitmoji {
+	code, ok := typeGitmojis[commitType]
+
+	switch {
+	case breaking:
+		code = ":boom:"
+	case !ok:
+		code = ":wrench:"
+	}
+
+	g, _ := Lookup(code)
+
+	return g
+}
+
+// Cut splits the subject of message into the leading gitmoji candidate and
+// the rest of the message. The candidate is a code (":name:") or a sequence
+// of emoji characters, and is not necessarily in the list, see Validate.
+func Cut(message string) (prefix, rest string) {
+	trimmed := strings.TrimLeft(message, " \t\n")
+
+	if strings.HasPrefix(trimmed, ":") {
+		if end := strings.Index(trimmed[1:], ":"); end > 0 && !strings.ContainsAny(trimmed[1:end+1], " \t\n") {
+			return trimmed[:end+2], strings.TrimLeft(trimmed[end+2:], " ")
+		}
+
+		return "", message
+	}
+
+	i := 0
+	for i < len(trimmed) {
+		r, size := utf8.DecodeRuneInString(trimmed[i:])
+		if !isEmojiRune(r) {
+			break
+		}
+
+		i += size
+	}
+
+	if i == 0 {
+		return "", message
+	}
+
+	return trimmed[:i], strings.TrimLeft(trimmed[i:], " ")
+}
+
+// Validate returns the gitmoji the message starts with. It returns ErrMissing
+// if there is none and an error if it is not in the official list.
+func Validate(message string) (Gitmoji, error) {
+	prefix, _ := Cut(message)
+	if prefix == "" {
+		return Gitmoji{}, ErrMissing
+	}
+
+	g, ok := Lookup(prefix)
+	if !ok {
+		return Gitmoji{}, fmt.Errorf("%q is not a gitmoji", prefix)
+	}
+
+	return g, nil
+}
+
+// Prefix returns message starting with the gitmoji written in the form,
+// replacing any gitmoji (or other emoji) it already starts with.
+func Prefix(message string, g Gitmoji, form Form) string {
+	_, rest := Cut(message)
+
+	return g.Format(form) + " " + rest
+}
+
+// Prompt returns the prompt fragment listing the gitmojis in the form.
+func Prompt(form Form) string {
+	var b strings.Builder
+
+	b.WriteString("Start the subject with exactly one gitmoji from this list, followed by a space:\n")
+
+	for _, g := range gitmojis {
+		fmt.Fprintf(&b, "%s %s\n", g.Format(form), g.Description)
+	}
+
+	return b.String()
+}
+
+// isEmojiRune returns true for characters used in emojis: symbols outside
+// Latin-1, variation selectors and zero width joiners.
+func isEmojiRune(r rune) bool {
+	if r == '\u200d' || r == '\ufe0f' {
+		return true
+	}
+
+	return r > unicode.MaxLatin1 && unicode.IsSymbol(r)
+}
+
+func normalize(emoji string) string {
+	return strings.ReplaceAll(emoji, "\ufe0f", "")
+}
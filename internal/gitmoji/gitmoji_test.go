@@ -0,0 +1,117 @@
+package gitmoji_test
+
+import (
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+)
+
+func TestAllHasUniqueCodesAndEmojis(t *testing.T) {
+	codes := map[string]bool{}
+	emojis := map[string]bool{}
+
+	for _, g := range gitmoji.All() {
+		if codes[g.Code] || emojis[g.Emoji] {
+			t.Errorf("duplicate gitmoji %+v", g)
+		}
+
+		codes[g.Code] = true
+		emojis[g.Emoji] = true
+	}
+
+	if len(codes) < 70 {
+		t.Errorf("got %d gitmojis, want the official list", len(codes))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected string
+		valid    bool
+	}{
+		{message: "✨ Add search", expected: ":sparkles:", valid: true},
+		{message: ":sparkles: Add search", expected: ":sparkles:", valid: true},
+		{message: "✨ feat(api): add search", expected: ":sparkles:", valid: true},
+		{message: "⚡ Speed up parsing", expected: ":zap:", valid: true},
+		{message: "⚡️ Speed up parsing", expected: ":zap:", valid: true},
+		{message: "🧑‍💻 Add make target", expected: ":technologist:", valid: true},
+		{message: "🦄 Add unicorns", valid: false},
+		{message: ":unicorn: Add unicorns", valid: false},
+		{message: "Add search", valid: false},
+		{message: "fix: handle : in values", valid: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.message, func(t *testing.T) {
+			g, err := gitmoji.Validate(tc.message)
+
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", g)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if g.Code != tc.expected {
+				t.Errorf("got %q, want %q", g.Code, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	sparkles, _ := gitmoji.Lookup(":sparkles:")
+
+	testCases := []struct {
+		message  string
+		form     gitmoji.Form
+		expected string
+	}{
+		{message: "Add search\n\nBody", form: gitmoji.Unicode, expected: "✨ Add search\n\nBody"},
+		{message: "🦄 Add search", form: gitmoji.Unicode, expected: "✨ Add search"},
+		{message: "✨ feat: add search", form: gitmoji.Code, expected: ":sparkles: feat: add search"},
+		{message: ":bug: Add search", form: "", expected: "✨ Add search"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.message, func(t *testing.T) {
+			if got := gitmoji.Prefix(tc.message, sparkles, tc.form); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestForType(t *testing.T) {
+	testCases := []struct {
+		commitType string
+		breaking   bool
+		expected   string
+	}{
+		{commitType: "feat", expected: ":sparkles:"},
+		{commitType: "fix", expected: ":bug:"},
+		{commitType: "docs", expected: ":memo:"},
+		{commitType: "feat", breaking: true, expected: ":boom:"},
+		{commitType: "deps", expected: ":wrench:"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.commitType, func(t *testing.T) {
+			if got := gitmoji.ForType(tc.commitType, tc.breaking).Code; got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
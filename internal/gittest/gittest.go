@@ -0,0 +1,96 @@
+/*
+Package gittest creates git repositories in temporary directories for tests.
+
+The repositories are isolated from the configuration of the user, and the
+author and committer are fixed.
+*/
+package gittest
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Repo is a git repository in a temporary directory.
+type Repo struct {
+	t testing.TB
+
+	// Dir is the directory of the repository.
+	Dir string
+}
+
+// New returns an empty repository with the branch main checked out. The test
+// is skipped if git is not installed.
+func New(t testing.TB) *Repo {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Jane Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "jane@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Jane Doe")
+	t.Setenv("GIT_COMMITTER_EMAIL", "jane@example.com")
+
+	r := &Repo{t: t, Dir: t.TempDir()}
+	r.Git("init", "--quiet")
+	r.Git("symbolic-ref", "HEAD", "refs/heads/main")
+
+	return r
+}
+
+// Git runs git in the repository and returns its trimmed output. The test
+// fails if git fails.
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		r.t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, stderr.String())
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// WriteFile writes the file at the path relative to the repository.
+func (r *Repo) WriteFile(name, content string) {
+	r.t.Helper()
+
+	p := filepath.Join(r.Dir, name)
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// Commit writes the files, commits all changes with the message, and returns
+// the hash of the commit.
+func (r *Repo) Commit(message string, files map[string]string) string {
+	r.t.Helper()
+
+	for name, content := range files {
+		r.WriteFile(name, content)
+	}
+
+	r.Git("add", "--all")
+	r.Git("commit", "--quiet", "--no-verify", "--allow-empty", "--message", message)
+
+	return r.Git("rev-parse", "HEAD")
+}
@@ -0,0 +1,71 @@
+// Package glob matches slash separated paths against glob patterns.
+//
+// The patterns support everything path.Match does, and in addition "**"
+// which matches zero or more directories, e.g. "services/**/*.go". A pattern
+// without a slash is matched against the base name of the path, like in
+// .gitignore.
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+const doubleStar = "**"
+
+// Match returns true if name matches pattern. Malformed patterns never match.
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	name = strings.TrimPrefix(name, "/")
+
+	if !strings.Contains(pattern, "/") {
+		ok, err := path.Match(pattern, path.Base(name))
+		return err == nil && ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// MatchAny returns true if name matches at least one of the patterns.
+func MatchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == doubleStar {
+			rest := patterns[1:]
+			if len(rest) == 0 {
+				return true
+			}
+
+			for i := 0; i <= len(names); i++ {
+				if matchSegments(rest, names[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(names) == 0 {
+			return false
+		}
+
+		ok, err := path.Match(patterns[0], names[0])
+		if err != nil || !ok {
+			return false
+		}
+
+		patterns = patterns[1:]
+		names = names[1:]
+	}
+
+	return len(names) == 0
+}
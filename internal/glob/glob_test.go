@@ -0,0 +1,36 @@
+package glob_test
+
+import (
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/glob"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "*.md", name: "docs/guide/intro.md", expected: true},
+		{pattern: "services/api/**", name: "services/api/handler.go", expected: true},
+		{pattern: "services/api/**", name: "services/api/v1/handler.go", expected: true},
+		{pattern: "services/api/**", name: "services/web/handler.go", expected: false},
+		{pattern: "**/testdata/*.json", name: "internal/openai/testdata/x.json", expected: true},
+		{pattern: "**/testdata/*.json", name: "testdata/x.json", expected: true},
+		{pattern: "cmd/*/main.go", name: "cmd/cli/main.go", expected: true},
+		{pattern: "cmd/*/main.go", name: "cmd/cli/sub/main.go", expected: false},
+		{pattern: "/go.mod", name: "go.mod", expected: true},
+		{pattern: "[", name: "[", expected: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.pattern, func(t *testing.T) {
+			if got := glob.Match(tc.pattern, tc.name); got != tc.expected {
+				t.Errorf("Match(%q, %q) = %t, want %t", tc.pattern, tc.name, got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package issue
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+// Apply adds the references to the message according to the placement.
+// References already in the message are not added again. scopeDelimiter
+// joins several references used as scope.
+func (c Config) Apply(message string, refs []string, scopeDelimiter string) string {
+	missing := []string{}
+
+	for _, ref := range refs {
+		if !mentions(message, ref) {
+			missing = append(missing, ref)
+		}
+	}
+
+	if len(missing) == 0 {
+		return message
+	}
+
+	switch c.Placement {
+	case Subject:
+		return prefixSubject(message, missing)
+	case Scope:
+		return setScope(message, missing, scopeDelimiter)
+	case Trailer:
+		token := c.TrailerToken
+		if token == "" {
+			token = DefaultTrailerToken
+		}
+
+		return trailer.Append(message, trailer.Trailer{Key: token, Value: strings.Join(missing, ", ")})
+	case None:
+	}
+
+	return message
+}
+
+// mentions reports if the message contains the reference as a whole, e.g.
+// "#12" is not in "#123" and "ENG-4" is not in "ENG-42".
+func mentions(message, ref string) bool {
+	re := regexp.MustCompile(`(?:^|[^A-Za-z0-9])` + regexp.QuoteMeta(ref) + `(?:$|[^A-Za-z0-9])`)
+
+	return re.MatchString(message)
+}
+
+func prefixSubject(message string, refs []string) string {
+	subject, rest, found := strings.Cut(message, "\n")
+	prefix := strings.Join(refs, " ") + " "
+
+	if commit, err := conventional.ParseHeader(subject); err == nil {
+		commit.Description = prefix + commit.Description
+		subject = commit.Header()
+	} else {
+		subject = prefix + subject
+	}
+
+	if !found {
+		return subject
+	}
+
+	return subject + "\n" + rest
+}
+
+func setScope(message string, refs []string, scopeDelimiter string) string {
+	subject, rest, found := strings.Cut(message, "\n")
+
+	commit, err := conventional.ParseHeader(subject)
+	if err != nil {
+		return prefixSubject(message, refs)
+	}
+
+	if scopeDelimiter == "" {
+		scopeDelimiter = conventional.DefaultScopeDelimiter
+	}
+
+	commit.Scope = strings.Join(refs, scopeDelimiter)
+	subject = commit.Header()
+
+	if !found {
+		return subject
+	}
+
+	return subject + "\n" + rest
+}
@@ -0,0 +1,153 @@
+/*
+Package issue extracts issue and ticket references from branch names and adds
+them to commit messages.
+
+With the default patterns the branch "feature/PROJ-1234-add-login" gives the
+Jira key "PROJ-1234" and "fix/123-crash" gives the GitHub reference "#123".
+Linear keys, e.g. "ENG-42" in "jane/eng-42-login", look like many other branch
+names, e.g. "chore/node-18-upgrade", so they need a configured pattern.
+*/
+package issue
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placement is where the issue references are put in the message.
+type Placement string
+
+const (
+	// None does not add issue references to the message.
+	None Placement = ""
+
+	// Subject prefixes the subject (or the description of a conventional
+	// commit) with the references, e.g. "PROJ-1234 Add login".
+	Subject Placement = "subject"
+
+	// Trailer adds a trailer with the references, e.g. "Refs: PROJ-1234".
+	Trailer Placement = "trailer"
+
+	// Scope uses the references as the scope of a conventional commit, e.g.
+	// "feat(PROJ-1234): add login". Messages that are not conventional
+	// commits are handled like Subject.
+	Scope Placement = "scope"
+)
+
+// DefaultTrailerToken is the trailer used with the Trailer placement.
+const DefaultTrailerToken = "Refs"
+
+// Pattern extracts issue references from a branch name.
+type Pattern struct {
+	// Name describes the pattern, e.g. "jira".
+	Name string `json:"name"`
+
+	// Regexp is matched against the branch name. The first capture group
+	// is the issue key.
+	Regexp string `json:"regexp"`
+
+	// Prefix is prepended to the key, e.g. "#" for GitHub issues.
+	Prefix string `json:"prefix,omitempty"`
+
+	// Uppercase converts the key to upper case, e.g. "eng-42" to "ENG-42".
+	Uppercase bool `json:"uppercase,omitempty"`
+}
+
+// DefaultPatterns returns patterns for Jira and GitHub issues.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		{Name: "jira", Regexp: `(?:^|[/_-])([A-Z][A-Z0-9]+-[0-9]+)(?:[/_-]|$)`},
+		{Name: "github", Regexp: `(?:^|/)(?:issue-|gh-|#)([0-9]+)(?:-|$)`, Prefix: "#"},
+		// A number without prefix must be followed by a word, so dates and
+		// versions, e.g. "hotfix/2024-10-18", are not references.
+		{Name: "github-number", Regexp: `(?:^|/)([0-9]+)-[A-Za-z]`, Prefix: "#"},
+	}
+}
+
+// Config is the issue reference configuration.
+type Config struct {
+	// Patterns extract references from the branch name. DefaultPatterns is
+	// used if empty.
+	Patterns []Pattern `json:"patterns,omitempty"`
+
+	// Placement decides where the references are put. References are not
+	// added if empty.
+	Placement Placement `json:"placement,omitempty"`
+
+	// TrailerToken is the trailer used with the Trailer placement.
+	// DefaultTrailerToken is used if empty.
+	TrailerToken string `json:"trailerToken,omitempty"`
+}
+
+// Validate returns an error if the placement or a pattern is invalid.
+func (c Config) Validate() error {
+	switch c.Placement {
+	case None, Subject, Trailer, Scope:
+	default:
+		return fmt.Errorf("invalid placement %q", c.Placement)
+	}
+
+	for _, pattern := range c.Patterns {
+		re, err := regexp.Compile(pattern.Regexp)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern.Name, err)
+		}
+
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("pattern %q has no capture group", pattern.Name)
+		}
+	}
+
+	return nil
+}
+
+// Extract returns the issue references found in the branch name. The
+// patterns are tried in order and the first one matching is used.
+func (c Config) Extract(branch string) ([]string, error) {
+	patterns := c.Patterns
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns()
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern.Name, err)
+		}
+
+		refs := []string{}
+
+		for _, match := range re.FindAllStringSubmatch(branch, -1) {
+			if len(match) < 2 || match[1] == "" {
+				continue
+			}
+
+			ref := match[1]
+			if pattern.Uppercase {
+				ref = strings.ToUpper(ref)
+			}
+
+			ref = pattern.Prefix + ref
+			if !contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+
+		if len(refs) > 0 {
+			return refs, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,190 @@
+package issue_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/issue"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		branch   string
+		expected []string
+	}{
+		{branch: "feature/PROJ-1234-add-login", expected: []string{"PROJ-1234"}},
+		{branch: "PROJ-1-and-PROJ-22", expected: []string{"PROJ-1", "PROJ-22"}},
+		{branch: "fix/123-crash-on-start", expected: []string{"#123"}},
+		{branch: "issue-77", expected: []string{"#77"}},
+		{branch: "jane/eng-42-login", expected: nil},
+		{branch: "chore/node-18-upgrade", expected: nil},
+		{branch: "feature/python-3-support", expected: nil},
+		{branch: "deps/go-1-19", expected: nil},
+		{branch: "main", expected: nil},
+		{branch: "release/1.2", expected: nil},
+		{branch: "hotfix/2024-10-18", expected: nil},
+		{branch: "release/1-2", expected: nil},
+		{branch: "2024-10-18", expected: nil},
+		{branch: "v2-3-0", expected: nil},
+		{branch: "gh-2024", expected: []string{"#2024"}},
+		{branch: "jane/#12", expected: []string{"#12"}},
+		{branch: "42-fix-login", expected: []string{"#42"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.branch, func(t *testing.T) {
+			got, err := issue.Config{}.Extract(tc.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestExtractLinear(t *testing.T) {
+	// The pattern of the README, Linear branches are named
+	// "<user>/<key>-<title>".
+	cfg := issue.Config{Patterns: []issue.Pattern{
+		{Name: "linear", Regexp: `^[^/]+/(eng-[0-9]+)(?:-|$)`, Uppercase: true},
+	}}
+
+	testCases := []struct {
+		branch   string
+		expected []string
+	}{
+		{branch: "jane/eng-42-login", expected: []string{"ENG-42"}},
+		{branch: "jane/eng-42", expected: []string{"ENG-42"}},
+		{branch: "chore/node-18-upgrade", expected: nil},
+		{branch: "jane/feature/eng-42-login", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.branch, func(t *testing.T) {
+			got, err := cfg.Extract(tc.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		placement issue.Placement
+		message   string
+		expected  string
+	}{
+		{
+			placement: issue.Subject,
+			message:   "Add login\n\nBody",
+			expected:  "PROJ-1 Add login\n\nBody",
+		},
+		{
+			placement: issue.Subject,
+			message:   "feat(api): add login",
+			expected:  "feat(api): PROJ-1 add login",
+		},
+		{
+			placement: issue.Scope,
+			message:   "feat(api): add login\n\nBody",
+			expected:  "feat(PROJ-1): add login\n\nBody",
+		},
+		{
+			placement: issue.Scope,
+			message:   "Add login",
+			expected:  "PROJ-1 Add login",
+		},
+		{
+			placement: issue.Trailer,
+			message:   "Add login\n\nBody\n",
+			expected:  "Add login\n\nBody\n\nRefs: PROJ-1",
+		},
+		{
+			placement: issue.Trailer,
+			message:   "feat!: add login\n\nBody\n\nBREAKING CHANGE: login required",
+			expected:  "feat!: add login\n\nBody\n\nBREAKING CHANGE: login required\nRefs: PROJ-1",
+		},
+		{
+			placement: issue.Trailer,
+			message:   "Add login for PROJ-1",
+			expected:  "Add login for PROJ-1",
+		},
+		{
+			placement: issue.Trailer,
+			message:   "Add login for PROJ-12",
+			expected:  "Add login for PROJ-12\n\nRefs: PROJ-1",
+		},
+		{
+			placement: issue.None,
+			message:   "Add login",
+			expected:  "Add login",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(string(tc.placement), func(t *testing.T) {
+			got := issue.Config{Placement: tc.placement}.Apply(tc.message, []string{"PROJ-1"}, "")
+			if got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestApplyMentioned(t *testing.T) {
+	testCases := []struct {
+		message  string
+		refs     []string
+		expected string
+	}{
+		{message: "Fix crash (#12)", refs: []string{"#12"}, expected: "Fix crash (#12)"},
+		{message: "Fix crash (#123)", refs: []string{"#12"}, expected: "Fix crash (#123)\n\nRefs: #12"},
+		{message: "Add login for ENG-42", refs: []string{"ENG-4", "ENG-42"}, expected: "Add login for ENG-42\n\nRefs: ENG-4"},
+		{message: "Add login\n\nRefs: ENG-4", refs: []string{"ENG-4"}, expected: "Add login\n\nRefs: ENG-4"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.message, func(t *testing.T) {
+			got := issue.Config{Placement: issue.Trailer}.Apply(tc.message, tc.refs, "")
+			if got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateReturnsErr(t *testing.T) {
+	testCases := []struct {
+		cfg issue.Config
+	}{
+		{cfg: issue.Config{Placement: "footer"}},
+		{cfg: issue.Config{Patterns: []issue.Pattern{{Name: "invalid", Regexp: "("}}}},
+		{cfg: issue.Config{Patterns: []issue.Pattern{{Name: "no group", Regexp: "[A-Z]+-[0-9]+"}}}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			if err := tc.cfg.Validate(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
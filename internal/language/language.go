@@ -0,0 +1,131 @@
+/*
+Package language describes the languages commit messages can be written in.
+
+Languages are identified by BCP 47 tags, e.g. "de", "sv-SE" or "en-GB". Only
+the syntax of a tag is validated, so any language known by the model can be
+used, but the languages listed here get guidance on the mood of the subject
+and on capitalization.
+*/
+package language
+
+import (
+	"fmt"
+	"strings"
+)
+
+// English is the default language.
+const English = "en"
+
+// Language is a parsed BCP 47 tag.
+type Language struct {
+	// Tag is the canonical tag, e.g. "sv-SE".
+	Tag string
+
+	// Base is the primary language subtag, e.g. "sv".
+	Base string
+
+	// Name is the English name of the language, e.g. "Swedish", or the tag
+	// if the language is not known.
+	Name string
+
+	// Mood describes the grammatical mood of the subject, e.g. "imperative
+	// mood (e.g., "Add", "Fix", "Change")".
+	Mood string
+
+	// CapitalizesNouns is true if nouns are capitalized, e.g. in German. The
+	// first letter of a subject is then never lowercased.
+	CapitalizesNouns bool
+}
+
+type known struct {
+	name             string
+	mood             string
+	capitalizesNouns bool
+}
+
+//nolint:gochecknoglobals
+var languages = map[string]known{
+	"da": {name: "Danish", mood: `imperative mood (e.g., "Tilføj", "Ret", "Ændr")`},
+	"de": {name: "German", mood: `imperative mood (e.g., "Füge hinzu", "Behebe", "Ändere")`, capitalizesNouns: true},
+	"en": {name: "English", mood: `imperative mood (e.g., "Add", "Fix", "Change")`},
+	"es": {name: "Spanish", mood: `imperative mood (e.g., "Añade", "Corrige", "Cambia")`},
+	"fi": {name: "Finnish", mood: `imperative mood (e.g., "Lisää", "Korjaa", "Muuta")`},
+	"fr": {name: "French", mood: `infinitive (e.g., "Ajouter", "Corriger", "Modifier")`},
+	"it": {name: "Italian", mood: `imperative mood (e.g., "Aggiungi", "Correggi", "Modifica")`},
+	"nb": {name: "Norwegian Bokmål", mood: `imperative mood (e.g., "Legg til", "Fiks", "Endre")`},
+	"nl": {name: "Dutch", mood: `imperative mood (e.g., "Voeg toe", "Herstel", "Wijzig")`},
+	"no": {name: "Norwegian", mood: `imperative mood (e.g., "Legg til", "Fiks", "Endre")`},
+	"pl": {name: "Polish", mood: `imperative mood (e.g., "Dodaj", "Napraw", "Zmień")`},
+	"pt": {name: "Portuguese", mood: `imperative mood (e.g., "Adiciona", "Corrige", "Altera")`},
+	"sv": {name: "Swedish", mood: `imperative mood (e.g., "Lägg till", "Fixa", "Ändra")`},
+}
+
+// Parse parses a BCP 47 tag. An empty tag is English.
+func Parse(tag string) (Language, error) {
+	if tag == "" {
+		tag = English
+	}
+
+	subtags := strings.Split(tag, "-")
+
+	for i, subtag := range subtags {
+		if !isAlphanumeric(subtag) || len(subtag) == 0 || len(subtag) > 8 {
+			return Language{}, fmt.Errorf("invalid language tag %q", tag)
+		}
+
+		switch {
+		case i == 0:
+			if len(subtag) < 2 || len(subtag) > 3 || !isLetters(subtag) {
+				return Language{}, fmt.Errorf("invalid language tag %q: %q is not a language", tag, subtag)
+			}
+
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 2 && isLetters(subtag):
+			subtags[i] = strings.ToUpper(subtag)
+		case len(subtag) == 4 && isLetters(subtag):
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+
+	lang := Language{
+		Tag:  strings.Join(subtags, "-"),
+		Base: subtags[0],
+		Name: strings.Join(subtags, "-"),
+		Mood: "imperative mood",
+	}
+
+	if k, ok := languages[lang.Base]; ok {
+		lang.Name = k.name
+		lang.Mood = k.mood
+		lang.CapitalizesNouns = k.capitalizesNouns
+	}
+
+	return lang, nil
+}
+
+// IsEnglish returns true for all variants of English.
+func (l Language) IsEnglish() bool {
+	return l.Base == English
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,55 @@
+package language_test
+
+import (
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/language"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		tag      string
+		expected language.Language
+	}{
+		{tag: "", expected: language.Language{Tag: "en", Base: "en", Name: "English"}},
+		{tag: "de", expected: language.Language{Tag: "de", Base: "de", Name: "German", CapitalizesNouns: true}},
+		{tag: "sv-se", expected: language.Language{Tag: "sv-SE", Base: "sv", Name: "Swedish"}},
+		{tag: "EN-gb", expected: language.Language{Tag: "en-GB", Base: "en", Name: "English"}},
+		{tag: "zh-hant-TW", expected: language.Language{Tag: "zh-Hant-TW", Base: "zh", Name: "zh-Hant-TW"}},
+		{tag: "es-419", expected: language.Language{Tag: "es-419", Base: "es", Name: "Spanish"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.tag, func(t *testing.T) {
+			lang, err := language.Parse(tc.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The mood is guidance for the model, only check that it is set.
+			if lang.Mood == "" {
+				t.Error("expected a mood")
+			}
+
+			lang.Mood = ""
+
+			if lang != tc.expected {
+				t.Errorf("got %+v, want %+v", lang, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tag := range []string{"german", "d", "1e", "de_DE", "de--DE", "sv-SE-"} {
+		tag := tag // capture range variable
+
+		t.Run(tag, func(t *testing.T) {
+			if _, err := language.Parse(tag); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -42,6 +42,10 @@ const (
 	// gpt-3.5-turbo is recomennded over the other GPT-3.5 model due to its
 	// (lowest) cost.
 	GPT3_5Turbo aiModel = "gpt-3.5-turbo"
+
+	// GPT4oMini - A small, fast and cheap model supporting structured
+	// outputs, see ChatCompletionRequestWithSchema.
+	GPT4oMini aiModel = "gpt-4o-mini"
 )
 
 // Coster is an interface that models can implement to calculate the cost of
@@ -61,11 +65,26 @@ func (m aiModel) Cost(totalTokens int) float64 {
 	case GPT3_5Turbo:
 		// $0.002 / 1K tokens
 		return float64(totalTokens) * 0.002 / 1000
+	case GPT4oMini:
+		// $0.00015 / 1K input tokens and $0.0006 / 1K output tokens. Without
+		// the split the cost is estimated with the price of output tokens.
+		return float64(totalTokens) * 0.0006 / 1000
 	default:
 		return 0.0
 	}
 }
 
+// usageCost returns the cost in dollars of a request with the prompt and
+// completion tokens, for models with different prices for input and output.
+func (m aiModel) usageCost(promptTokens, completionTokens int) float64 {
+	switch m {
+	case GPT4oMini:
+		return (float64(promptTokens)*0.00015 + float64(completionTokens)*0.0006) / 1000
+	default:
+		return calculateCost(promptTokens+completionTokens, m)
+	}
+}
+
 // aiRole defines the role of the message. Typically a conversation is
 // formatted with a system message first, followed by alternating user and
 // assistant messages.
@@ -109,15 +128,51 @@ type Message struct {
 // See more about temperature here:
 // https://platform.openai.com/docs/quickstart/adjust-your-settings
 func (c *Client) ChatCompletionRequest(ctx context.Context, messages []Message, model aiModel, temperature float32) (ChatCompletionResponse, error) {
-	if temperature < 0 || temperature > 1 {
-		return ChatCompletionResponse{}, fmt.Errorf("temperature must be between 0 and 1 (inclusive), got %f", temperature)
-	}
+	return c.chatCompletionRequest(ctx, chatCompletionRequest{
+		Model:       string(model),
+		Messages:    messages,
+		Temperature: temperature,
+	})
+}
+
+// JSONSchema is the schema the response must follow, see
+// https://platform.openai.com/docs/guides/structured-outputs.
+type JSONSchema struct {
+	// Name identifies the schema, e.g. "commit_message".
+	Name string `json:"name"`
+
+	// Schema is the JSON schema. In strict mode all properties must be
+	// required and additional properties not allowed.
+	Schema json.RawMessage `json:"schema"`
+
+	// Strict makes the model always follow the schema.
+	Strict bool `json:"strict"`
+}
+
+// ChatCompletionRequestWithSchema does a request like ChatCompletionRequest,
+// where each message in the response is JSON following the schema. The model
+// must support structured outputs, e.g. GPT4oMini. The log probabilities of
+// the tokens are returned in the response.
+func (c *Client) ChatCompletionRequestWithSchema(ctx context.Context, messages []Message, model aiModel, temperature float32, schema JSONSchema) (ChatCompletionResponse, error) {
+	return c.chatCompletionRequest(ctx, chatCompletionRequest{
+		Model:       string(model),
+		Messages:    messages,
+		Temperature: temperature,
+		ResponseFormat: &responseFormat{
+			Type:       "json_schema",
+			JSONSchema: &schema,
+		},
+		Logprobs: true,
+	})
+}
 
-	requestBody := chatCompletionRequest{
-		Model:    string(model),
-		Messages: messages,
+func (c *Client) chatCompletionRequest(ctx context.Context, requestBody chatCompletionRequest) (ChatCompletionResponse, error) {
+	if requestBody.Temperature < 0 || requestBody.Temperature > 1 {
+		return ChatCompletionResponse{}, fmt.Errorf("temperature must be between 0 and 1 (inclusive), got %f", requestBody.Temperature)
 	}
 
+	model := aiModel(requestBody.Model)
+
 	requestBytes, err := json.Marshal(requestBody)
 	if err != nil {
 		return ChatCompletionResponse{}, fmt.Errorf("could not marshal body: %w", err)
@@ -149,11 +204,13 @@ func (c *Client) ChatCompletionRequest(ctx context.Context, messages []Message,
 		return ChatCompletionResponse{}, fmt.Errorf("could not decode response: %w", err)
 	}
 
-	cost := calculateCost(cResponse.Usage.TotalTokens, model)
+	cost := model.usageCost(cResponse.Usage.PromptTokens, cResponse.Usage.CompletionTokens)
 	answers := []string{}
+	logprobs := [][]float64{}
 
 	for _, choice := range cResponse.Choices {
 		answers = append(answers, choice.Content())
+		logprobs = append(logprobs, choice.TokenLogprobs())
 	}
 
 	return ChatCompletionResponse{
@@ -161,13 +218,21 @@ func (c *Client) ChatCompletionRequest(ctx context.Context, messages []Message,
 		Model:    model,
 		Cost:     cost,
 		Messages: answers,
+		Logprobs: logprobs,
 	}, nil
 }
 
 type chatCompletionRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float32   `json:"temperature"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    float32         `json:"temperature"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+	Logprobs       bool            `json:"logprobs,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
 }
 
 type rawChatCompletionUsageResponse struct {
@@ -181,10 +246,20 @@ type rawChatCompletionMessageResponse struct {
 	Content string `json:"content"`
 }
 
+type rawChatCompletionLogprobResponse struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+type rawChatCompletionLogprobsResponse struct {
+	Content []rawChatCompletionLogprobResponse `json:"content"`
+}
+
 type rawChatCompletionChoiceResponse struct {
-	Message      rawChatCompletionMessageResponse `json:"message"`
-	FinishReason string                           `json:"finish_reason"`
-	Index        int                              `json:"index"`
+	Message      rawChatCompletionMessageResponse   `json:"message"`
+	Logprobs     *rawChatCompletionLogprobsResponse `json:"logprobs"`
+	FinishReason string                             `json:"finish_reason"`
+	Index        int                                `json:"index"`
 }
 
 type rawChatCompletionResponse struct {
@@ -204,12 +279,29 @@ type ChatCompletionResponse struct {
 	Cost float64
 
 	Messages []string
+
+	// Logprobs are the log probabilities of the tokens of each message, if
+	// they were requested.
+	Logprobs [][]float64
 }
 
 func (c rawChatCompletionChoiceResponse) Content() string {
 	return c.Message.Content
 }
 
+func (c rawChatCompletionChoiceResponse) TokenLogprobs() []float64 {
+	if c.Logprobs == nil {
+		return nil
+	}
+
+	logprobs := make([]float64, 0, len(c.Logprobs.Content))
+	for _, token := range c.Logprobs.Content {
+		logprobs = append(logprobs, token.Logprob)
+	}
+
+	return logprobs
+}
+
 func calculateCost(totalTokens int, model Coster) float64 {
 	return model.Cost(totalTokens)
 }
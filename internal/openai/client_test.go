@@ -3,6 +3,7 @@ package openai_test
 import (
 	"context"
 	"embed"
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -129,3 +130,50 @@ func createFakeHTTPClient(t *testing.T, expectedStatusCode int, testdataFile str
 		},
 	}
 }
+
+func TestChatCompletionRequestWithSchemaSendsResponseFormat(t *testing.T) {
+	file, err := testdata.Open("testdata/chat_completion_response.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	var requestBody struct {
+		Model          string `json:"model"`
+		ResponseFormat struct {
+			Type       string `json:"type"`
+			JSONSchema struct {
+				Name   string          `json:"name"`
+				Schema json.RawMessage `json:"schema"`
+				Strict bool            `json:"strict"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+
+	httpClient := mockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+				t.Fatal(err)
+			}
+
+			return &http.Response{StatusCode: http.StatusOK, Body: file, Header: make(http.Header)}, nil
+		},
+	}
+
+	schema := openai.JSONSchema{Name: "answer", Schema: json.RawMessage(`{"type":"object"}`), Strict: true}
+
+	_, err = openai.NewClient(httpClient, "").ChatCompletionRequestWithSchema(context.Background(), []openai.Message{}, openai.GPT4oMini, 0.2, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requestBody.Model != "gpt-4o-mini" || requestBody.ResponseFormat.Type != "json_schema" {
+		t.Errorf("unexpected request %+v", requestBody)
+	}
+
+	if requestBody.ResponseFormat.JSONSchema.Name != "answer" || !requestBody.ResponseFormat.JSONSchema.Strict ||
+		string(requestBody.ResponseFormat.JSONSchema.Schema) != `{"type":"object"}` {
+		t.Errorf("unexpected schema %+v", requestBody.ResponseFormat.JSONSchema)
+	}
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/anthropic"
+	"github.com/philiplinell/commit-msg/internal/openai"
+)
+
+// Anthropic answers requests with the Anthropic Messages API. The answer is
+// requested as the input of a tool with the schema of the request.
+type Anthropic struct {
+	client *anthropic.Client
+}
+
+// NewAnthropic returns a provider using the client.
+func NewAnthropic(client *anthropic.Client) *Anthropic {
+	return &Anthropic{
+		client: client,
+	}
+}
+
+// Name returns "anthropic".
+func (a *Anthropic) Name() string {
+	return "anthropic"
+}
+
+// Complete does a messages request where the model must answer with the
+// tool.
+func (a *Anthropic) Complete(ctx context.Context, request Request) (Response, error) {
+	system, messages := anthropicMessages(request.Messages)
+
+	tool := anthropic.Tool{
+		Name:        request.Schema.Name,
+		Description: "Answer with " + strings.ReplaceAll(request.Schema.Name, "_", " ") + ".",
+		InputSchema: request.Schema.Schema,
+	}
+
+	maxTokens := request.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropic.DefaultMaxTokens
+	}
+
+	content, err := a.client.MessagesRequestWithTool(ctx, system, messages, anthropic.Claude3_5Haiku, request.Temperature, maxTokens, tool)
+	if err != nil {
+		return Response{}, fmt.Errorf("could not do MessagesRequest: %w", err)
+	}
+
+	// The input of a cut off tool use is not valid JSON.
+	if content.Truncated() {
+		return Response{Cost: content.Cost}, TruncatedError{Provider: a.Name(), MaxTokens: maxTokens}
+	}
+
+	input, ok := content.ToolInput(tool.Name)
+	if !ok {
+		return Response{Cost: content.Cost}, fmt.Errorf("%w: the tool was not used, stop reason %q", ErrUnexpectedResponse, content.StopReason)
+	}
+
+	return Response{
+		Content: string(input),
+		Cost:    content.Cost,
+	}, nil
+}
+
+// anthropicMessages maps the messages to the system prompt and the
+// messages of the Anthropic API. Consecutive messages with the same role are
+// merged, as the roles must alternate.
+func anthropicMessages(messages []openai.Message) (string, []anthropic.Message) {
+	var (
+		system []string
+		mapped []anthropic.Message
+	)
+
+	for _, message := range messages {
+		role := anthropic.UserRole
+
+		switch message.Role {
+		case openai.SystemRole:
+			system = append(system, message.Content)
+			continue
+		case openai.AssistantRole:
+			role = anthropic.AssistantRole
+		}
+
+		block := anthropic.TextBlock(message.Content)
+
+		if last := len(mapped) - 1; last >= 0 && mapped[last].Role == role {
+			mapped[last].Content = append(mapped[last].Content, block)
+			continue
+		}
+
+		mapped = append(mapped, anthropic.Message{Role: role, Content: []anthropic.ContentBlock{block}})
+	}
+
+	return strings.Join(system, "\n\n"), mapped
+}
@@ -0,0 +1,112 @@
+package provider_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/anthropic"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+func TestAnthropicComplete(t *testing.T) {
+	var requestBody struct {
+		System   string              `json:"system"`
+		Messages []anthropic.Message `json:"messages"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Error(err)
+		}
+
+		_, _ = w.Write([]byte(`{"content":[{"type":"tool_use","name":"commit_message","input":{"subject":"Add tests"}}],"usage":{"input_tokens":10,"output_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	p := provider.NewAnthropic(anthropic.NewClient(server.Client(), "", server.URL))
+
+	response, err := p.Complete(context.Background(), provider.Request{
+		Messages: []openai.Message{
+			{Role: openai.SystemRole, Content: "You write commit messages."},
+			{Role: openai.SystemRole, Content: "Use the imperative mood."},
+			{Role: openai.UserRole, Content: "example diff"},
+			{Role: openai.AssistantRole, Content: "example message"},
+			{Role: openai.UserRole, Content: "diff"},
+			{Role: openai.UserRole, Content: "more diff"},
+		},
+		Schema: openai.JSONSchema{Name: "commit_message", Schema: json.RawMessage(`{"type":"object"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Content != `{"subject":"Add tests"}` || response.Cost <= 0 {
+		t.Errorf("got %+v", response)
+	}
+
+	if requestBody.System != "You write commit messages.\n\nUse the imperative mood." {
+		t.Errorf("got system %q", requestBody.System)
+	}
+
+	roles := []anthropic.Role{anthropic.UserRole, anthropic.AssistantRole, anthropic.UserRole}
+	if len(requestBody.Messages) != len(roles) {
+		t.Fatalf("got %d messages, want %d", len(requestBody.Messages), len(roles))
+	}
+
+	for i, role := range roles {
+		if requestBody.Messages[i].Role != role {
+			t.Errorf("got role %q of message %d, want %q", requestBody.Messages[i].Role, i, role)
+		}
+	}
+
+	if len(requestBody.Messages[2].Content) != 2 {
+		t.Errorf("got %d content blocks, want the last user messages merged", len(requestBody.Messages[2].Content))
+	}
+}
+
+func TestAnthropicCompleteWithoutTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"I cannot."}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	p := provider.NewAnthropic(anthropic.NewClient(server.Client(), "", server.URL))
+
+	_, err := p.Complete(context.Background(), provider.Request{Schema: openai.JSONSchema{Name: "commit_message"}})
+	if !errors.Is(err, provider.ErrUnexpectedResponse) {
+		t.Errorf("got %v, want %v", err, provider.ErrUnexpectedResponse)
+	}
+}
+
+func TestAnthropicCompleteTruncated(t *testing.T) {
+	var requestBody struct {
+		MaxTokens int `json:"max_tokens"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Error(err)
+		}
+
+		_, _ = w.Write([]byte(`{"content":[{"type":"tool_use","name":"pull_request","input":{}}],"stop_reason":"max_tokens","usage":{"input_tokens":10,"output_tokens":4096}}`))
+	}))
+	defer server.Close()
+
+	p := provider.NewAnthropic(anthropic.NewClient(server.Client(), "", server.URL))
+
+	response, err := p.Complete(context.Background(), provider.Request{Schema: openai.JSONSchema{Name: "pull_request"}, MaxTokens: 4096})
+
+	var truncated provider.TruncatedError
+	if !errors.As(err, &truncated) || truncated.MaxTokens != 4096 || !errors.Is(err, provider.ErrUnexpectedResponse) {
+		t.Errorf("got %v, want a TruncatedError at 4096 tokens", err)
+	}
+
+	if requestBody.MaxTokens != 4096 || response.Cost <= 0 {
+		t.Errorf("got max tokens %d and cost %v, want the limit of the request and the cost", requestBody.MaxTokens, response.Cost)
+	}
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChainConfig configures a Chain.
+type ChainConfig struct {
+	// Timeout is the timeout of each provider. Zero means only the timeout
+	// of the context passed to Complete.
+	Timeout time.Duration
+
+	// Hedge is the delay after which the next provider is started while the
+	// previous one has not answered, taking whichever answers first. Zero
+	// disables hedging, the next provider is only started on failure.
+	Hedge time.Duration
+
+	// OnFailure, if set, is called with the name of a provider that failed
+	// and its error, e.g. to log it.
+	OnFailure func(name string, err error)
+}
+
+// Chain is a provider trying an ordered list of providers, e.g. OpenAI and
+// then Heuristic of package commitassist, until one answers.
+type Chain struct {
+	providers []Provider
+	cfg       ChainConfig
+}
+
+// NewChain returns a provider trying the providers in order.
+func NewChain(cfg ChainConfig, providers ...Provider) *Chain {
+	return &Chain{
+		providers: providers,
+		cfg:       cfg,
+	}
+}
+
+// Name returns the names of the providers separated by comma, e.g.
+// "openai,heuristic".
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.providers))
+	for _, p := range c.providers {
+		names = append(names, p.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+type attempt struct {
+	provider Provider
+	response Response
+	err      error
+}
+
+// Complete returns the first answer of the providers. A provider is started
+// when the previous one fails, times out or, with hedging, has not answered
+// within the delay. The providers still running when one answers are
+// cancelled.
+//
+// The Provider of the response is the name of the provider that answered and
+// the Cost is the combined cost of the providers that returned. If all fail,
+// the error wraps the error of the last one.
+func (c *Chain) Complete(ctx context.Context, request Request) (Response, error) {
+	if len(c.providers) == 0 {
+		return Response{}, fmt.Errorf("%w: no providers", ErrUnsupported)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The channel is buffered so that cancelled providers do not block.
+	attempts := make(chan attempt, len(c.providers))
+	started, running := 0, 0
+
+	var hedge <-chan time.Time
+
+	start := func() {
+		p := c.providers[started]
+		started++
+		running++
+
+		go func() {
+			attemptCtx := ctx
+
+			if c.cfg.Timeout > 0 {
+				var cancelAttempt context.CancelFunc
+
+				attemptCtx, cancelAttempt = context.WithTimeout(ctx, c.cfg.Timeout)
+				defer cancelAttempt()
+			}
+
+			response, err := p.Complete(attemptCtx, request)
+			attempts <- attempt{provider: p, response: response, err: err}
+		}()
+
+		hedge = nil
+		if c.cfg.Hedge > 0 && started < len(c.providers) {
+			hedge = time.After(c.cfg.Hedge)
+		}
+	}
+
+	start()
+
+	var (
+		cost    float64
+		lastErr error
+	)
+
+	for running > 0 {
+		select {
+		case <-hedge:
+			start()
+		case a := <-attempts:
+			running--
+			cost += a.response.Cost
+
+			if a.err == nil {
+				a.response.Cost = cost
+				if a.response.Provider == "" {
+					a.response.Provider = a.provider.Name()
+				}
+
+				return a.response, nil
+			}
+
+			lastErr = fmt.Errorf("%s: %w", a.provider.Name(), a.err)
+
+			if c.cfg.OnFailure != nil {
+				c.cfg.OnFailure(a.provider.Name(), a.err)
+			}
+
+			if ctx.Err() != nil {
+				return Response{Cost: cost}, fmt.Errorf("could not complete the request: %w", ctx.Err())
+			}
+
+			if started < len(c.providers) {
+				start()
+			}
+		}
+	}
+
+	return Response{Cost: cost}, fmt.Errorf("all providers failed, last error: %w", lastErr)
+}
@@ -0,0 +1,155 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+var errFailed = errors.New("failed")
+
+// fakeProvider answers with its name after the delay, unless the context is
+// done first.
+type fakeProvider struct {
+	name      string
+	delay     time.Duration
+	err       error
+	cost      float64
+	cancelled *int32
+}
+
+func (f fakeProvider) Name() string {
+	return f.name
+}
+
+func (f fakeProvider) Complete(ctx context.Context, _ provider.Request) (provider.Response, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		if f.cancelled != nil {
+			atomic.AddInt32(f.cancelled, 1)
+		}
+
+		return provider.Response{}, ctx.Err()
+	}
+
+	if f.err != nil {
+		return provider.Response{Cost: f.cost}, f.err
+	}
+
+	return provider.Response{Content: f.name, Cost: f.cost}, nil
+}
+
+func TestChain(t *testing.T) {
+	testCases := []struct {
+		name         string
+		cfg          provider.ChainConfig
+		providers    []provider.Provider
+		wantProvider string
+		wantCost     float64
+	}{
+		{
+			name:         "first answers",
+			providers:    []provider.Provider{fakeProvider{name: "a", cost: 1}, fakeProvider{name: "b", cost: 2}},
+			wantProvider: "a",
+			wantCost:     1,
+		},
+		{
+			name:         "next on error",
+			providers:    []provider.Provider{fakeProvider{name: "a", err: errFailed, cost: 1}, fakeProvider{name: "b", cost: 2}},
+			wantProvider: "b",
+			wantCost:     3,
+		},
+		{
+			name:         "next on timeout",
+			cfg:          provider.ChainConfig{Timeout: 10 * time.Millisecond},
+			providers:    []provider.Provider{fakeProvider{name: "a", delay: time.Second}, fakeProvider{name: "b"}},
+			wantProvider: "b",
+		},
+		{
+			name:         "hedged",
+			cfg:          provider.ChainConfig{Hedge: 10 * time.Millisecond},
+			providers:    []provider.Provider{fakeProvider{name: "a", delay: time.Second}, fakeProvider{name: "b"}},
+			wantProvider: "b",
+		},
+		{
+			name:         "hedged first answers",
+			cfg:          provider.ChainConfig{Hedge: 10 * time.Millisecond},
+			providers:    []provider.Provider{fakeProvider{name: "a", delay: 20 * time.Millisecond}, fakeProvider{name: "b", delay: time.Second}},
+			wantProvider: "a",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := provider.NewChain(tc.cfg, tc.providers...).Complete(context.Background(), provider.Request{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Provider != tc.wantProvider || response.Content != tc.wantProvider {
+				t.Errorf("got provider %q with content %q, want %q", response.Provider, response.Content, tc.wantProvider)
+			}
+
+			if response.Cost != tc.wantCost {
+				t.Errorf("got cost %v, want %v", response.Cost, tc.wantCost)
+			}
+		})
+	}
+}
+
+func TestChainCancelsTheSlowerProvider(t *testing.T) {
+	var cancelled int32
+
+	chain := provider.NewChain(
+		provider.ChainConfig{Hedge: 10 * time.Millisecond},
+		fakeProvider{name: "a", delay: time.Second, cancelled: &cancelled},
+		fakeProvider{name: "b"},
+	)
+
+	if _, err := chain.Complete(context.Background(), provider.Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&cancelled) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the slower provider was not cancelled")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestChainAllFail(t *testing.T) {
+	var failed []string
+
+	chain := provider.NewChain(
+		provider.ChainConfig{
+			OnFailure: func(name string, err error) {
+				failed = append(failed, name)
+			},
+		},
+		fakeProvider{name: "a", err: errFailed},
+		fakeProvider{name: "b", err: provider.ErrUnsupported},
+	)
+
+	if chain.Name() != "a,b" {
+		t.Errorf("got name %q, want %q", chain.Name(), "a,b")
+	}
+
+	_, err := chain.Complete(context.Background(), provider.Request{})
+	if !errors.Is(err, provider.ErrUnsupported) {
+		t.Errorf("got %v, want the error of the last provider", err)
+	}
+
+	if len(failed) != 2 || failed[0] != "a" || failed[1] != "b" {
+		t.Errorf("got failures %v, want [a b]", failed)
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/gemini"
+	"github.com/philiplinell/commit-msg/internal/openai"
+)
+
+// Gemini answers requests with the Gemini generateContent API.
+type Gemini struct {
+	client *gemini.Client
+}
+
+// NewGemini returns a provider using the client.
+func NewGemini(client *gemini.Client) *Gemini {
+	return &Gemini{
+		client: client,
+	}
+}
+
+// Name returns "gemini".
+func (g *Gemini) Name() string {
+	return "gemini"
+}
+
+// Complete does a generateContent request with the schema of the request. A
+// BlockedError is returned if the safety filters blocked the prompt or the
+// answer.
+func (g *Gemini) Complete(ctx context.Context, request Request) (Response, error) {
+	system, contents := geminiContents(request.Messages)
+
+	content, err := g.client.GenerateContentWithSchema(ctx, system, contents, gemini.Gemini2_0Flash, request.Temperature, request.Schema.Schema)
+	if err != nil {
+		return Response{}, fmt.Errorf("could not do GenerateContent: %w", err)
+	}
+
+	switch {
+	case content.BlockReason != "":
+		return Response{Cost: content.Cost}, BlockedError{Provider: g.Name(), Reason: content.BlockReason, Categories: content.BlockedCategories}
+	case isBlockedFinishReason(content.FinishReason):
+		return Response{Cost: content.Cost}, BlockedError{Provider: g.Name(), Reason: content.FinishReason, Categories: content.BlockedCategories}
+	case content.Text == "":
+		return Response{Cost: content.Cost}, fmt.Errorf("%w: no answer, finish reason %q", ErrUnexpectedResponse, content.FinishReason)
+	}
+
+	return Response{
+		Content: content.Text,
+		Cost:    content.Cost,
+	}, nil
+}
+
+// isBlockedFinishReason returns true if the answer was stopped by the safety
+// filters.
+func isBlockedFinishReason(reason string) bool {
+	switch reason {
+	case "SAFETY", "PROHIBITED_CONTENT", "BLOCKLIST", "SPII":
+		return true
+	default:
+		return false
+	}
+}
+
+// geminiContents maps the messages to the system instruction and the
+// contents of the Gemini API, where the assistant is the "model". Consecutive
+// messages with the same role are merged, as the roles must alternate.
+func geminiContents(messages []openai.Message) (string, []gemini.Content) {
+	var (
+		system   []string
+		contents []gemini.Content
+	)
+
+	for _, message := range messages {
+		role := gemini.UserRole
+
+		switch message.Role {
+		case openai.SystemRole:
+			system = append(system, message.Content)
+			continue
+		case openai.AssistantRole:
+			role = gemini.ModelRole
+		}
+
+		if last := len(contents) - 1; last >= 0 && contents[last].Role == role {
+			contents[last].Parts = append(contents[last].Parts, gemini.Part{Text: message.Content})
+			continue
+		}
+
+		contents = append(contents, gemini.TextContent(role, message.Content))
+	}
+
+	return strings.Join(system, "\n\n"), contents
+}
@@ -0,0 +1,109 @@
+package provider_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/gemini"
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+func newGemini(t *testing.T, response string, onRequest func(r *http.Request)) *provider.Gemini {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if onRequest != nil {
+			onRequest(r)
+		}
+
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return provider.NewGemini(gemini.NewClient(server.Client(), "", server.URL, gemini.APIKeyAuth))
+}
+
+func TestGeminiComplete(t *testing.T) {
+	var requestBody struct {
+		SystemInstruction gemini.Content   `json:"systemInstruction"`
+		Contents          []gemini.Content `json:"contents"`
+	}
+
+	p := newGemini(t, `{"candidates":[{"content":{"parts":[{"text":"{\"subject\":\"Add tests\"}"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5}}`, func(r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Error(err)
+		}
+	})
+
+	response, err := p.Complete(context.Background(), provider.Request{
+		Messages: []openai.Message{
+			{Role: openai.SystemRole, Content: "You write commit messages."},
+			{Role: openai.UserRole, Content: "example diff"},
+			{Role: openai.AssistantRole, Content: "example message"},
+			{Role: openai.UserRole, Content: "diff"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Content != `{"subject":"Add tests"}` || response.Cost <= 0 {
+		t.Errorf("got %+v", response)
+	}
+
+	if requestBody.SystemInstruction.Parts[0].Text != "You write commit messages." {
+		t.Errorf("got system instruction %+v", requestBody.SystemInstruction)
+	}
+
+	roles := []gemini.Role{gemini.UserRole, gemini.ModelRole, gemini.UserRole}
+	if len(requestBody.Contents) != len(roles) {
+		t.Fatalf("got %d contents, want %d", len(requestBody.Contents), len(roles))
+	}
+
+	for i, role := range roles {
+		if requestBody.Contents[i].Role != role {
+			t.Errorf("got role %q of content %d, want %q", requestBody.Contents[i].Role, i, role)
+		}
+	}
+}
+
+func TestGeminiCompleteBlocked(t *testing.T) {
+	testCases := []struct {
+		response string
+		reason   string
+	}{
+		{
+			response: `{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[{"category":"HARM_CATEGORY_HARASSMENT","blocked":true}]}}`,
+			reason:   "SAFETY",
+		},
+		{
+			response: `{"candidates":[{"content":{"parts":[]},"finishReason":"PROHIBITED_CONTENT"}]}`,
+			reason:   "PROHIBITED_CONTENT",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.reason, func(t *testing.T) {
+			_, err := newGemini(t, tc.response, nil).Complete(context.Background(), provider.Request{})
+
+			var blocked provider.BlockedError
+			if !errors.As(err, &blocked) || blocked.Reason != tc.reason || blocked.Provider != "gemini" {
+				t.Errorf("got %v, want BlockedError with reason %q", err, tc.reason)
+			}
+		})
+	}
+}
+
+func TestGeminiCompleteWithoutAnswer(t *testing.T) {
+	_, err := newGemini(t, `{"candidates":[{"content":{"parts":[]},"finishReason":"MAX_TOKENS"}]}`, nil).Complete(context.Background(), provider.Request{})
+	if !errors.Is(err, provider.ErrUnexpectedResponse) {
+		t.Errorf("got %v, want %v", err, provider.ErrUnexpectedResponse)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Package provider abstracts the services answering the prompts of commitassist,
+e.g. the OpenAI API.
+
+A provider gets a conversation (a system message followed by alternating user
+and assistant messages) and answers with JSON following a schema. A Chain
+combines providers, trying the next one when a provider fails.
+*/
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/openai"
+)
+
+var (
+	// ErrUnsupported is returned when the provider cannot answer the
+	// request, e.g. a schema it does not know.
+	ErrUnsupported = errors.New("not supported by the provider")
+
+	// ErrUnexpectedResponse is returned when the response of the service
+	// could not be used.
+	ErrUnexpectedResponse = errors.New("unexpected response")
+)
+
+// BlockedError is returned when the safety filters of the service blocked the
+// request or the answer. It is not a sign that the model is unsure, trying
+// again with the same diff gives the same result.
+type BlockedError struct {
+	// Provider is the name of the provider, e.g. "gemini".
+	Provider string
+
+	// Reason is why it was blocked, e.g. "SAFETY".
+	Reason string
+
+	// Categories are the harm categories it was blocked for, if known.
+	Categories []string
+}
+
+func (e BlockedError) Error() string {
+	if len(e.Categories) == 0 {
+		return fmt.Sprintf("blocked by %s: %s", e.Provider, e.Reason)
+	}
+
+	return fmt.Sprintf("blocked by %s: %s (%s)", e.Provider, e.Reason, strings.Join(e.Categories, ", "))
+}
+
+// TruncatedError is returned when the answer was cut off at the maximum
+// number of tokens, see Request.MaxTokens. It wraps ErrUnexpectedResponse.
+type TruncatedError struct {
+	// Provider is the name of the provider, e.g. "anthropic".
+	Provider string
+
+	// MaxTokens is the limit the answer was cut off at.
+	MaxTokens int
+}
+
+func (e TruncatedError) Error() string {
+	return fmt.Sprintf("the answer of %s was cut off at %d tokens", e.Provider, e.MaxTokens)
+}
+
+func (e TruncatedError) Unwrap() error {
+	return ErrUnexpectedResponse
+}
+
+// Request is a conversation to answer.
+type Request struct {
+	// Messages are the system message followed by alternating user and
+	// assistant messages, ending with a user message.
+	Messages []openai.Message
+
+	// Schema is the JSON schema of the answer.
+	Schema openai.JSONSchema
+
+	// Temperature is between 0 and 1, lower is more deterministic.
+	Temperature float32
+
+	// MaxTokens limits the answer for providers that require a limit, e.g.
+	// anthropic. Their default is used if zero.
+	MaxTokens int
+}
+
+// Response is the answer to a Request.
+type Response struct {
+	// Content is the JSON answer, following the schema of the request.
+	Content string
+
+	// Logprobs are the log probabilities of the tokens of the answer, if
+	// the provider returns them.
+	Logprobs []float64
+
+	// Cost is the cost of the request in dollars.
+	Cost float64
+
+	// Provider is the name of the provider that answered, set by Chain. It
+	// is empty if the provider answered directly.
+	Provider string
+}
+
+// Provider answers requests.
+type Provider interface {
+	// Name identifies the provider, e.g. "openai".
+	Name() string
+
+	Complete(ctx context.Context, request Request) (Response, error)
+}
+
+// OpenAI answers requests with the OpenAI chat completion API.
+type OpenAI struct {
+	client *openai.Client
+}
+
+// NewOpenAI returns a provider using the client.
+func NewOpenAI(client *openai.Client) *OpenAI {
+	return &OpenAI{
+		client: client,
+	}
+}
+
+// Name returns "openai".
+func (o *OpenAI) Name() string {
+	return "openai"
+}
+
+// Complete does a chat completion request with structured outputs.
+func (o *OpenAI) Complete(ctx context.Context, request Request) (Response, error) {
+	content, err := o.client.ChatCompletionRequestWithSchema(ctx, request.Messages, openai.GPT4oMini, request.Temperature, request.Schema)
+	if err != nil {
+		return Response{}, fmt.Errorf("could not do ChatCompletionRequest: %w", err)
+	}
+
+	if len(content.Messages) != 1 {
+		return Response{}, fmt.Errorf("%w: got %d messages", ErrUnexpectedResponse, len(content.Messages))
+	}
+
+	response := Response{
+		Content: content.Messages[0],
+		Cost:    content.Cost,
+	}
+
+	if len(content.Logprobs) == 1 {
+		response.Logprobs = content.Logprobs[0]
+	}
+
+	return response, nil
+}
@@ -0,0 +1,74 @@
+package provider_test
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/openai"
+	"github.com/philiplinell/commit-msg/internal/provider"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+type mockHTTPClient struct {
+	DoFn func(req *http.Request) (*http.Response, error)
+}
+
+func (f mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return f.DoFn(req)
+}
+
+func newOpenAI(t *testing.T, body string) *provider.OpenAI {
+	t.Helper()
+
+	httpClient := mockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	return provider.NewOpenAI(openai.NewClient(httpClient, ""))
+}
+
+func TestOpenAIComplete(t *testing.T) {
+	body, err := testdata.ReadFile("testdata/chat_completion_response.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := newOpenAI(t, string(body)).Complete(context.Background(), provider.Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Content != `{"subject":"Add tests"}` {
+		t.Errorf("got content %q", response.Content)
+	}
+
+	if len(response.Logprobs) != 2 || response.Logprobs[0] != -0.5 || response.Logprobs[1] != -0.25 {
+		t.Errorf("got logprobs %v", response.Logprobs)
+	}
+
+	if response.Cost <= 0 {
+		t.Errorf("got cost %v, want > 0", response.Cost)
+	}
+}
+
+func TestOpenAICompleteWithoutMessages(t *testing.T) {
+	body := `{"model":"gpt-4o-mini","usage":{"prompt_tokens":1,"completion_tokens":0,"total_tokens":1},"choices":[]}`
+
+	_, err := newOpenAI(t, body).Complete(context.Background(), provider.Request{})
+	if !errors.Is(err, provider.ErrUnexpectedResponse) {
+		t.Errorf("got %v, want %v", err, provider.ErrUnexpectedResponse)
+	}
+}
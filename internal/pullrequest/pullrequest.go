@@ -0,0 +1,222 @@
+/*
+Package pullrequest renders the title and Markdown description of a pull
+request.
+
+Without a template the description has the sections Summary, Changes,
+Testing and Risk. A repository can have a pull request template, e.g.
+.github/pull_request_template.md, whose sections are filled in instead, see
+Template.
+*/
+package pullrequest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TemplatePaths are the paths of a pull request template, relative to the
+// root of the repository, in the order they are looked for.
+//
+//nolint:gochecknoglobals
+var TemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+// Section is a section of the description.
+type Section struct {
+	// Heading is the text of the heading, without the leading #.
+	Heading string `json:"heading"`
+
+	// Content is the Markdown below the heading.
+	Content string `json:"content"`
+}
+
+// Description is a pull request.
+type Description struct {
+	Title   string
+	Summary string
+
+	// Changes are the notable changes, one per item.
+	Changes []string
+
+	// Testing is how the changes were or can be tested.
+	Testing string
+
+	// Risk is what could go wrong, e.g. when deploying the changes.
+	Risk string
+}
+
+// Markdown returns the description with a section each for the summary,
+// changes, testing and risk. Empty sections are left out.
+func (d Description) Markdown() string {
+	changes := make([]string, 0, len(d.Changes))
+	for _, change := range d.Changes {
+		if change = strings.TrimSpace(change); change != "" {
+			changes = append(changes, "- "+strings.TrimPrefix(change, "- "))
+		}
+	}
+
+	sections := []Section{
+		{Heading: "Summary", Content: d.Summary},
+		{Heading: "Changes", Content: strings.Join(changes, "\n")},
+		{Heading: "Testing", Content: d.Testing},
+		{Heading: "Risk", Content: d.Risk},
+	}
+
+	var b strings.Builder
+
+	for _, section := range sections {
+		content := strings.TrimSpace(section.Content)
+		if content == "" {
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&b, "## %s\n\n%s", section.Heading, content)
+	}
+
+	return b.String()
+}
+
+// Template is a pull request template, split in sections at the Markdown
+// headings.
+type Template struct {
+	// Preamble is the text before the first heading.
+	Preamble string
+
+	Sections []TemplateSection
+}
+
+// TemplateSection is a section of a template.
+type TemplateSection struct {
+	// Line is the heading line, e.g. "## Motivation".
+	Line string
+
+	Section
+}
+
+// ParseTemplate splits the template at the headings. Headings in fenced code
+// blocks are ignored.
+func ParseTemplate(template string) Template {
+	var (
+		parsed  Template
+		content []string
+		fenced  bool
+	)
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(content, "\n"))
+		content = nil
+
+		if len(parsed.Sections) == 0 {
+			parsed.Preamble = text
+			return
+		}
+
+		parsed.Sections[len(parsed.Sections)-1].Content = text
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(template, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		}
+
+		if !fenced && isHeading(trimmed) {
+			flush()
+
+			parsed.Sections = append(parsed.Sections, TemplateSection{
+				Line:    trimmed,
+				Section: Section{Heading: strings.TrimSpace(strings.TrimLeft(trimmed, "#"))},
+			})
+
+			continue
+		}
+
+		content = append(content, line)
+	}
+
+	flush()
+
+	return parsed
+}
+
+// isHeading returns true for an ATX heading, e.g. "## Testing".
+func isHeading(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+
+	return level >= 1 && level <= 6 && (len(line) == level || line[level] == ' ')
+}
+
+// Headings returns the headings of the sections.
+func (t Template) Headings() []string {
+	headings := make([]string, 0, len(t.Sections))
+	for _, section := range t.Sections {
+		headings = append(headings, section.Heading)
+	}
+
+	return headings
+}
+
+// Fill returns the template with the content of the sections replaced by the
+// filled ones with the same heading, compared case-insensitively. Sections
+// that are not filled keep the content of the template, e.g. a checklist.
+func (t Template) Fill(filled []Section) string {
+	parts := []string{}
+
+	if t.Preamble != "" {
+		parts = append(parts, t.Preamble)
+	}
+
+	for _, section := range t.Sections {
+		content := section.Content
+
+		for _, f := range filled {
+			if strings.EqualFold(strings.TrimSpace(f.Heading), section.Heading) && strings.TrimSpace(f.Content) != "" {
+				content = strings.TrimSpace(f.Content)
+				break
+			}
+		}
+
+		if content == "" {
+			parts = append(parts, section.Line)
+			continue
+		}
+
+		parts = append(parts, section.Line+"\n\n"+content)
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// FindTemplate returns the first template of TemplatePaths in the directory
+// dir, and an empty string if there is none.
+func FindTemplate(dir string) (string, error) {
+	for _, p := range TemplatePaths {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("could not read the pull request template: %w", err)
+		}
+
+		return string(content), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,92 @@
+package pullrequest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/pullrequest"
+)
+
+func TestMarkdown(t *testing.T) {
+	testCases := []struct {
+		description pullrequest.Description
+		expected    string
+	}{
+		{
+			description: pullrequest.Description{
+				Summary: "Add user search.",
+				Changes: []string{"Add the search package", "- Add the /users endpoint", " "},
+				Testing: "Unit tests.",
+				Risk:    "Low.",
+			},
+			expected: "## Summary\n\nAdd user search.\n\n## Changes\n\n- Add the search package\n- Add the /users endpoint\n\n## Testing\n\nUnit tests.\n\n## Risk\n\nLow.",
+		},
+		{
+			description: pullrequest.Description{Summary: "Fix a typo."},
+			expected:    "## Summary\n\nFix a typo.",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			if got := tc.description.Markdown(); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	content, err := pullrequest.FindTemplate("testdata/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := pullrequest.ParseTemplate(content)
+
+	if got := strings.Join(template.Headings(), "|"); got != "Description|How was it tested?|Checklist" {
+		t.Fatalf("got headings %q", got)
+	}
+
+	filled := template.Fill([]pullrequest.Section{
+		{Heading: "description", Content: "Add user search."},
+		{Heading: "How was it tested?", Content: "Unit tests."},
+		{Heading: "Checklist", Content: ""},
+	})
+
+	expected := `<!-- Describe the change. -->
+
+## Description
+
+Add user search.
+
+## How was it tested?
+
+Unit tests.
+
+## Checklist
+
+- [ ] Tests added
+- [ ] Documentation updated`
+
+	if filled != expected {
+		t.Errorf("got %q, want %q", filled, expected)
+	}
+}
+
+func TestParseTemplateIgnoresCodeBlocks(t *testing.T) {
+	template := pullrequest.ParseTemplate("## Usage\n\n```sh\n# not a heading\n```\n\n#hashtag")
+
+	if len(template.Sections) != 1 || !strings.Contains(template.Sections[0].Content, "# not a heading") {
+		t.Errorf("got %+v, want one section", template.Sections)
+	}
+}
+
+func TestFindTemplateMissing(t *testing.T) {
+	content, err := pullrequest.FindTemplate(t.TempDir())
+	if err != nil || content != "" {
+		t.Errorf("got %q, %v, want no template", content, err)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Package rebase reads and writes the todo list of an interactive rebase, the
+file git-rebase-todo that "git rebase -i" opens in the sequence editor.
+
+Lines that are not understood are kept as they are, so a todo list that is
+parsed and written again is unchanged. Annotations are added as comments,
+which git ignores.
+*/
+package rebase
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of the todo list.
+const FileName = "git-rebase-todo"
+
+// Command is the command of a line of the todo list.
+type Command string
+
+const (
+	Pick   Command = "pick"
+	Reword Command = "reword"
+	Edit   Command = "edit"
+	Squash Command = "squash"
+	Fixup  Command = "fixup"
+	Drop   Command = "drop"
+)
+
+//nolint:gochecknoglobals
+var abbreviations = map[string]Command{
+	"p": Pick,
+	"r": Reword,
+	"e": Edit,
+	"s": Squash,
+	"f": Fixup,
+	"d": Drop,
+}
+
+// IsTodo reports if the file is the todo list of a rebase, e.g. when
+// commit-msg is the editor of git and is also asked to edit commit messages.
+func IsTodo(filename string) bool {
+	return filepath.Base(filename) == FileName
+}
+
+// Line is a line of the todo list.
+type Line struct {
+	// Command is empty for lines that are not a commit, e.g. comments,
+	// "exec" or "label".
+	Command Command
+
+	// Options of the command, e.g. "-C" of fixup.
+	Options []string
+
+	// Hash is the abbreviated hash of the commit.
+	Hash string
+
+	// Subject is the rest of the line, usually the subject of the commit.
+	Subject string
+
+	// raw is the line as read, written unless the line is changed.
+	raw     string
+	changed bool
+
+	// Comments are written after the line.
+	Comments []string
+}
+
+// String returns the line as it is written to the todo list, without the
+// comments.
+func (l Line) String() string {
+	if !l.changed {
+		return l.raw
+	}
+
+	fields := append([]string{string(l.Command)}, l.Options...)
+	fields = append(fields, l.Hash)
+
+	if l.Subject != "" {
+		fields = append(fields, l.Subject)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// IsCommit reports if the line is a command on a commit, e.g. pick.
+func (l Line) IsCommit() bool {
+	return l.Command != ""
+}
+
+// DefaultCommentChar starts the comments of the todo list unless
+// core.commentChar is set.
+const DefaultCommentChar = "#"
+
+// Todo is the todo list of a rebase.
+type Todo struct {
+	Lines []*Line
+
+	// CommentChar starts the comments, DefaultCommentChar if empty. It must
+	// be core.commentChar, or git reads the comments as commands.
+	CommentChar string
+}
+
+func (t Todo) commentChar() string {
+	if t.CommentChar == "" {
+		return DefaultCommentChar
+	}
+
+	return t.CommentChar
+}
+
+// Parse reads the todo list.
+func Parse(content string) Todo {
+	todo := Todo{}
+
+	for _, raw := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		todo.Lines = append(todo.Lines, parseLine(raw))
+	}
+
+	return todo
+}
+
+func parseLine(raw string) *Line {
+	line := &Line{raw: raw}
+
+	fields := strings.Fields(raw)
+	if len(fields) < 2 {
+		return line
+	}
+
+	command := Command(fields[0])
+	if abbreviation, ok := abbreviations[fields[0]]; ok {
+		command = abbreviation
+	}
+
+	switch command {
+	case Pick, Reword, Edit, Squash, Fixup, Drop:
+	default:
+		return line
+	}
+
+	i := 1
+	for i < len(fields) && strings.HasPrefix(fields[i], "-") {
+		i++
+	}
+
+	if i == len(fields) {
+		return line
+	}
+
+	line.Command = command
+	line.Options = fields[1:i]
+	line.Hash = fields[i]
+
+	// The subject is the rest of the line as is, with its spacing.
+	if rest := strings.TrimSpace(raw); i+1 < len(fields) {
+		for _, field := range fields[:i+1] {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, field))
+		}
+
+		line.Subject = rest
+	}
+
+	return line
+}
+
+// Commits returns the lines with a command on a commit.
+func (t Todo) Commits() []*Line {
+	commits := []*Line{}
+
+	for _, line := range t.Lines {
+		if line.IsCommit() {
+			commits = append(commits, line)
+		}
+	}
+
+	return commits
+}
+
+// IsLinear reports if all lines are commands on commits or comments, i.e. if
+// the commits can be reordered. Lines like "exec", "label" or "merge", e.g.
+// of "git rebase --rebase-merges", depend on the order.
+func (t Todo) IsLinear() bool {
+	for _, line := range t.Lines {
+		trimmed := strings.TrimSpace(line.raw)
+		if !line.IsCommit() && trimmed != "" && !strings.HasPrefix(trimmed, t.commentChar()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MoveAfter changes the command of the line to command, e.g. Fixup, and
+// moves it after target and the fixups and squashes already following it.
+// The target must be before the line, the order of the commits it is moved
+// past is kept.
+func (t *Todo) MoveAfter(line, target *Line, command Command) error {
+	from, to := t.index(line), t.index(target)
+	if from < 0 || to < 0 {
+		return fmt.Errorf("the line is not in the todo list")
+	}
+
+	if to >= from {
+		return fmt.Errorf("%s must be before %s", target.Hash, line.Hash)
+	}
+
+	line.Command = command
+	line.Options = nil
+	line.changed = true
+
+	lines := append(append([]*Line{}, t.Lines[:from]...), t.Lines[from+1:]...)
+
+	at := to + 1
+	for at < len(lines) && (lines[at].Command == Fixup || lines[at].Command == Squash) {
+		at++
+	}
+
+	t.Lines = append(lines[:at], append([]*Line{line}, lines[at:]...)...)
+
+	return nil
+}
+
+func (t Todo) index(line *Line) int {
+	for i, l := range t.Lines {
+		if l == line {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// String returns the todo list with the comments of the lines.
+func (t Todo) String() string {
+	var b strings.Builder
+
+	for _, line := range t.Lines {
+		b.WriteString(line.String())
+		b.WriteString("\n")
+
+		for _, comment := range line.Comments {
+			for _, l := range strings.Split(comment, "\n") {
+				b.WriteString(strings.TrimRight(t.commentChar()+" "+l, " "))
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return b.String()
+}
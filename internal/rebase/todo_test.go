@@ -0,0 +1,140 @@
+package rebase_test
+
+import (
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/rebase"
+)
+
+const todo = `pick 1111111 Add user search
+p 2222222 wip
+fixup -C 3333333 fixup! Add user search
+pick 4444444 Fix search by email
+
+# Rebase 0000000..4444444 onto 0000000 (4 commands)
+#
+# Commands:
+# p, pick <commit> = use commit
+`
+
+func TestParse(t *testing.T) {
+	parsed := rebase.Parse(todo)
+
+	if got := parsed.String(); got != todo {
+		t.Errorf("got %q, want the todo list unchanged", got)
+	}
+
+	commits := parsed.Commits()
+	if len(commits) != 4 {
+		t.Fatalf("got %d commits, want 4", len(commits))
+	}
+
+	testCases := []struct {
+		line    *rebase.Line
+		command rebase.Command
+		hash    string
+		subject string
+	}{
+		{line: commits[0], command: rebase.Pick, hash: "1111111", subject: "Add user search"},
+		{line: commits[1], command: rebase.Pick, hash: "2222222", subject: "wip"},
+		{line: commits[2], command: rebase.Fixup, hash: "3333333", subject: "fixup! Add user search"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.hash, func(t *testing.T) {
+			if tc.line.Command != tc.command || tc.line.Hash != tc.hash || tc.line.Subject != tc.subject {
+				t.Errorf("got %+v", tc.line)
+			}
+		})
+	}
+
+	if !parsed.IsLinear() {
+		t.Error("got not linear, want linear")
+	}
+}
+
+func TestMoveAfter(t *testing.T) {
+	parsed := rebase.Parse(todo)
+	commits := parsed.Commits()
+
+	commits[0].Comments = []string{"summary: adds search\nby name"}
+
+	if err := parsed.MoveAfter(commits[3], commits[0], rebase.Squash); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `pick 1111111 Add user search
+# summary: adds search
+# by name
+squash 4444444 Fix search by email
+p 2222222 wip
+fixup -C 3333333 fixup! Add user search
+
+# Rebase 0000000..4444444 onto 0000000 (4 commands)
+#
+# Commands:
+# p, pick <commit> = use commit
+`
+
+	if got := parsed.String(); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+
+	if err := parsed.MoveAfter(commits[0], commits[1], rebase.Fixup); err == nil {
+		t.Error("got no error, want an error for a target after the line")
+	}
+}
+
+func TestIsLinear(t *testing.T) {
+	parsed := rebase.Parse("label onto\npick 1111111 Add search\nmerge -C 2222222 feature\n")
+
+	if parsed.IsLinear() {
+		t.Error("got linear, want not linear")
+	}
+}
+
+func TestCommentChar(t *testing.T) {
+	testCases := []struct {
+		commentChar string
+		content     string
+		expected    string
+		linear      bool
+	}{
+		{
+			content:  "pick 1111111 Add search\n",
+			expected: "pick 1111111 Add search\n# summary: adds search\n",
+			linear:   true,
+		},
+		{
+			commentChar: ";",
+			content:     "pick 1111111 Add search\n; Rebase 0000000..1111111\n",
+			expected:    "pick 1111111 Add search\n; summary: adds search\n; Rebase 0000000..1111111\n",
+			linear:      true,
+		},
+		{
+			commentChar: ";",
+			content:     "pick 1111111 Add search\n# not a comment\n",
+			expected:    "pick 1111111 Add search\n; summary: adds search\n# not a comment\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.expected, func(t *testing.T) {
+			parsed := rebase.Parse(tc.content)
+			parsed.CommentChar = tc.commentChar
+			parsed.Commits()[0].Comments = []string{"summary: adds search"}
+
+			if got := parsed.String(); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+
+			if got := parsed.IsLinear(); got != tc.linear {
+				t.Errorf("got linear %t, want %t", got, tc.linear)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Package review looks for likely mistakes in a diff before it is committed,
+e.g. debug prints left in, TODOs, swallowed errors, leaked secrets or code
+changed without its tests.
+
+Check finds them with simple rules. The findings are given to the model as
+hints, and are the only findings when no model is used. A Policy decides if
+the findings block the commit.
+*/
+package review
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/diff"
+)
+
+// Severity is how serious a finding is.
+type Severity string
+
+const (
+	Info    Severity = "info"
+	Warning Severity = "warning"
+	Error   Severity = "error"
+)
+
+// ParseSeverity returns the severity of "info", "warning" or "error".
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(strings.ToLower(strings.TrimSpace(s))); severity {
+	case Info, Warning, Error:
+		return severity, nil
+	default:
+		return "", fmt.Errorf("invalid severity %q, must be %q, %q or %q", s, Info, Warning, Error)
+	}
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case Warning:
+		return 1
+	case Error:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports if the severity is as serious as other, or more.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// Category is the kind of a finding.
+type Category string
+
+const (
+	Debug         Category = "debug"
+	Todo          Category = "todo"
+	ErrorHandling Category = "error-handling"
+	Secret        Category = "secret"
+	Tests         Category = "tests"
+	Other         Category = "other"
+)
+
+// Finding is a likely mistake in the diff.
+type Finding struct {
+	Path string `json:"path"`
+
+	// Line is the line in the new file, zero if the finding is about the
+	// whole file.
+	Line int `json:"line"`
+
+	Severity Severity `json:"severity"`
+	Category Category `json:"category"`
+	Message  string   `json:"message"`
+}
+
+// String returns the finding as "path:line: severity: message (category)".
+func (f Finding) String() string {
+	location := f.Path
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+	}
+
+	return fmt.Sprintf("%s: %s: %s (%s)", location, f.Severity, f.Message, f.Category)
+}
+
+// Action is what to do with findings at or above the threshold.
+type Action string
+
+const (
+	// Block fails the pre-commit hook, so the commit is not made.
+	Block Action = "block"
+
+	// Warn only prints the findings.
+	Warn Action = "warn"
+)
+
+// Policy decides if the findings block the commit.
+type Policy struct {
+	// Threshold is the lowest severity that blocks the commit, Error if
+	// empty.
+	Threshold Severity `json:"threshold,omitempty"`
+
+	// Action is what to do with findings at or above the threshold, Block
+	// if empty.
+	Action Action `json:"action,omitempty"`
+}
+
+// Validate returns an error if the threshold or the action is invalid.
+func (p Policy) Validate() error {
+	if p.Threshold != "" {
+		if _, err := ParseSeverity(string(p.Threshold)); err != nil {
+			return err
+		}
+	}
+
+	switch p.Action {
+	case "", Block, Warn:
+		return nil
+	default:
+		return fmt.Errorf("invalid action %q, must be %q or %q", p.Action, Block, Warn)
+	}
+}
+
+// Blocks reports if the findings block the commit.
+func (p Policy) Blocks(findings []Finding) bool {
+	if p.Action == Warn {
+		return false
+	}
+
+	threshold := p.Threshold
+	if threshold == "" {
+		threshold = Error
+	}
+
+	for _, finding := range findings {
+		if finding.Severity.AtLeast(threshold) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//nolint:gochecknoglobals
+var lineRules = []struct {
+	category Category
+	severity Severity
+	regexp   *regexp.Regexp
+	message  string
+}{
+	{
+		category: Secret,
+		severity: Error,
+		regexp:   regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----|\bAKIA[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36}\b|\bsk-[A-Za-z0-9_-]{20,}|\bxox[baprs]-[A-Za-z0-9-]{10,}`),
+		message:  "looks like a secret, e.g. a private or API key",
+	},
+	{
+		category: Secret,
+		severity: Warning,
+		regexp:   regexp.MustCompile(`(?i)\b(api_?key|secret|token|passw(or)?d)\b["']?\s*[:=]+\s*["'][^"'\s]{8,}["']`),
+		message:  "assigns what looks like a hard-coded credential",
+	},
+	{
+		category: Debug,
+		severity: Warning,
+		regexp:   regexp.MustCompile(`\bconsole\.(log|debug)\(|\bdebugger;|\bpdb\.set_trace\(|\bbreakpoint\(\)|\bvar_dump\(|\bdd\(|\bSystem\.out\.println\(|^\s*println\(|\bdbg!\(|^\s*print\(`),
+		message:  "looks like a debug print",
+	},
+	{
+		category: Todo,
+		severity: Info,
+		regexp:   regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK)\b`),
+		message:  "adds a TODO",
+	},
+	{
+		category: ErrorHandling,
+		severity: Warning,
+		regexp:   regexp.MustCompile(`^\s*_\s*(,\s*_\s*)?=\s*\w.*\(|\bcatch\s*(\([^)]*\))?\s*\{\s*\}|\bexcept(\s+\w+)?\s*:\s*pass\b|if err != nil \{\s*\}`),
+		message:  "ignores or swallows an error",
+	},
+}
+
+// Check returns the findings of the rules in the diff. A rule is only
+// reported once per file, at the first added line matching it.
+func Check(files []diff.File) []Finding {
+	findings := []Finding{}
+
+	for _, file := range files {
+		if file.IsDeleted || file.IsBinary {
+			continue
+		}
+
+	rules:
+		for _, rule := range lineRules {
+			// Prints are expected in tests.
+			if rule.category == Debug && isTest(file.Path()) {
+				continue
+			}
+
+			for _, hunk := range file.Hunks {
+				numbers := hunk.LineNumbers()
+
+				for i, line := range hunk.Lines {
+					if line.Kind == diff.Added && rule.regexp.MatchString(line.Content) {
+						findings = append(findings, Finding{
+							Path:     file.Path(),
+							Line:     numbers[i],
+							Severity: rule.severity,
+							Category: rule.category,
+							Message:  rule.message,
+						})
+
+						continue rules
+					}
+				}
+			}
+		}
+	}
+
+	return append(findings, checkTests(files)...)
+}
+
+//nolint:gochecknoglobals
+var codeExtensions = []string{".go", ".py", ".js", ".ts", ".jsx", ".tsx", ".java", ".kt", ".rb", ".rs", ".cs", ".php", ".swift", ".c", ".cc", ".cpp"}
+
+// checkTests reports code changed without any test changes, and tests
+// removed without code changes.
+func checkTests(files []diff.File) []Finding {
+	code, tests := []diff.File{}, []diff.File{}
+
+	for _, file := range files {
+		switch {
+		case isTest(file.Path()):
+			tests = append(tests, file)
+		case isCode(file.Path()):
+			code = append(code, file)
+		}
+	}
+
+	switch {
+	case len(code) > 0 && len(tests) == 0:
+		added := 0
+		for _, file := range code {
+			a, _ := file.Stat()
+			added += a
+		}
+
+		// Small changes, e.g. a typo, do not need tests.
+		if added < 10 {
+			return nil
+		}
+
+		return []Finding{{
+			Path:     code[0].Path(),
+			Severity: Info,
+			Category: Tests,
+			Message:  "changes code without changing any tests",
+		}}
+	case len(code) == 0 && len(tests) > 0:
+		findings := []Finding{}
+
+		for _, file := range tests {
+			if _, removed := file.Stat(); file.IsDeleted || removed > 0 {
+				findings = append(findings, Finding{
+					Path:     file.Path(),
+					Severity: Warning,
+					Category: Tests,
+					Message:  "removes or changes tests without changing the code they test",
+				})
+			}
+		}
+
+		return findings
+	}
+
+	return nil
+}
+
+func isCode(p string) bool {
+	for _, ext := range codeExtensions {
+		if strings.HasSuffix(p, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTest(p string) bool {
+	name := p[strings.LastIndex(p, "/")+1:]
+	if i := strings.LastIndex(name, "."); i > 0 {
+		name = name[:i]
+	}
+
+	return strings.HasSuffix(name, "_test") || strings.HasSuffix(name, ".test") ||
+		strings.HasSuffix(name, ".spec") || strings.HasSuffix(name, "_spec") ||
+		strings.HasPrefix(name, "test_") || strings.Contains("/"+p, "/testdata/")
+}
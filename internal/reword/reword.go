@@ -0,0 +1,191 @@
+/*
+Package reword rewrites the messages of a linear range of commits ending at
+HEAD, keeping their trees and authors.
+
+The branch is saved to a backup ref before it is rewritten, see BackupRef,
+and backups are never overwritten, so every earlier history can be restored.
+*/
+package reword
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/git"
+)
+
+// BackupRefPrefix is the prefix of the refs pointing to branches before they
+// were rewritten.
+const BackupRefPrefix = "refs/commit-msg/reword/"
+
+// Commit is a commit of the range, with the suggested message.
+type Commit struct {
+	git.Revision
+
+	Message   string
+	Suggested string
+}
+
+// Commits returns the commits of the revision, e.g. "main..HEAD", oldest
+// first. The commits must be a linear history ending at head, without merge
+// or root commits.
+func Commits(ctx context.Context, repo *git.Repo, revision, head string) ([]Commit, error) {
+	revisions, err := repo.RevList(ctx, revision)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the commits: %w", err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("no commits in %q", revision)
+	}
+
+	if revisions[0].Hash != head {
+		return nil, fmt.Errorf("the range %q must end at HEAD", revision)
+	}
+
+	for i, rev := range revisions {
+		if len(rev.Parents) != 1 {
+			return nil, fmt.Errorf("could not reword %.7s, merge and root commits are not supported", rev.Hash)
+		}
+
+		if i+1 < len(revisions) && rev.Parents[0] != revisions[i+1].Hash {
+			return nil, fmt.Errorf("the history of %q is not linear", revision)
+		}
+	}
+
+	history, err := repo.Log(ctx, git.LogOptions{Revision: revision})
+	if err != nil {
+		return nil, fmt.Errorf("could not get the messages: %w", err)
+	}
+
+	messages := map[string]string{}
+	for _, commit := range history {
+		messages[commit.Hash] = commit.Message
+	}
+
+	commits := make([]Commit, 0, len(revisions))
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		commits = append(commits, Commit{
+			Revision: revisions[i],
+			Message:  messages[revisions[i].Hash],
+		})
+	}
+
+	return commits, nil
+}
+
+// CheckShared returns an error if the oldest commit, and so all commits after
+// it, is on a remote-tracking branch or a protected branch, or if the branch
+// itself is protected.
+func CheckShared(ctx context.Context, repo *git.Repo, branch, oldest string, isProtected func(branch string) bool) error {
+	refs, err := repo.RefsContaining(ctx, oldest)
+	if err != nil {
+		return fmt.Errorf("could not get the branches containing %.7s: %w", oldest, err)
+	}
+
+	shared := []string{}
+
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "refs/heads/")
+
+		switch {
+		case strings.HasPrefix(ref, "refs/remotes/"):
+			shared = append(shared, strings.TrimPrefix(ref, "refs/remotes/"))
+		case name != ref && isProtected(name):
+			shared = append(shared, name)
+		}
+	}
+
+	if len(shared) > 0 {
+		return fmt.Errorf("the commits are on %s", strings.Join(shared, ", "))
+	}
+
+	if isProtected(branch) {
+		return fmt.Errorf("the branch %s is protected", branch)
+	}
+
+	return nil
+}
+
+// BackupRef returns the ref the branch at head is saved to. The ref is unique
+// for each history of the branch.
+func BackupRef(branch, head string) string {
+	return BackupRefPrefix + branch + "/" + head
+}
+
+// Rewrite saves the branch to its backup ref, rewrites the commits with their
+// suggested messages, and moves the branch to the rewritten commits, unless
+// it was moved since head. It returns the backup ref. The branch is unchanged
+// if an error is returned.
+func Rewrite(ctx context.Context, repo *git.Repo, branch, head string, commits []Commit) (string, error) {
+	backup := BackupRef(branch, head)
+
+	// A backup of the same history may exist if it was restored and is
+	// rewritten again. It points to head, so it is kept.
+	if _, err := repo.RevParse(ctx, backup); err != nil {
+		if err := repo.CreateRef(ctx, backup, head, "commit-msg reword: backup"); err != nil {
+			return "", fmt.Errorf("could not create the backup ref: %w", err)
+		}
+	}
+
+	parent := commits[0].Parents[0]
+
+	for _, commit := range commits {
+		var err error
+
+		parent, err = repo.Reword(ctx, commit.Hash, parent, commit.Suggested+"\n")
+		if err != nil {
+			return "", fmt.Errorf("could not reword %.7s: %w", commit.Hash, err)
+		}
+	}
+
+	if err := repo.UpdateRef(ctx, "refs/heads/"+branch, parent, head, "commit-msg reword"); err != nil {
+		return "", fmt.Errorf("could not update %s: %w", branch, err)
+	}
+
+	return backup, nil
+}
+
+// SideBySide returns the lines of left and right in two columns of the
+// width, longer lines are wrapped.
+func SideBySide(left, right string, width int) []string {
+	leftLines := wrapLines(left, width)
+	rightLines := wrapLines(right, width)
+
+	lines := []string{}
+
+	for i := 0; i < len(leftLines) || i < len(rightLines); i++ {
+		l, r := "", ""
+
+		if i < len(leftLines) {
+			l = leftLines[i]
+		}
+
+		if i < len(rightLines) {
+			r = rightLines[i]
+		}
+
+		lines = append(lines, strings.TrimRight(fmt.Sprintf("  %-*s | %s", width, l, r), " "))
+	}
+
+	return lines
+}
+
+func wrapLines(s string, width int) []string {
+	lines := []string{}
+
+	for _, line := range strings.Split(s, "\n") {
+		runes := []rune(line)
+
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+
+		lines = append(lines, string(runes))
+	}
+
+	return lines
+}
@@ -0,0 +1,275 @@
+package reword_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/gittest"
+	"github.com/philiplinell/commit-msg/internal/reword"
+)
+
+func TestCommits(t *testing.T) {
+	r := gittest.New(t)
+	root := r.Commit("root", map[string]string{"a.txt": "a\n"})
+	first := r.Commit("first\n\nSigned-off-by: Jane Doe <jane@example.com>", map[string]string{"a.txt": "b\n"})
+	r.Git("checkout", "--quiet", "-b", "side", root)
+	r.Commit("side", map[string]string{"b.txt": "b\n"})
+	r.Git("checkout", "--quiet", "main")
+	r.Git("merge", "--quiet", "--no-edit", "side")
+	merge := r.Git("rev-parse", "HEAD")
+	r.Git("checkout", "--quiet", "-b", "linear", first)
+	second := r.Commit("second", map[string]string{"a.txt": "c\n"})
+
+	testCases := []struct {
+		name     string
+		revision string
+		head     string
+		expected []string
+		err      string
+	}{
+		{name: "linear", revision: root + "..linear", head: second, expected: []string{first, second}},
+		{name: "not at head", revision: root + ".." + first, head: second, err: "must end at HEAD"},
+		{name: "empty", revision: second + ".." + second, head: second, err: "no commits"},
+		{name: "merge", revision: first + "..main", head: merge, err: "merge and root commits"},
+		{name: "root", revision: first, head: first, err: "merge and root commits"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			commits, err := reword.Commits(context.Background(), git.New(r.Dir), tc.revision, tc.head)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(commits) != len(tc.expected) {
+				t.Fatalf("got %d commits, want %d", len(commits), len(tc.expected))
+			}
+
+			for i, commit := range commits {
+				if commit.Hash != tc.expected[i] {
+					t.Errorf("got commit %d %s, want %s", i, commit.Hash, tc.expected[i])
+				}
+
+				if want := r.Git("show", "--no-patch", "--format=%B", commit.Hash); commit.Message != want {
+					t.Errorf("got message %q, want %q", commit.Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckShared(t *testing.T) {
+	r := gittest.New(t)
+	first := r.Commit("first", nil)
+	r.Git("checkout", "--quiet", "-b", "feature")
+	second := r.Commit("second", nil)
+	r.Git("update-ref", "refs/remotes/origin/pushed", second)
+	r.Git("checkout", "--quiet", "-b", "release/1", first)
+	third := r.Commit("third", nil)
+	r.Git("checkout", "--quiet", "-b", "local", third)
+	fourth := r.Commit("fourth", nil)
+
+	isProtected := func(branch string) bool {
+		return branch == "main" || strings.HasPrefix(branch, "release/")
+	}
+
+	testCases := []struct {
+		name   string
+		branch string
+		oldest string
+		err    string
+	}{
+		{name: "on a protected branch", branch: "feature", oldest: first, err: "main"},
+		{name: "on a remote-tracking branch", branch: "feature", oldest: second, err: "origin/pushed"},
+		{name: "on a protected pattern", branch: "local", oldest: third, err: "release/1"},
+		{name: "the branch is protected", branch: "release/1", oldest: fourth, err: "release/1 is protected"},
+		{name: "local", branch: "local", oldest: fourth},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := reword.CheckShared(context.Background(), git.New(r.Dir), tc.branch, tc.oldest, isProtected)
+
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatal(err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	r := gittest.New(t)
+	base := r.Commit("base", map[string]string{"a.txt": "a\n"})
+	r.Commit("first", map[string]string{"a.txt": "b\n"})
+	r.Commit("second", map[string]string{"a.txt": "c\n"})
+
+	ctx := context.Background()
+	repo := git.New(r.Dir)
+
+	rewrite := func(messages ...string) (string, string) {
+		t.Helper()
+
+		head := r.Git("rev-parse", "HEAD")
+
+		commits, err := reword.Commits(ctx, repo, base+"..HEAD", head)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range commits {
+			commits[i].Suggested = messages[i]
+		}
+
+		backup, err := reword.Rewrite(ctx, repo, "main", head, commits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return head, backup
+	}
+
+	original, backup := rewrite("First", "Second")
+
+	if got := r.Git("log", "--format=%s", base+"..main"); got != "Second\nFirst" {
+		t.Errorf("got messages %q", got)
+	}
+
+	if got := r.Git("rev-parse", "main^{tree}"); got != r.Git("rev-parse", original+"^{tree}") {
+		t.Error("expected the tree to be unchanged")
+	}
+
+	rewritten, secondBackup := rewrite("First again", "Second again")
+
+	if backup == secondBackup {
+		t.Fatalf("expected a new backup ref, got %s twice", backup)
+	}
+
+	testCases := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: backup, expected: original},
+		{ref: secondBackup, expected: rewritten},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.ref, func(t *testing.T) {
+			if got := r.Git("rev-parse", tc.ref); got != tc.expected {
+				t.Errorf("got %s, want %s", got, tc.expected)
+			}
+		})
+	}
+
+	// Rewording the restored history again keeps its backup.
+	r.Git("reset", "--quiet", "--soft", backup)
+
+	if _, again := rewrite("First", "Second"); again != backup {
+		t.Errorf("got backup %s, want %s", again, backup)
+	}
+}
+
+func TestRewriteMovedBranch(t *testing.T) {
+	r := gittest.New(t)
+	base := r.Commit("base", nil)
+	head := r.Commit("first", nil)
+
+	ctx := context.Background()
+	repo := git.New(r.Dir)
+
+	commits, err := reword.Commits(ctx, repo, base+"..HEAD", head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits[0].Suggested = "First"
+
+	moved := r.Commit("concurrent", nil)
+
+	if _, err := reword.Rewrite(ctx, repo, "main", head, commits); err == nil {
+		t.Fatal("expected an error when the branch moved")
+	}
+
+	if got := r.Git("rev-parse", "main"); got != moved {
+		t.Errorf("got main at %s, want it unchanged at %s", got, moved)
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	testCases := []struct {
+		name        string
+		left, right string
+		width       int
+		expected    []string
+	}{
+		{
+			name:  "same lines",
+			left:  "Fix bug",
+			right: "Fix nil map",
+			width: 8,
+			expected: []string{
+				"  Fix bug  | Fix nil",
+				"           | map",
+			},
+		},
+		{
+			name:  "more lines on the right",
+			left:  "Fix",
+			right: "Fix\n\nbody",
+			width: 4,
+			expected: []string{
+				"  Fix  | Fix",
+				"       |",
+				"       | body",
+			},
+		},
+		{
+			name:  "wrapped",
+			left:  "abcdefghij",
+			right: "",
+			width: 4,
+			expected: []string{
+				"  abcd |",
+				"  efgh |",
+				"  ij   |",
+			},
+		},
+		{
+			name:     "runes",
+			left:     "åäöü",
+			right:    "ü",
+			width:    3,
+			expected: []string{"  åäö | ü", "  ü   |"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			got := reword.SideBySide(tc.left, tc.right, tc.width)
+
+			if strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.expected, "\n"))
+			}
+		})
+	}
+}
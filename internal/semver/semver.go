@@ -0,0 +1,233 @@
+/*
+Package semver recommends the next semantic version (https://semver.org) of a
+release from its commit messages.
+
+A breaking change (a "!" after the type or a BREAKING CHANGE footer) bumps
+the major version, a feat the minor version, and a fix or perf the patch
+version. Other conventional commit types, e.g. docs or chore, do not require
+a release. A message starting with a gitmoji bumps the part of the version of
+the gitmoji. Other messages cannot be classified, see Classify.
+
+Before 1.0.0 the public API is not stable, and a breaking change bumps the
+minor version instead of the major one.
+*/
+package semver
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/conventional"
+	"github.com/philiplinell/commit-msg/internal/gitmoji"
+)
+
+// Level is the part of the version to bump.
+type Level int
+
+const (
+	// None means no release is needed.
+	None Level = iota
+	Patch
+	Minor
+	Major
+)
+
+// ParseLevel returns the level of "none", "patch", "minor" or "major".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none", "":
+		return None, nil
+	case "patch":
+		return Patch, nil
+	case "minor":
+		return Minor, nil
+	case "major":
+		return Major, nil
+	default:
+		return None, fmt.Errorf("invalid level %q, must be none, patch, minor or major", s)
+	}
+}
+
+func (l Level) String() string {
+	switch l {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalJSON encodes the level as its name.
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// Version is a semantic version.
+type Version struct {
+	// Prefix is written before the version, e.g. "v" in "v1.2.3".
+	Prefix string
+
+	Major, Minor, Patch int
+
+	// Prerelease is e.g. "rc.1" in "1.2.3-rc.1".
+	Prerelease string
+}
+
+//nolint:gochecknoglobals
+var versionRegexp = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// Parse parses a version, e.g. "v1.2.3" or "1.2.3-rc.1". Build metadata is
+// ignored.
+func Parse(s string) (Version, error) {
+	matches := versionRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q", s)
+	}
+
+	// The parts are digits, validated by the regexp.
+	major, _ := strconv.Atoi(matches[2])
+	minor, _ := strconv.Atoi(matches[3])
+	patch, _ := strconv.Atoi(matches[4])
+
+	return Version{
+		Prefix:     matches[1],
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		Prerelease: matches[5],
+	}, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+
+	return s
+}
+
+// Less returns true if v has lower precedence than o. Prereleases are
+// compared as strings.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+
+	if v.Patch != o.Patch {
+		return v.Patch < o.Patch
+	}
+
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return false
+	case v.Prerelease == "":
+		return false
+	case o.Prerelease == "":
+		return true
+	default:
+		return v.Prerelease < o.Prerelease
+	}
+}
+
+// Bump returns the next version. Before 1.0.0 Major bumps the minor version.
+// The next version of a prerelease is the release, if the prerelease already
+// bumps the level, e.g. 1.3.0-rc.1 is followed by 1.3.0 for Minor.
+func (v Version) Bump(level Level) Version {
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+
+	if level == Major && v.Major == 0 {
+		level = Minor
+	}
+
+	if v.Prerelease != "" {
+		switch {
+		case level == None:
+			return v
+		case level == Patch,
+			level == Minor && v.Patch == 0,
+			level == Major && v.Minor == 0 && v.Patch == 0:
+			return next
+		}
+	}
+
+	switch level {
+	case Major:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case Minor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	case Patch:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	default:
+		return v
+	}
+}
+
+// Latest returns the highest version of the tags that are semantic versions,
+// and false if there is none. Prereleases are only included if
+// includePrerelease is true.
+func Latest(tags []string, includePrerelease bool) (Version, string, bool) {
+	var (
+		latest Version
+		tag    string
+		found  bool
+	)
+
+	for _, t := range tags {
+		v, err := Parse(t)
+		if err != nil || (v.Prerelease != "" && !includePrerelease) {
+			continue
+		}
+
+		if !found || latest.Less(v) {
+			latest, tag, found = v, t, true
+		}
+	}
+
+	return latest, tag, found
+}
+
+// Classify returns the level of the commit message and why, and false if
+// the message is neither a conventional commit nor starts with a gitmoji.
+func Classify(message string) (Level, string, bool) {
+	prefix, rest := gitmoji.Cut(strings.TrimSpace(message))
+
+	if commit, err := conventional.Parse(rest); err == nil {
+		if breaking := commit.BreakingChange(); breaking != "" {
+			return Major, "breaking change: " + breaking, true
+		}
+
+		switch commit.Type {
+		case conventional.Feat:
+			return Minor, "a new feature", true
+		case conventional.Fix:
+			return Patch, "a bug fix", true
+		case conventional.Perf:
+			return Patch, "a performance improvement", true
+		default:
+			return None, fmt.Sprintf("%s does not affect users", commit.Type), true
+		}
+	}
+
+	if prefix != "" {
+		if g, ok := gitmoji.Lookup(prefix); ok {
+			level, err := ParseLevel(g.Semver)
+			if err == nil {
+				return level, fmt.Sprintf("the gitmoji %s: %s", g.Code, strings.TrimSuffix(g.Description, ".")), true
+			}
+		}
+	}
+
+	return None, "", false
+}
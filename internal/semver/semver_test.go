@@ -0,0 +1,85 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/semver"
+)
+
+func TestBump(t *testing.T) {
+	testCases := []struct {
+		version  string
+		level    semver.Level
+		expected string
+	}{
+		{version: "v1.2.3", level: semver.Major, expected: "v2.0.0"},
+		{version: "v1.2.3", level: semver.Minor, expected: "v1.3.0"},
+		{version: "1.2.3", level: semver.Patch, expected: "1.2.4"},
+		{version: "1.2.3", level: semver.None, expected: "1.2.3"},
+		{version: "0.4.1", level: semver.Major, expected: "0.5.0"},
+		{version: "1.3.0-rc.1", level: semver.Minor, expected: "1.3.0"},
+		{version: "1.3.0-rc.1", level: semver.Major, expected: "2.0.0"},
+		{version: "2.0.0-rc.1", level: semver.Major, expected: "2.0.0"},
+		{version: "1.2.3+build.5", level: semver.Patch, expected: "1.2.4"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.version+" "+tc.level.String(), func(t *testing.T) {
+			v, err := semver.Parse(tc.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := v.Bump(tc.level).String(); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tags := []string{"v1.9.0", "latest", "v1.10.0", "v1.11.0-rc.1", "v1.2.0"}
+
+	if _, tag, ok := semver.Latest(tags, false); !ok || tag != "v1.10.0" {
+		t.Errorf("got %q, want %q", tag, "v1.10.0")
+	}
+
+	if _, tag, ok := semver.Latest(tags, true); !ok || tag != "v1.11.0-rc.1" {
+		t.Errorf("got %q, want %q", tag, "v1.11.0-rc.1")
+	}
+
+	if _, _, ok := semver.Latest([]string{"latest"}, true); ok {
+		t.Error("expected no version")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		message string
+		level   semver.Level
+		ok      bool
+	}{
+		{message: "feat(api)!: remove v1", level: semver.Major, ok: true},
+		{message: "fix: handle empty input\n\nBREAKING CHANGE: errors are returned", level: semver.Major, ok: true},
+		{message: "feat: add search", level: semver.Minor, ok: true},
+		{message: "fix: handle empty input", level: semver.Patch, ok: true},
+		{message: "perf: cache results", level: semver.Patch, ok: true},
+		{message: "docs: describe search", level: semver.None, ok: true},
+		{message: "✨ Add search", level: semver.Minor, ok: true},
+		{message: ":bug: Fix search", level: semver.Patch, ok: true},
+		{message: "Add search", level: semver.None, ok: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.message, func(t *testing.T) {
+			level, reason, ok := semver.Classify(tc.message)
+			if level != tc.level || ok != tc.ok {
+				t.Errorf("got %s (%q), %v, want %s, %v", level, reason, ok, tc.level, tc.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Package split makes the commits of a suggested split of the staged changes,
+see commitassist.SuggestSplit.
+*/
+package split
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/git"
+)
+
+// Reason is recorded in the reflog of the commits.
+const Reason = "commit-msg split"
+
+// Apply makes the commits of the split in order, by unstaging everything and
+// staging the units of each commit. It returns the hashes of the commits
+// made, also if it fails partway through. The staged changes are then lost
+// from the index, so the caller should save them before.
+func Apply(ctx context.Context, repo *git.Repo, units []diff.Unit, commits []commitassist.SplitCommit) ([]string, error) {
+	if err := repo.ResetIndex(ctx); err != nil {
+		return nil, fmt.Errorf("could not unstage the changes: %w", err)
+	}
+
+	hashes := make([]string, 0, len(commits))
+
+	for i, commit := range commits {
+		patch := make([]diff.Unit, 0, len(commit.Units))
+
+		for _, id := range commit.Units {
+			if id < 0 || id >= len(units) {
+				return hashes, fmt.Errorf("commit %d has no hunk %d", i+1, id)
+			}
+
+			patch = append(patch, units[id])
+		}
+
+		if err := repo.ApplyCached(ctx, diff.JoinUnits(patch)); err != nil {
+			return hashes, fmt.Errorf("could not stage commit %d: %w", i+1, err)
+		}
+
+		hash, err := repo.Commit(ctx, commit.Message, Reason)
+		if err != nil {
+			return hashes, fmt.Errorf("could not make commit %d: %w", i+1, err)
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
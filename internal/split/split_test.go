@@ -0,0 +1,129 @@
+package split_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/commitassist"
+	"github.com/philiplinell/commit-msg/internal/diff"
+	"github.com/philiplinell/commit-msg/internal/git"
+	"github.com/philiplinell/commit-msg/internal/gittest"
+	"github.com/philiplinell/commit-msg/internal/split"
+)
+
+// stage commits a.txt and b.txt and stages changes to both, and returns the
+// units of the staged diff, one per file.
+func stage(t *testing.T) (*gittest.Repo, []diff.Unit) {
+	t.Helper()
+
+	r := gittest.New(t)
+	r.Commit("Initial commit", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	r.WriteFile("a.txt", "a\nchanged\n")
+	r.WriteFile("b.txt", "b\nchanged\n")
+	r.Git("add", "--all")
+
+	gitDiff, err := git.New(r.Dir).StagedDiff(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	units := diff.SplitUnits(gitDiff)
+	if len(units) != 2 {
+		t.Fatalf("got %d units, want 2", len(units))
+	}
+
+	return r, units
+}
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		commits  []commitassist.SplitCommit
+		made     int
+		err      string
+		expected string
+	}{
+		{
+			name: "all",
+			commits: []commitassist.SplitCommit{
+				{Message: "Change b", Units: []int{1}},
+				{Message: "Change a", Units: []int{0}},
+			},
+			made:     2,
+			expected: "Change a\nChange b\nInitial commit",
+		},
+		{
+			name: "together",
+			commits: []commitassist.SplitCommit{
+				{Message: "Change a and b", Units: []int{0, 1}},
+			},
+			made:     1,
+			expected: "Change a and b\nInitial commit",
+		},
+		{
+			name: "hunk staged twice",
+			commits: []commitassist.SplitCommit{
+				{Message: "Change a", Units: []int{0}},
+				{Message: "Change a again", Units: []int{0}},
+				{Message: "Change b", Units: []int{1}},
+			},
+			made:     1,
+			err:      "could not stage commit 2",
+			expected: "Change a\nInitial commit",
+		},
+		{
+			name: "no such hunk",
+			commits: []commitassist.SplitCommit{
+				{Message: "Change a", Units: []int{0}},
+				{Message: "Change c", Units: []int{2}},
+			},
+			made:     1,
+			err:      "commit 2 has no hunk 2",
+			expected: "Change a\nInitial commit",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			r, units := stage(t)
+
+			hashes, err := split.Apply(context.Background(), git.New(r.Dir), units, tc.commits)
+
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatal(err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+
+			if len(hashes) != tc.made {
+				t.Errorf("got %d commits, want %d", len(hashes), tc.made)
+			}
+
+			if got := r.Git("log", "--format=%s"); got != tc.expected {
+				t.Errorf("got history %q, want %q", got, tc.expected)
+			}
+
+			if len(hashes) > 0 && r.Git("rev-parse", "HEAD") != hashes[len(hashes)-1] {
+				t.Error("expected HEAD at the last commit made")
+			}
+
+			if got := r.Git("reflog", "--format=%gs", "-n1", "HEAD"); len(hashes) > 0 && got != split.Reason {
+				t.Errorf("got reflog %q, want %q", got, split.Reason)
+			}
+
+			// The working tree is never changed, so all changes are either
+			// committed or left in it.
+			if got := r.Git("cat-file", "-p", ":a.txt") + r.Git("cat-file", "-p", ":b.txt"); tc.err == "" && got != "a\nchangedb\nchanged" {
+				t.Errorf("got index %q, want all changes committed", got)
+			}
+
+			if got := r.Git("status", "--porcelain"); tc.err != "" && got != "M b.txt" {
+				t.Errorf("got status %q, want the rest left in the working tree", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Package squash reads the messages of commits being squashed into one, and
+what of them should survive in the message of the result.
+
+Git concatenates the messages when squashing. In an interactive rebase the
+message file looks like this, where the messages of fixups are commented out:
+
+	# This is a combination of 2 commits.
+	# This is the 1st commit message:
+
+	Add user search
+
+	# This is the commit message #2:
+
+	Fix search by email
+
+With "git merge --squash" .git/SQUASH_MSG lists the commits like "git log":
+
+	Squashed commit of the following:
+
+	commit 4f1d2c3...
+	Author: Jane Doe <jane@example.com>
+	Date:   Mon May 1 10:00:00 2023 +0200
+
+	    Add user search
+*/
+package squash
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+// DefaultCommentChar is the comment char of git if core.commentChar is not
+// set.
+const DefaultCommentChar = "#"
+
+const (
+	// rebaseHeaderPrefix follows the comment char on the first line in an
+	// interactive rebase.
+	rebaseHeaderPrefix = " This is a combination of "
+	mergeHeader        = "Squashed commit of the following:"
+
+	// rebaseMessagePattern follows the comment char before each message in
+	// an interactive rebase, "# This is the 1st commit message:" or "# This
+	// is the commit message #2:". Skipped messages, of fixups, are
+	// commented out.
+	rebaseMessagePattern = ` This is the (?:\S+ commit message|commit message #\d+):$`
+)
+
+//nolint:gochecknoglobals
+var (
+	mergeCommitRegexp = regexp.MustCompile(`^commit ([0-9a-f]{7,64})\b`)
+
+	// referenceRegexp matches GitHub references, e.g. "#123", and Jira and
+	// Linear keys, e.g. "PROJ-1234".
+	referenceRegexp = regexp.MustCompile(`(?:^|[\s(\[,])(#[0-9]+|[A-Z][A-Z0-9]+-[0-9]+)\b`)
+
+	// nonIssuePrefixes look like Jira keys, e.g. "UTF-8", but are not.
+	nonIssuePrefixes = []string{"AES", "CVE", "HTTP", "ISO", "MD", "RFC", "SHA", "TLS", "UTF"}
+
+	// droppedTrailers identify a single commit, and do not apply to the
+	// result.
+	droppedTrailers = []string{"Change-Id"}
+)
+
+// Commit is a commit being squashed.
+type Commit struct {
+	// Hash is empty if it is not known, e.g. in an interactive rebase.
+	Hash    string
+	Message string
+}
+
+// Squash is the content of the message file of a squash.
+type Squash struct {
+	// Commits are the squashed commits, oldest first.
+	Commits []Commit
+
+	// Rebase is true for a squash in an interactive rebase. The squashed
+	// commits are then amended into HEAD, so the net diff is between HEAD^
+	// and the index.
+	Rebase bool
+}
+
+// Messages returns the messages of the commits, oldest first.
+func (s Squash) Messages() []string {
+	messages := make([]string, 0, len(s.Commits))
+	for _, commit := range s.Commits {
+		messages = append(messages, commit.Message)
+	}
+
+	return messages
+}
+
+// Parse reads the message file of a squash, in the format of an interactive
+// rebase or of .git/SQUASH_MSG. Any other content is a single message. Lines
+// starting with the comment char are comments, DefaultCommentChar is used if
+// it is empty. Comments and empty messages are left out.
+func Parse(content, commentChar string) Squash {
+	if commentChar == "" {
+		commentChar = DefaultCommentChar
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, commentChar+rebaseHeaderPrefix):
+			return Squash{Commits: parseRebase(lines, commentChar), Rebase: true}
+		case strings.TrimSpace(line) == mergeHeader:
+			return Squash{Commits: parseMerge(lines, commentChar)}
+		case strings.TrimSpace(line) != "" && !strings.HasPrefix(line, commentChar):
+			return Squash{Commits: appendCommit(nil, "", lines, commentChar)}
+		}
+	}
+
+	return Squash{}
+}
+
+func parseRebase(lines []string, commentChar string) []Commit {
+	messageRegexp := regexp.MustCompile("^" + regexp.QuoteMeta(commentChar) + rebaseMessagePattern)
+
+	commits := []Commit{}
+
+	var message []string
+
+	inMessage := false
+
+	for _, line := range lines {
+		if messageRegexp.MatchString(line) {
+			if inMessage {
+				commits = appendCommit(commits, "", message, commentChar)
+			}
+
+			inMessage, message = true, nil
+
+			continue
+		}
+
+		if inMessage {
+			message = append(message, line)
+		}
+	}
+
+	if inMessage {
+		commits = appendCommit(commits, "", message, commentChar)
+	}
+
+	return commits
+}
+
+// parseMerge reads the commits listed by "git merge --squash", which are
+// most recent first.
+func parseMerge(lines []string, commentChar string) []Commit {
+	commits := []Commit{}
+
+	var (
+		hash    string
+		message []string
+	)
+
+	started := false
+
+	for _, line := range lines {
+		if match := mergeCommitRegexp.FindStringSubmatch(line); match != nil {
+			if started {
+				commits = appendCommit(commits, hash, message, commentChar)
+			}
+
+			started, hash, message = true, match[1], nil
+
+			continue
+		}
+
+		// The message is indented, the headers, e.g. "Author:", are not.
+		if started && (strings.HasPrefix(line, "    ") || strings.TrimSpace(line) == "") {
+			message = append(message, strings.TrimPrefix(line, "    "))
+		}
+	}
+
+	if started {
+		commits = appendCommit(commits, hash, message, commentChar)
+	}
+
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+
+	return commits
+}
+
+func appendCommit(commits []Commit, hash string, lines []string, commentChar string) []Commit {
+	kept := []string{}
+
+	for _, line := range lines {
+		if !strings.HasPrefix(line, commentChar) {
+			kept = append(kept, line)
+		}
+	}
+
+	message := strings.TrimSpace(strings.Join(kept, "\n"))
+	if message == "" {
+		return commits
+	}
+
+	return append(commits, Commit{Hash: hash, Message: message})
+}
+
+// Trailers returns the trailers of the messages that should be kept in the
+// squashed message, e.g. "Co-authored-by" and "Refs", without duplicates.
+// Trailers identifying a single commit, like "Change-Id", are left out.
+func Trailers(messages []string) []trailer.Trailer {
+	trailers := []trailer.Trailer{}
+
+	for _, message := range messages {
+	trailers:
+		for _, t := range trailer.Parse(message) {
+			for _, key := range droppedTrailers {
+				if strings.EqualFold(t.Key, key) {
+					continue trailers
+				}
+			}
+
+			for _, existing := range trailers {
+				if existing.Equal(t) {
+					continue trailers
+				}
+			}
+
+			trailers = append(trailers, t)
+		}
+	}
+
+	return trailers
+}
+
+// References returns the issue references in the subjects and bodies of the
+// messages, e.g. "#123" and "PROJ-1234", without duplicates. References in
+// trailers are kept by Trailers.
+func References(messages []string) []string {
+	refs := []string{}
+	seen := map[string]bool{}
+
+	for _, message := range messages {
+		body, _ := trailer.Split(message)
+
+		for _, match := range referenceRegexp.FindAllStringSubmatch(body, -1) {
+			if !seen[match[1]] && !isStandard(match[1]) {
+				seen[match[1]] = true
+				refs = append(refs, match[1])
+			}
+		}
+	}
+
+	return refs
+}
+
+func isStandard(ref string) bool {
+	prefix, _, _ := strings.Cut(ref, "-")
+
+	for _, p := range nonIssuePrefixes {
+		if prefix == p {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package squash_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/squash"
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		commentChar string
+		expected    squash.Squash
+	}{
+		{
+			name: "rebase",
+			content: `# This is a combination of 3 commits.
+# This is the 1st commit message:
+
+Add user search
+
+Refs: PROJ-1
+
+# This is the commit message #2:
+
+Fix search by email
+
+# The commit message #3 will be skipped:
+
+# fixup! Add user search
+
+# Please enter the commit message for your changes.
+`,
+			expected: squash.Squash{
+				Commits: []squash.Commit{
+					{Message: "Add user search\n\nRefs: PROJ-1"},
+					{Message: "Fix search by email"},
+				},
+				Rebase: true,
+			},
+		},
+		{
+			name: "merge",
+			content: `Squashed commit of the following:
+
+commit 2222222222222222222222222222222222222222
+Author: Jane Doe <jane@example.com>
+Date:   Tue May 2 10:00:00 2023 +0200
+
+    Fix search by email
+    
+    Closes #12
+
+commit 1111111111111111111111111111111111111111
+Author: Jane Doe <jane@example.com>
+Date:   Mon May 1 10:00:00 2023 +0200
+
+    Add user search
+
+# Please enter the commit message for your changes.
+`,
+			expected: squash.Squash{
+				Commits: []squash.Commit{
+					{Hash: "1111111111111111111111111111111111111111", Message: "Add user search"},
+					{Hash: "2222222222222222222222222222222222222222", Message: "Fix search by email\n\nCloses #12"},
+				},
+			},
+		},
+		{
+			name:    "plain",
+			content: "Add user search\n\n# Please enter the commit message for your changes.\n",
+			expected: squash.Squash{
+				Commits: []squash.Commit{{Message: "Add user search"}},
+			},
+		},
+		{
+			name:     "empty",
+			content:  "# Please enter the commit message for your changes.\n",
+			expected: squash.Squash{},
+		},
+		{
+			name: "rebase with custom comment char",
+			content: `; This is a combination of 2 commits.
+; This is the 1st commit message:
+
+Add user search
+
+#12 is fixed by this
+
+; This is the commit message #2:
+
+Fix search by email
+
+; Please enter the commit message for your changes.
+`,
+			commentChar: ";",
+			expected: squash.Squash{
+				Commits: []squash.Commit{
+					{Message: "Add user search\n\n#12 is fixed by this"},
+					{Message: "Fix search by email"},
+				},
+				Rebase: true,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			got := squash.Parse(tc.content, tc.commentChar)
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %+v, want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	messages := []string{
+		"Add user search\n\nRefs: PROJ-1\nChange-Id: I1111\nSigned-off-by: Jane Doe <jane@example.com>",
+		"Fix search\n\nSigned-off-by: Jane Doe <jane@example.com>\nCo-authored-by: John Doe <john@example.com>",
+	}
+
+	expected := []trailer.Trailer{
+		{Key: "Refs", Value: "PROJ-1"},
+		{Key: "Signed-off-by", Value: "Jane Doe <jane@example.com>"},
+		{Key: "Co-authored-by", Value: "John Doe <john@example.com>"},
+	}
+
+	if got := squash.Trailers(messages); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	messages := []string{
+		"PROJ-12 Add user search (#34)\n\nEncode names as UTF-8.\n\nRefs: PROJ-1",
+		"Fix search\n\nFixes #34, ENG-5",
+	}
+
+	expected := []string{"PROJ-12", "#34", "ENG-5"}
+
+	if got := squash.References(messages); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
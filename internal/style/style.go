@@ -0,0 +1,270 @@
+/*
+Package style loads the styles of commit messages.
+
+A style is a Go text/template file (with the extension .tmpl) defining the
+following templates:
+
+	{{define "name"}}Terse{{end}}
+	{{define "description"}}Short, lower case subjects without a body.{{end}}
+	{{define "prompt"}}terse. Use a single lower case line{{end}}
+	{{define "example.1.diff"}}{{template "readme.diff"}}{{end}}
+	{{define "example.1.message"}}add readme{{end}}
+
+"prompt" is used in the system prompt after "The style of the commit message
+should be ". Any number of examples can be given, numbered from 1. The
+templates are executed with Data, and can use the templates defined by the
+built-in partials, e.g. "readme.diff".
+
+Messages in other languages than English are defined with the language as
+suffix, e.g. "example.1.message.de". They are used instead of the English
+message when Data.Language is the language.
+
+The built-in styles are embedded in the binary. Styles loaded from a
+directory replace built-in styles with the same name.
+*/
+package style
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed builtin/*.tmpl
+var builtin embed.FS
+
+const (
+	builtinDir   = "builtin"
+	partialsFile = "partials.tmpl"
+	extension    = ".tmpl"
+
+	nameTemplate        = "name"
+	descriptionTemplate = "description"
+	promptTemplate      = "prompt"
+)
+
+// Data is passed to the templates when they are executed.
+type Data struct {
+	// ConventionalCommit is true if the message should follow the
+	// conventional commit specification.
+	ConventionalCommit bool
+
+	// Language is the primary language subtag of the message, e.g. "de".
+	// Empty is English.
+	Language string
+}
+
+// Example is an example diff and the expected message.
+type Example struct {
+	Diff    string
+	Message string
+
+	// Language is the language of the message, empty for English.
+	Language string
+}
+
+// Style is an executed style template.
+type Style struct {
+	Name        string
+	Description string
+
+	// Prompt describes the style to the model.
+	Prompt string
+
+	Examples []Example
+
+	// Source is the file the style was loaded from.
+	Source string
+}
+
+// Registry holds the loaded styles.
+type Registry struct {
+	partials  *template.Template
+	templates map[string]*template.Template
+	sources   map[string]string
+}
+
+// Builtin returns a registry with the built-in styles.
+func Builtin() (*Registry, error) {
+	partials, err := template.ParseFS(builtin, path.Join(builtinDir, partialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse partials: %w", err)
+	}
+
+	r := &Registry{
+		partials:  partials,
+		templates: map[string]*template.Template{},
+		sources:   map[string]string{},
+	}
+
+	entries, err := fs.ReadDir(builtin, builtinDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read built-in styles: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == partialsFile {
+			continue
+		}
+
+		content, err := fs.ReadFile(builtin, path.Join(builtinDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read built-in style %q: %w", entry.Name(), err)
+		}
+
+		if err := r.add("builtin:"+entry.Name(), string(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// LoadDir loads the styles in the directory dir. A missing directory is not an
+// error.
+func (r *Registry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read styles directory %q: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != extension {
+			continue
+		}
+
+		filename := filepath.Join(dir, entry.Name())
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("could not read style %q: %w", filename, err)
+		}
+
+		if err := r.add(filename, string(content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) add(source, content string) error {
+	tmpl, err := r.partials.Clone()
+	if err != nil {
+		return fmt.Errorf("could not clone partials: %w", err)
+	}
+
+	tmpl, err = tmpl.New(source).Parse(content)
+	if err != nil {
+		return fmt.Errorf("could not parse style %q: %w", source, err)
+	}
+
+	for _, required := range []string{nameTemplate, descriptionTemplate, promptTemplate} {
+		if tmpl.Lookup(required) == nil {
+			return fmt.Errorf("style %q does not define %q", source, required)
+		}
+	}
+
+	name, err := execute(tmpl, nameTemplate, Data{})
+	if err != nil {
+		return fmt.Errorf("style %q: %w", source, err)
+	}
+
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("style %q: invalid name %q", source, name)
+	}
+
+	r.templates[name] = tmpl
+	r.sources[name] = source
+
+	return nil
+}
+
+// Names returns the names of the styles, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Has returns true if there is a style with the name.
+func (r *Registry) Has(name string) bool {
+	_, ok := r.templates[name]
+
+	return ok
+}
+
+// Get executes the style with the name.
+func (r *Registry) Get(name string, data Data) (Style, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return Style{}, fmt.Errorf("invalid style %q", name)
+	}
+
+	style := Style{Name: name, Source: r.sources[name]}
+
+	var err error
+
+	if style.Description, err = execute(tmpl, descriptionTemplate, data); err != nil {
+		return Style{}, err
+	}
+
+	if style.Prompt, err = execute(tmpl, promptTemplate, data); err != nil {
+		return Style{}, err
+	}
+
+	for i := 1; ; i++ {
+		diffName := "example." + strconv.Itoa(i) + ".diff"
+		messageName := "example." + strconv.Itoa(i) + ".message"
+
+		if tmpl.Lookup(diffName) == nil || tmpl.Lookup(messageName) == nil {
+			break
+		}
+
+		example := Example{}
+
+		if data.Language != "" && tmpl.Lookup(messageName+"."+data.Language) != nil {
+			messageName += "." + data.Language
+			example.Language = data.Language
+		}
+
+		if example.Diff, err = execute(tmpl, diffName, data); err != nil {
+			return Style{}, err
+		}
+
+		if example.Message, err = execute(tmpl, messageName, data); err != nil {
+			return Style{}, err
+		}
+
+		style.Examples = append(style.Examples, example)
+	}
+
+	return style, nil
+}
+
+func execute(tmpl *template.Template, name string, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("could not execute template %q: %w", name, err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
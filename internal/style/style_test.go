@@ -0,0 +1,179 @@
+package style_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/style"
+)
+
+func TestBuiltin(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"ConversationalAndCasual", "DescriptiveAndNeutral", "Gitmoji", "ListBased", "ProblemSolution", "RepoStyle"}
+	if !reflect.DeepEqual(styles.Names(), expected) {
+		t.Errorf("got %v, want %v", styles.Names(), expected)
+	}
+
+	for _, name := range expected {
+		s, err := styles.Get(name, style.Data{})
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if s.Description == "" || s.Prompt == "" {
+			t.Errorf("%s: missing description or prompt", name)
+		}
+
+		// RepoStyle uses the commits of the repository as examples.
+		if name != "RepoStyle" && len(s.Examples) != 1 {
+			t.Errorf("%s: got %d examples, want 1", name, len(s.Examples))
+		}
+
+		for _, example := range s.Examples {
+			if !strings.HasPrefix(example.Diff, "diff --git a/README.md b/README.md") {
+				t.Errorf("%s: unexpected example diff %q", name, example.Diff)
+			}
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := styles.LoadDir("testdata/styles"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !styles.Has("Terse") {
+		t.Fatalf("expected Terse to be loaded, got %v", styles.Names())
+	}
+
+	testCases := []struct {
+		data     style.Data
+		expected []string
+	}{
+		{data: style.Data{}, expected: []string{"add readme", "document usage"}},
+		{data: style.Data{ConventionalCommit: true}, expected: []string{"docs: add readme", "document usage"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			terse, err := styles.Get("Terse", tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			messages := []string{}
+			for _, example := range terse.Examples {
+				messages = append(messages, example.Message)
+			}
+
+			if !reflect.DeepEqual(messages, tc.expected) {
+				t.Errorf("got %q, want %q", messages, tc.expected)
+			}
+		})
+	}
+
+	listBased, err := styles.Get("ListBased", style.Data{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if listBased.Prompt != "a list of bullet points" || len(listBased.Examples) != 0 {
+		t.Errorf("expected the built-in ListBased to be replaced, got %+v", listBased)
+	}
+}
+
+func TestLoadDirMissingDirectory(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := styles.LoadDir(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("got %s, want no error", err)
+	}
+}
+
+func TestLoadDirInvalidStyle(t *testing.T) {
+	testCases := []string{
+		`{{define "name"}}Incomplete{{end}}`,
+		`{{define "name"}}Two words{{end}}{{define "description"}}x{{end}}{{define "prompt"}}x{{end}}`,
+		`{{define "name"}}`,
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "invalid.tmpl"), []byte(tc), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			styles, err := style.Builtin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := styles.LoadDir(dir); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGetUnknownStyle(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := styles.Get("Unknown", style.Data{}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestGetLanguage(t *testing.T) {
+	styles, err := style.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		language string
+		expected string
+	}{
+		{language: "", expected: ""},
+		{language: "sv", expected: "sv"},
+		{language: "de", expected: "de"},
+		{language: "fr", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.language, func(t *testing.T) {
+			s, err := styles.Get("DescriptiveAndNeutral", style.Data{Language: tc.language})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Examples[0].Language != tc.expected {
+				t.Errorf("got example in %q, want %q", s.Examples[0].Language, tc.expected)
+			}
+		})
+	}
+}
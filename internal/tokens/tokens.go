@@ -0,0 +1,45 @@
+/*
+Package tokens estimates the number of tokens a text uses in a prompt.
+
+The estimate is based on the rule of thumb that a token is about four
+characters of English text (see
+https://help.openai.com/en/articles/4936856-what-are-tokens-and-how-to-count-them).
+It is good enough for budgeting, not for billing.
+*/
+package tokens
+
+import "strings"
+
+const charsPerToken = 4
+
+// TruncatedMarker is appended to text shortened by Truncate.
+const TruncatedMarker = "\n[... truncated]"
+
+// Estimate returns the estimated number of tokens in s.
+func Estimate(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// Truncate shortens s to about maxTokens tokens, cutting at a line break
+// when possible. An empty string is returned if maxTokens is not positive.
+func Truncate(s string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	if Estimate(s) <= maxTokens {
+		return s
+	}
+
+	maxChars := maxTokens*charsPerToken - len(TruncatedMarker)
+	if maxChars <= 0 {
+		return ""
+	}
+
+	cut := s[:maxChars]
+	if idx := strings.LastIndex(cut, "\n"); idx > 0 {
+		cut = cut[:idx]
+	}
+
+	return cut + TruncatedMarker
+}
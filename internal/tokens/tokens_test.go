@@ -0,0 +1,52 @@
+package tokens_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/tokens"
+)
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		s        string
+		expected int
+	}{
+		{s: "", expected: 0},
+		{s: "a", expected: 1},
+		{s: "abcd", expected: 1},
+		{s: "abcde", expected: 2},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.s, func(t *testing.T) {
+			if got := tokens.Estimate(tc.s); got != tc.expected {
+				t.Errorf("got %d, want %d", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	s := strings.Repeat("0123456789\n", 100)
+
+	got := tokens.Truncate(s, 50)
+
+	if tokens.Estimate(got) > 50 {
+		t.Errorf("got %d tokens, want at most 50", tokens.Estimate(got))
+	}
+
+	if !strings.HasSuffix(got, "0123456789"+tokens.TruncatedMarker) {
+		t.Errorf("expected the text to be cut at a line break, got %q", got)
+	}
+
+	if tokens.Truncate(s, 1000) != s {
+		t.Error("expected text within the limit to be unchanged")
+	}
+
+	if tokens.Truncate(s, 0) != "" {
+		t.Error("expected empty string for zero tokens")
+	}
+}
@@ -0,0 +1,85 @@
+package trailer
+
+import (
+	"sort"
+	"strings"
+)
+
+// Config decides which trailers are added to the message.
+type Config struct {
+	// SignOff adds "Signed-off-by" with the identity of the committer, like
+	// "git commit --signoff".
+	SignOff bool `json:"signOff,omitempty"`
+
+	// CoAuthors are the people currently pairing, e.g. "Jane Doe
+	// <jane@example.com>". A "Co-authored-by" trailer is added for each.
+	CoAuthors []string `json:"coAuthors,omitempty"`
+
+	// SuggestCoAuthors suggests "Co-authored-by" trailers for the people
+	// the committer has recently co-authored commits with.
+	SuggestCoAuthors bool `json:"suggestCoAuthors,omitempty"`
+
+	// Custom are trailers that are always added, e.g. "Reviewed-by".
+	Custom []Trailer `json:"custom,omitempty"`
+}
+
+// Trailers returns the trailers to add to the message. identity is the
+// committer, e.g. "Jane Doe <jane@example.com>", used for "Signed-off-by".
+func (c Config) Trailers(identity string) []Trailer {
+	trailers := []Trailer{}
+
+	for _, coAuthor := range c.CoAuthors {
+		trailers = append(trailers, Trailer{Key: CoAuthoredBy, Value: coAuthor})
+	}
+
+	trailers = append(trailers, c.Custom...)
+
+	if c.SignOff && identity != "" {
+		trailers = append(trailers, Trailer{Key: SignedOffBy, Value: identity})
+	}
+
+	return trailers
+}
+
+// SuggestCoAuthors returns the co-authors of the recent commit messages,
+// most frequent first. Co-authors equal to one of the excluded (e.g. the
+// committer or the configured co-authors) are left out.
+func SuggestCoAuthors(messages []string, exclude []string, limit int) []string {
+	counts := map[string]int{}
+	order := []string{}
+
+	for _, message := range messages {
+		for _, coAuthor := range Values(Parse(message), CoAuthoredBy) {
+			if containsFold(exclude, coAuthor) {
+				continue
+			}
+
+			if counts[coAuthor] == 0 {
+				order = append(order, coAuthor)
+			}
+
+			counts[coAuthor]++
+		}
+	}
+
+	// Ties keep the order of the messages, i.e. the most recent first.
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	return order
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,178 @@
+/*
+Package trailer reads and writes commit message trailers, such as
+"Signed-off-by: Jane Doe <jane@example.com>", following the rules of git
+interpret-trailers.
+
+The trailers are the last paragraph of the message. A paragraph is a trailer
+block if all its lines are trailers (or continuation lines, starting with
+whitespace), or if at least 25% of its lines are trailers and one of them is
+generated by git, e.g. "Signed-off-by".
+*/
+package trailer
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	SignedOffBy  = "Signed-off-by"
+	CoAuthoredBy = "Co-authored-by"
+)
+
+// Trailer is a single "Key: value" trailer.
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// String returns the trailer formatted as "Key: value".
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// Equal returns true if the trailers have the same key, ignoring case, and
+// the same value.
+func (t Trailer) Equal(other Trailer) bool {
+	return strings.EqualFold(t.Key, other.Key) && t.Value == other.Value
+}
+
+//nolint:gochecknoglobals
+var (
+	// BREAKING CHANGE is not a trailer to git, but it is in conventional
+	// commits.
+	trailerRegexp = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*)\s*:\s*(.*)$`)
+
+	gitGeneratedPrefixes = []string{SignedOffBy + ": ", "(cherry picked from commit "}
+)
+
+// Parse returns the trailers of the message.
+func Parse(message string) []Trailer {
+	_, trailers := Split(message)
+
+	return trailers
+}
+
+// Split splits the message into the part before the trailer block and the
+// trailers. The message must be without comments, e.g. read with package
+// commitfile, since the comment char depends on the repository.
+func Split(message string) (body string, trailers []Trailer) {
+	lines := []string{}
+
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start := len(lines)
+	for start > 0 && lines[start-1] != "" {
+		start--
+	}
+
+	// The first paragraph is the subject, never trailers.
+	if start == 0 {
+		return strings.Join(lines, "\n"), nil
+	}
+
+	trailers, ok := parseBlock(lines[start:])
+	if !ok {
+		return strings.Join(lines, "\n"), nil
+	}
+
+	return strings.TrimRight(strings.Join(lines[:start], "\n"), "\n"), trailers
+}
+
+func parseBlock(lines []string) ([]Trailer, bool) {
+	trailers := []Trailer{}
+	nonTrailers := 0
+	gitGenerated := false
+
+	for _, line := range lines {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(trailers) > 0 {
+			trailers[len(trailers)-1].Value += "\n" + line
+			continue
+		}
+
+		for _, prefix := range gitGeneratedPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				gitGenerated = true
+			}
+		}
+
+		matches := trailerRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			nonTrailers++
+			continue
+		}
+
+		trailers = append(trailers, Trailer{Key: matches[1], Value: matches[2]})
+	}
+
+	if len(trailers) == 0 {
+		return nil, false
+	}
+
+	if nonTrailers == 0 || gitGenerated && len(trailers)*4 >= len(trailers)+nonTrailers {
+		return trailers, true
+	}
+
+	return nil, false
+}
+
+// Append adds the trailers to the trailer block of the message, creating the
+// block if needed. A trailer equal to one already in the message is not
+// added again.
+func Append(message string, trailers ...Trailer) string {
+	body, existing := Split(message)
+
+	for _, t := range trailers {
+		if !containsTrailer(existing, t) {
+			existing = append(existing, t)
+		}
+	}
+
+	switch {
+	case len(existing) == 0:
+		return body
+	case body == "":
+		return Format(existing)
+	default:
+		return body + "\n\n" + Format(existing)
+	}
+}
+
+// Format returns the trailers on one line each.
+func Format(trailers []Trailer) string {
+	lines := make([]string, 0, len(trailers))
+	for _, t := range trailers {
+		lines = append(lines, t.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Values returns the values of the trailers with the key, ignoring case.
+func Values(trailers []Trailer, key string) []string {
+	values := []string{}
+
+	for _, t := range trailers {
+		if strings.EqualFold(t.Key, key) {
+			values = append(values, t.Value)
+		}
+	}
+
+	return values
+}
+
+func containsTrailer(trailers []Trailer, t Trailer) bool {
+	for _, existing := range trailers {
+		if existing.Equal(t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package trailer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philiplinell/commit-msg/internal/trailer"
+)
+
+func TestSplit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		message  string
+		body     string
+		trailers []trailer.Trailer
+	}{
+		{
+			name:    "subject only",
+			message: "Signed-off-by: Jane <jane@example.com>",
+			body:    "Signed-off-by: Jane <jane@example.com>",
+		},
+		{
+			name:    "all trailers",
+			message: "Add login\n\nBody\n\nRefs: PROJ-1\nCo-authored-by: John <john@example.com>\n  continued\n",
+			body:    "Add login\n\nBody",
+			trailers: []trailer.Trailer{
+				{Key: "Refs", Value: "PROJ-1"},
+				{Key: "Co-authored-by", Value: "John <john@example.com>\n  continued"},
+			},
+		},
+		{
+			name:     "git generated with other lines",
+			message:  "Add login\n\nSome text\nmore text\nSigned-off-by: Jane <jane@example.com>\n# comment",
+			body:     "Add login",
+			trailers: []trailer.Trailer{{Key: "Signed-off-by", Value: "Jane <jane@example.com>"}},
+		},
+		{
+			name:     "body line starting with a reference",
+			message:  "Fix crash\n\n#123 is fixed by this\n\nRefs: #123",
+			body:     "Fix crash\n\n#123 is fixed by this",
+			trailers: []trailer.Trailer{{Key: "Refs", Value: "#123"}},
+		},
+		{
+			name:    "not a trailer block",
+			message: "Add login\n\nSome text\nRefs: PROJ-1",
+			body:    "Add login\n\nSome text\nRefs: PROJ-1",
+		},
+		{
+			name:     "only trailers in commit file",
+			message:  "\n\nSigned-off-by: Jane <jane@example.com>\n",
+			body:     "",
+			trailers: []trailer.Trailer{{Key: "Signed-off-by", Value: "Jane <jane@example.com>"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run(tc.name, func(t *testing.T) {
+			body, trailers := trailer.Split(tc.message)
+
+			if body != tc.body {
+				t.Errorf("got body %q, want %q", body, tc.body)
+			}
+
+			if !reflect.DeepEqual(trailers, tc.trailers) {
+				t.Errorf("got trailers %v, want %v", trailers, tc.trailers)
+			}
+		})
+	}
+}
+
+func TestAppend(t *testing.T) {
+	signOff := trailer.Trailer{Key: trailer.SignedOffBy, Value: "Jane <jane@example.com>"}
+
+	testCases := []struct {
+		message  string
+		expected string
+	}{
+		{
+			message:  "Add login",
+			expected: "Add login\n\nSigned-off-by: Jane <jane@example.com>",
+		},
+		{
+			message:  "Add login\n\nBody\n\nRefs: PROJ-1",
+			expected: "Add login\n\nBody\n\nRefs: PROJ-1\nSigned-off-by: Jane <jane@example.com>",
+		},
+		{
+			message:  "Add login\n\nsigned-off-by: Jane <jane@example.com>",
+			expected: "Add login\n\nsigned-off-by: Jane <jane@example.com>",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // capture range variable
+
+		t.Run("", func(t *testing.T) {
+			if got := trailer.Append(tc.message, signOff); got != tc.expected {
+				t.Errorf("got %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSuggestCoAuthors(t *testing.T) {
+	messages := []string{
+		"Fix\n\nCo-authored-by: A <a@example.com>",
+		"Add\n\nCo-authored-by: B <b@example.com>\nCo-authored-by: Me <me@example.com>",
+		"Change\n\nCo-authored-by: B <b@example.com>",
+		"Remove\n\nCo-authored-by: C <c@example.com>",
+	}
+
+	got := trailer.SuggestCoAuthors(messages, []string{"Me <me@example.com>"}, 2)
+
+	expected := []string{"B <b@example.com>", "A <a@example.com>"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}